@@ -2,30 +2,49 @@ package main
 
 import (
 	"context"
-	"log"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/sj1815/golang-country-search/internal/config"
+	"github.com/sj1815/golang-country-search/internal/logging"
 	"github.com/sj1815/golang-country-search/internal/router"
 )
 
 // main is the entry point of the application.
 func main() {
-	// Load application configuration (server port, timeouts, etc.)
-	cfg := config.DefaultConfig()
+	// Load application configuration from defaults, config file, environment and flags
+	cfg, err := config.Load(os.Args[1:], os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
 
 	// Initialize application dependencies (handlers, services, clients, caches)
-	deps := config.InitDependencies(cfg)
+	deps, err := config.InitDependencies(cfg)
+	if err != nil {
+		slog.Error("failed to initialize dependencies", logging.Error(err))
+		os.Exit(1)
+	}
+	logger := deps.Logger
+	slog.SetDefault(logger)
 
-	// Create HTTP router with all registered routes
-	mux := router.NewRouter(deps.CountryHandler)
+	// Create HTTP router with all registered routes wrapped in the configured middleware
+	handler := router.NewRouter(deps.CountryHandler, deps.RouterOptions()...)
 
 	server := &http.Server{
 		Addr:         cfg.ServerPort,
-		Handler:      mux,
+		Handler:      handler,
 		ReadTimeout:  cfg.ServerReadTimeout,
 		WriteTimeout: cfg.ServerWriteTimeout,
 	}
@@ -35,10 +54,52 @@ func main() {
 
 	// Start server in a separate goroutine
 	go func() {
-		log.Printf("Server starting on port %s", cfg.ServerPort)
+		logger.Info("server starting", slog.String("addr", cfg.ServerPort))
 		serverErrors <- server.ListenAndServe()
 	}()
 
+	// Background cache jobs stop once shutdown begins
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
+	// Warm the cache; readiness fails until the warm-up finishes or times out
+	go deps.WarmUp(backgroundCtx)
+
+	// Snapshot the cache periodically; deps.Close takes a final snapshot at shutdown
+	go deps.RunSnapshots(backgroundCtx)
+
+	// Remove expired entries that are never read again
+	go deps.RunExpirySweep(backgroundCtx)
+
+	// Keep the cache peers in sync with DNS when they are discovered through SRV records
+	go deps.DiscoverPeers(backgroundCtx)
+
+	// Reload the reloadable settings on SIGHUP and, if enabled, whenever the config file changes
+	reloader := config.NewReloader(cfg, deps, func() (*config.Config, error) {
+		return config.Load(os.Args[1:], os.LookupEnv)
+	})
+
+	reloadCtx, stopReloading := context.WithCancel(context.Background())
+	defer stopReloading()
+
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	go func() {
+		for {
+			select {
+			case <-reloadCtx.Done():
+				return
+			case <-hangup:
+				logger.Info("SIGHUP received, reloading configuration")
+				_ = reloader.Reload()
+			}
+		}
+	}()
+
+	if cfg.File != "" && cfg.Reload.WatchInterval > 0 {
+		go reloader.Watch(reloadCtx, cfg.File, cfg.Reload.WatchInterval)
+	}
+
 	shutdown := make(chan os.Signal, 1)
 	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
 
@@ -46,22 +107,57 @@ func main() {
 	select {
 	// Listen for server errors
 	case err := <-serverErrors:
-		log.Fatalf("Server error: %v", err)
+		logger.Error("server error", logging.Error(err))
+		stopReloading()
+		stopBackground()
+		closeDependencies(logger, deps)
+		os.Exit(1)
 	// Listen for shutdown signal
 	case sig := <-shutdown:
-		log.Printf("Shutdown signal received: %v", sig)
+		logger.Info("shutdown signal received", slog.String("signal", sig.String()))
+		stopReloading()
+		stopBackground()
+
+		// Fail readiness first and give load balancers time to stop routing new requests here.
+		deps.Health.SetDraining()
+		if cfg.Health.DrainDelay > 0 {
+			logger.Info("draining before shutdown", slog.Duration("delay", cfg.Health.DrainDelay))
+			time.Sleep(cfg.Health.DrainDelay)
+		}
 
 		ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 		defer cancel()
 
+		forceFailed := false
 		if err := server.Shutdown(ctx); err != nil {
-			log.Printf("Graceful shutdown failed: %v", err)
+			logger.Warn("graceful shutdown failed", logging.Error(err))
 
 			if err := server.Close(); err != nil {
-				log.Fatalf("Forced shutdown failed: %v", err)
+				logger.Error("forced shutdown failed", logging.Error(err))
+				forceFailed = true
 			}
 		}
 
-		log.Println("Server stopped gracefully")
+		// Save the cache snapshot and flush traces even if the server did not stop cleanly.
+		closeDependencies(logger, deps)
+		if forceFailed {
+			os.Exit(1)
+		}
+
+		logger.Info("server stopped gracefully")
+	}
+}
+
+// closeTimeout bounds releasing dependencies at exit, after the server shutdown has used
+// up its own timeout.
+const closeTimeout = 5 * time.Second
+
+// closeDependencies releases deps with a fresh timeout, logging any failure.
+func closeDependencies(logger *slog.Logger, deps *config.Dependencies) {
+	ctx, cancel := context.WithTimeout(context.Background(), closeTimeout)
+	defer cancel()
+
+	if err := deps.Close(ctx); err != nil {
+		logger.Warn("failed to release dependencies", logging.Error(err))
 	}
 }
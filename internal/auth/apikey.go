@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// APIKeyHeader is the header clients may send their API key in instead of Authorization.
+const APIKeyHeader = "X-API-Key"
+
+// APIKey is a configured API key. Exactly one of Key and Hash must be set; prefer Hash so
+// the key itself never has to be stored.
+type APIKey struct {
+	ID   string `yaml:"id" toml:"id"`
+	Name string `yaml:"name" toml:"name"`
+	// Key is the plaintext key.
+	Key string `yaml:"key" toml:"key"`
+	// Hash is the hex-encoded SHA-256 of the key, as produced by HashKey.
+	Hash   string   `yaml:"hash" toml:"hash"`
+	Scopes []string `yaml:"scopes" toml:"scopes"`
+	Tier   string   `yaml:"tier" toml:"tier"`
+}
+
+// String describes the key without revealing it, so keys can be logged safely.
+func (k APIKey) String() string {
+	return fmt.Sprintf("{id:%s scopes:%v tier:%s}", k.ID, k.Scopes, k.Tier)
+}
+
+// HashKey returns the hex-encoded SHA-256 of key.
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// LoadKeysFile reads a YAML list of API keys from path.
+func LoadKeysFile(path string) ([]APIKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("LoadKeysFile: failed to read keys file: %w", err)
+	}
+
+	var keys []APIKey
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&keys); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("LoadKeysFile: failed to parse %s: %w", path, err)
+	}
+
+	return keys, nil
+}
+
+// KeyStore authenticates requests by API key. Only key hashes are kept in memory.
+type KeyStore struct {
+	byHash map[string]*Identity
+}
+
+// NewKeyStore builds a store from keys, reporting every invalid or duplicate entry.
+func NewKeyStore(keys []APIKey) (*KeyStore, error) {
+	s := &KeyStore{byHash: make(map[string]*Identity, len(keys))}
+	ids := make(map[string]bool, len(keys))
+
+	var errs []error
+	for i, k := range keys {
+		label := k.ID
+		if label == "" {
+			label = fmt.Sprintf("#%d", i)
+		}
+
+		hash, err := keyHash(k)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("key %s: %w", label, err))
+			continue
+		}
+
+		switch {
+		case k.ID == "":
+			errs = append(errs, fmt.Errorf("key %s: id is required", label))
+		case ids[k.ID]:
+			errs = append(errs, fmt.Errorf("key %s: duplicate id", label))
+		case s.byHash[hash] != nil:
+			errs = append(errs, fmt.Errorf("key %s: same key as %s", label, s.byHash[hash].ID))
+		default:
+			ids[k.ID] = true
+			s.byHash[hash] = &Identity{
+				ID:     k.ID,
+				Name:   k.Name,
+				Scopes: append([]string(nil), k.Scopes...),
+				Tier:   k.Tier,
+				Method: MethodAPIKey,
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("NewKeyStore: %w", errors.Join(errs...))
+	}
+	return s, nil
+}
+
+// Len returns the number of keys in the store.
+func (s *KeyStore) Len() int {
+	return len(s.byHash)
+}
+
+// Authenticate looks up the key sent in "Authorization: Bearer" or X-API-Key.
+func (s *KeyStore) Authenticate(r *http.Request) (*Identity, error) {
+	key, ok := BearerToken(r)
+	if !ok {
+		key = strings.TrimSpace(r.Header.Get(APIKeyHeader))
+	}
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+
+	id, ok := s.byHash[HashKey(key)]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown API key", ErrInvalidCredentials)
+	}
+	return id, nil
+}
+
+// keyHash returns the normalised hash of k, validating that exactly one of Key and Hash is set.
+func keyHash(k APIKey) (string, error) {
+	switch {
+	case k.Key != "" && k.Hash != "":
+		return "", errors.New("set either key or hash, not both")
+	case k.Key != "":
+		return HashKey(k.Key), nil
+	case k.Hash != "":
+		hash := strings.ToLower(strings.TrimSpace(k.Hash))
+		if b, err := hex.DecodeString(hash); err != nil || len(b) != sha256.Size {
+			return "", errors.New("hash must be a hex-encoded SHA-256 digest")
+		}
+		return hash, nil
+	default:
+		return "", errors.New("key or hash is required")
+	}
+}
@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHashKey tests that keys hash to hex-encoded SHA-256.
+func TestHashKey(t *testing.T) {
+	assert.Equal(t, "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08", HashKey("test"))
+}
+
+// TestKeyStore_Authenticate tests authenticating by bearer token and X-API-Key header.
+func TestKeyStore_Authenticate(t *testing.T) {
+	store, err := NewKeyStore([]APIKey{
+		{ID: "dashboard", Key: "plain-key", Scopes: []string{ScopeCountriesRead}, Tier: "pro"},
+		{ID: "ops", Hash: HashKey("hashed-key"), Scopes: []string{ScopeCountriesRead, ScopeAdminCache}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, store.Len())
+
+	t.Run("bearer token", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Authorization", "Bearer plain-key")
+
+		id, err := store.Authenticate(r)
+		require.NoError(t, err)
+		assert.Equal(t, "dashboard", id.ID)
+		assert.Equal(t, "pro", id.Tier)
+		assert.Equal(t, MethodAPIKey, id.Method)
+	})
+
+	t.Run("api key header with hashed key", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set(APIKeyHeader, "hashed-key")
+
+		id, err := store.Authenticate(r)
+		require.NoError(t, err)
+		assert.Equal(t, "ops", id.ID)
+		assert.True(t, id.HasScope(ScopeAdminCache))
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set(APIKeyHeader, "nope")
+
+		_, err := store.Authenticate(r)
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	})
+
+	t.Run("no credentials", func(t *testing.T) {
+		_, err := store.Authenticate(httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.ErrorIs(t, err, ErrNoCredentials)
+	})
+}
+
+// TestNewKeyStore_Invalid tests that every invalid key is reported.
+func TestNewKeyStore_Invalid(t *testing.T) {
+	_, err := NewKeyStore([]APIKey{
+		{ID: "a", Key: "k1"},
+		{ID: "a", Key: "k2"},
+		{ID: "b", Key: "k1"},
+		{ID: "c"},
+		{ID: "d", Key: "k3", Hash: HashKey("k3")},
+		{ID: "e", Hash: "not-hex"},
+		{Key: "k4"},
+	})
+	require.Error(t, err)
+
+	for _, want := range []string{
+		"key a: duplicate id",
+		"key b: same key as a",
+		"key c: key or hash is required",
+		"key d: set either key or hash",
+		"key e: hash must be",
+		"key #6: id is required",
+	} {
+		assert.Contains(t, err.Error(), want)
+	}
+}
+
+// TestLoadKeysFile tests reading hashed keys from disk.
+func TestLoadKeysFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(fmt.Sprintf(`
+- id: dashboard
+  name: Web dashboard
+  hash: %s
+  scopes: [countries:read]
+  tier: free
+`, HashKey("secret"))), 0o600))
+
+	keys, err := LoadKeysFile(path)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, "dashboard", keys[0].ID)
+	assert.Equal(t, []string{ScopeCountriesRead}, keys[0].Scopes)
+
+	require.NoError(t, os.WriteFile(path, []byte("- id: x\n  secret: y\n"), 0o600))
+	_, err = LoadKeysFile(path)
+	assert.Error(t, err)
+}
+
+// TestAPIKey_String tests that formatting a key never reveals it.
+func TestAPIKey_String(t *testing.T) {
+	keys := []APIKey{{ID: "dashboard", Key: "super-secret", Scopes: []string{ScopeCountriesRead}}}
+
+	assert.NotContains(t, fmt.Sprint(keys), "super-secret")
+	assert.Contains(t, fmt.Sprint(keys), "dashboard")
+}
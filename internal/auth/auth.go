@@ -0,0 +1,102 @@
+// Package auth authenticates API clients and carries their identity through request contexts.
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Scopes granted to clients.
+const (
+	ScopeCountriesRead = "countries:read"
+	ScopeAdminCache    = "admin:cache"
+)
+
+// Authentication methods recorded on an Identity.
+const (
+	MethodAPIKey = "api_key"
+	MethodJWT    = "jwt"
+)
+
+var (
+	// ErrNoCredentials means the request carried no credentials this authenticator understands.
+	ErrNoCredentials = errors.New("no credentials")
+	// ErrInvalidCredentials means the request carried credentials that were rejected.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Identity describes an authenticated client.
+type Identity struct {
+	// ID identifies the client in logs and metrics. It is never a secret.
+	ID string
+	// Name is a human-readable description of the client.
+	Name string
+	// Scopes lists the permissions granted to the client.
+	Scopes []string
+	// Tier selects the client's rate limit; empty means the route default.
+	Tier string
+	// Method is how the client authenticated, such as MethodAPIKey.
+	Method string
+}
+
+// HasScope reports whether the identity was granted scope.
+func (id *Identity) HasScope(scope string) bool {
+	return id != nil && slices.Contains(id.Scopes, scope)
+}
+
+// Authenticator identifies the client that sent a request. It returns ErrNoCredentials when
+// the request carries nothing it recognises and an error wrapping ErrInvalidCredentials when
+// the credentials are wrong.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Identity, error)
+}
+
+// Chain is an Authenticator that tries each authenticator in turn and returns the first
+// identity found. When none succeeds it returns the first rejection, or ErrNoCredentials if
+// no authenticator recognised the request.
+type Chain []Authenticator
+
+// Authenticate implements Authenticator.
+func (c Chain) Authenticate(r *http.Request) (*Identity, error) {
+	var rejected error
+	for _, a := range c {
+		id, err := a.Authenticate(r)
+		switch {
+		case err == nil:
+			return id, nil
+		case errors.Is(err, ErrNoCredentials):
+		case rejected == nil:
+			rejected = err
+		}
+	}
+	if rejected != nil {
+		return nil, rejected
+	}
+	return nil, ErrNoCredentials
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the identity.
+func NewContext(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the identity stored in ctx, or nil if the request is unauthenticated.
+func FromContext(ctx context.Context) *Identity {
+	id, _ := ctx.Value(contextKey{}).(*Identity)
+	return id
+}
+
+// BearerToken returns the token from an "Authorization: Bearer" header, if present.
+func BearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestIdentity_HasScope tests scope checks, including on a nil identity.
+func TestIdentity_HasScope(t *testing.T) {
+	id := &Identity{ID: "dashboard", Scopes: []string{ScopeCountriesRead}}
+
+	assert.True(t, id.HasScope(ScopeCountriesRead))
+	assert.False(t, id.HasScope(ScopeAdminCache))
+
+	var none *Identity
+	assert.False(t, none.HasScope(ScopeCountriesRead))
+}
+
+// TestContext tests storing and retrieving an identity.
+func TestContext(t *testing.T) {
+	assert.Nil(t, FromContext(context.Background()))
+
+	id := &Identity{ID: "dashboard"}
+	assert.Same(t, id, FromContext(NewContext(context.Background(), id)))
+}
+
+// TestBearerToken tests extracting bearer tokens from the Authorization header.
+func TestBearerToken(t *testing.T) {
+	tests := []struct {
+		header string
+		token  string
+		ok     bool
+	}{
+		{"Bearer abc123", "abc123", true},
+		{"bearer  abc123 ", "abc123", true},
+		{"Basic dXNlcjpwYXNz", "", false},
+		{"Bearer ", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Authorization", tt.header)
+
+		token, ok := BearerToken(r)
+		assert.Equal(t, tt.token, token, tt.header)
+		assert.Equal(t, tt.ok, ok, tt.header)
+	}
+}
+
+// TestChain tests that the first identity wins and rejections take precedence over missing credentials.
+func TestChain(t *testing.T) {
+	store, err := NewKeyStore([]APIKey{{ID: "dashboard", Key: "plain-key"}})
+	require.NoError(t, err)
+	none := authFunc(func(*http.Request) (*Identity, error) { return nil, ErrNoCredentials })
+	chain := Chain{none, store}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	_, err = chain.Authenticate(r)
+	assert.ErrorIs(t, err, ErrNoCredentials)
+
+	r.Header.Set(APIKeyHeader, "wrong")
+	_, err = chain.Authenticate(r)
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	r.Header.Set(APIKeyHeader, "plain-key")
+	id, err := chain.Authenticate(r)
+	require.NoError(t, err)
+	assert.Equal(t, "dashboard", id.ID)
+}
+
+type authFunc func(*http.Request) (*Identity, error)
+
+func (f authFunc) Authenticate(r *http.Request) (*Identity, error) { return f(r) }
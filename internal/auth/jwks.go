@@ -0,0 +1,358 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults for JWKSOptions.
+const (
+	DefaultJWKSRefreshInterval    = time.Hour
+	DefaultJWKSMinRefreshInterval = time.Minute
+)
+
+// maxJWKSSize bounds the key set document read from a file or URL.
+const maxJWKSSize = 1 << 20
+
+// JWKSOptions configures a JWKS.
+type JWKSOptions struct {
+	// Client fetches key sets from http and https sources. Defaults to a client with a 10s timeout.
+	Client *http.Client
+	// RefreshInterval is how long fetched keys are used before the set is fetched again.
+	// Defaults to DefaultJWKSRefreshInterval.
+	RefreshInterval time.Duration
+	// MinRefreshInterval limits how often lookups fetch the set, whether it is stale or a
+	// token is signed by an unknown key. Defaults to DefaultJWKSMinRefreshInterval;
+	// negative disables the limit.
+	MinRefreshInterval time.Duration
+	// Logger receives a warning when a refresh fails and stale keys stay in use.
+	// Defaults to slog.Default().
+	Logger *slog.Logger
+}
+
+// JWKS is a JSON Web Key Set (RFC 7517) read from a file or an http(s) URL. Keys are loaded
+// on first use, refreshed periodically, and refreshed early when a token names a key the set
+// does not have, so signing keys can be rotated without a restart. Concurrent lookups share
+// one fetch, which runs without holding the lock, so lookups against the cached keys never
+// wait for the network. Only RSA keys and EC keys on P-256 are used; other keys in the set
+// are ignored.
+type JWKS struct {
+	source     string
+	client     *http.Client
+	refresh    time.Duration
+	minRefresh time.Duration
+	logger     *slog.Logger
+	now        func() time.Time
+
+	mu        sync.Mutex
+	keys      []jsonWebKey
+	fetched   time.Time
+	attempted time.Time
+	// err is the outcome of the last fetch.
+	err error
+	// loading is the fetch in flight, if any.
+	loading *jwksLoad
+}
+
+// jwksLoad is a fetch of the key set that lookups can wait for.
+type jwksLoad struct {
+	started time.Time
+	done    chan struct{}
+	err     error
+}
+
+// jsonWebKey is a parsed signing key from the set.
+type jsonWebKey struct {
+	kid string
+	alg string
+	key crypto.PublicKey
+}
+
+// NewJWKS returns a key set read from source, a file path or an http(s) URL. Nothing is
+// read until the first lookup or Refresh.
+func NewJWKS(source string, opts JWKSOptions) *JWKS {
+	k := &JWKS{
+		source:     source,
+		client:     opts.Client,
+		refresh:    opts.RefreshInterval,
+		minRefresh: opts.MinRefreshInterval,
+		logger:     opts.Logger,
+		now:        time.Now,
+	}
+	if k.client == nil {
+		k.client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if k.refresh <= 0 {
+		k.refresh = DefaultJWKSRefreshInterval
+	}
+	if k.minRefresh == 0 {
+		k.minRefresh = DefaultJWKSMinRefreshInterval
+	}
+	if k.logger == nil {
+		k.logger = slog.Default()
+	}
+	return k
+}
+
+// IsURL reports whether source is fetched over HTTP rather than read from a file.
+func IsURL(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// Refresh reads the key set from its source now, replacing the cached keys on success. It
+// joins a fetch that is already in flight instead of starting another.
+func (k *JWKS) Refresh(ctx context.Context) error {
+	return k.load(ctx, true)
+}
+
+// Len returns the number of usable keys currently cached.
+func (k *JWKS) Len() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return len(k.keys)
+}
+
+// key returns the public key for a token header's kid and alg, refreshing the set when it
+// is stale or does not contain a matching key.
+func (k *JWKS) key(ctx context.Context, kid, alg string) (crypto.PublicKey, error) {
+	k.mu.Lock()
+	stale := k.fetched.IsZero() || k.now().Sub(k.fetched) >= k.refresh
+	k.mu.Unlock()
+
+	if stale {
+		if err := k.load(ctx, false); err != nil && k.Len() == 0 {
+			return nil, err
+		}
+	}
+
+	if key, ok := k.find(kid, alg); ok {
+		return key, nil
+	}
+
+	// The signing key may have been rotated since the last fetch.
+	if err := k.load(ctx, false); err != nil {
+		return nil, err
+	}
+	if key, ok := k.find(kid, alg); ok {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("%w: no %s signing key with id %q", ErrInvalidCredentials, alg, kid)
+}
+
+// find returns the cached key matching kid and usable with alg. Tokens without a kid match
+// any suitable key when the set holds exactly one.
+func (k *JWKS) find(kid, alg string) (crypto.PublicKey, bool) {
+	k.mu.Lock()
+	keys := k.keys
+	k.mu.Unlock()
+
+	var match crypto.PublicKey
+	matches := 0
+	for _, key := range keys {
+		if (key.alg != "" && key.alg != alg) || !keyFitsAlg(key.key, alg) {
+			continue
+		}
+		if kid != "" && key.kid == kid {
+			return key.key, true
+		}
+		if kid == "" {
+			match = key.key
+			matches++
+		}
+	}
+	return match, matches == 1
+}
+
+// load waits for a fetch of the key set, starting one unless one is already in flight. Unless
+// force is set, no fetch starts within minRefresh of the last attempt and load returns that
+// attempt's error instead, so a failing source is not fetched on every lookup. The fetch is
+// detached from ctx, so a lookup that gives up does not fail the others waiting for it.
+func (k *JWKS) load(ctx context.Context, force bool) error {
+	k.mu.Lock()
+	call := k.loading
+	if call == nil {
+		now := k.now()
+		if !force && !k.attempted.IsZero() && k.minRefresh >= 0 && now.Sub(k.attempted) < k.minRefresh {
+			err := k.err
+			k.mu.Unlock()
+			return err
+		}
+		k.attempted = now
+		call = &jwksLoad{started: now, done: make(chan struct{})}
+		k.loading = call
+		go k.fetch(context.WithoutCancel(ctx), call)
+	}
+	k.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// fetch reads and parses the key set for call, swapping the new keys in on success.
+func (k *JWKS) fetch(ctx context.Context, call *jwksLoad) {
+	var keys []jsonWebKey
+	data, err := k.read(ctx)
+	if err != nil {
+		err = fmt.Errorf("JWKS: failed to read %s: %w", k.source, err)
+	} else if keys, err = parseJWKS(data); err != nil {
+		err = fmt.Errorf("JWKS: failed to parse %s: %w", k.source, err)
+	}
+
+	k.mu.Lock()
+	if err == nil {
+		k.keys = keys
+		k.fetched = call.started
+	}
+	k.err = err
+	k.loading = nil
+	cached := len(k.keys)
+	k.mu.Unlock()
+
+	if err != nil && cached > 0 {
+		k.logger.WarnContext(ctx, "failed to refresh JWKS, using cached keys",
+			slog.String("source", k.source), slog.String("error", err.Error()))
+	}
+	call.err = err
+	close(call.done)
+}
+
+// read returns the raw key set document from the file or URL.
+func (k *JWKS) read(ctx context.Context) ([]byte, error) {
+	if !IsURL(k.source) {
+		f, err := os.Open(k.source)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return io.ReadAll(io.LimitReader(f, maxJWKSSize))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, k.source, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxJWKSSize))
+}
+
+// parseJWKS decodes a key set document, keeping the RSA and P-256 signing keys.
+func parseJWKS(data []byte) ([]jsonWebKey, error) {
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			Alg string `json:"alg"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+
+	var keys []jsonWebKey
+	var errs []error
+	for i, raw := range set.Keys {
+		if raw.Use != "" && raw.Use != "sig" {
+			continue
+		}
+
+		var key crypto.PublicKey
+		var err error
+		switch {
+		case raw.Kty == "RSA":
+			key, err = rsaKey(raw.N, raw.E)
+		case raw.Kty == "EC" && raw.Crv == "P-256":
+			key, err = ecKey(raw.X, raw.Y)
+		default:
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("key %d (%s): %w", i, raw.Kid, err))
+			continue
+		}
+		keys = append(keys, jsonWebKey{kid: raw.Kid, alg: raw.Alg, key: key})
+	}
+
+	if len(keys) == 0 {
+		errs = append(errs, errors.New("no usable RSA or P-256 signing keys"))
+		return nil, errors.Join(errs...)
+	}
+	return keys, nil
+}
+
+func rsaKey(n, e string) (*rsa.PublicKey, error) {
+	nb, err := base64.RawURLEncoding.DecodeString(n)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus: %w", err)
+	}
+	eb, err := base64.RawURLEncoding.DecodeString(e)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent: %w", err)
+	}
+	exp := new(big.Int).SetBytes(eb)
+	if len(nb) == 0 || !exp.IsInt64() || exp.Int64() < 3 || exp.Int64() > 1<<31-1 {
+		return nil, errors.New("invalid RSA key parameters")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(nb), E: int(exp.Int64())}, nil
+}
+
+func ecKey(x, y string) (*ecdsa.PublicKey, error) {
+	xb, err := base64.RawURLEncoding.DecodeString(x)
+	if err != nil {
+		return nil, fmt.Errorf("invalid x coordinate: %w", err)
+	}
+	yb, err := base64.RawURLEncoding.DecodeString(y)
+	if err != nil {
+		return nil, fmt.Errorf("invalid y coordinate: %w", err)
+	}
+	if len(xb) != 32 || len(yb) != 32 {
+		return nil, errors.New("P-256 coordinates must be 32 bytes")
+	}
+	return ecdsa.ParseUncompressedPublicKey(elliptic.P256(), append(append([]byte{4}, xb...), yb...))
+}
+
+// keyFitsAlg reports whether key can verify signatures made with alg.
+func keyFitsAlg(key crypto.PublicKey, alg string) bool {
+	switch key.(type) {
+	case *rsa.PublicKey:
+		return alg == AlgRS256
+	case *ecdsa.PublicKey:
+		return alg == AlgES256
+	}
+	return false
+}
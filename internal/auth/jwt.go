@@ -0,0 +1,238 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Signature algorithms accepted in JWTs.
+const (
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+)
+
+// DefaultClockSkew is the leeway allowed when checking a token's exp and nbf claims.
+const DefaultClockSkew = time.Minute
+
+// JWTOptions configures a JWTValidator.
+type JWTOptions struct {
+	// Keys holds the keys tokens must be signed with.
+	Keys *JWKS
+	// Issuer, if set, must equal the token's iss claim.
+	Issuer string
+	// Audience, if set, must contain one of the values in the token's aud claim.
+	Audience []string
+	// ClockSkew is the leeway allowed for exp and nbf. Defaults to DefaultClockSkew;
+	// negative means none.
+	ClockSkew time.Duration
+}
+
+// JWTValidator authenticates requests carrying an RS256 or ES256 signed JWT as a bearer
+// token. The token must have an exp claim; its scopes come from the space-separated scope
+// claim and the scp claim, and its client ID from sub, client_id or azp.
+type JWTValidator struct {
+	keys     *JWKS
+	issuer   string
+	audience []string
+	skew     time.Duration
+	now      func() time.Time
+}
+
+// NewJWTValidator returns a validator checking tokens against opts.
+func NewJWTValidator(opts JWTOptions) *JWTValidator {
+	v := &JWTValidator{
+		keys:     opts.Keys,
+		issuer:   opts.Issuer,
+		audience: opts.Audience,
+		skew:     opts.ClockSkew,
+		now:      time.Now,
+	}
+	if v.skew == 0 {
+		v.skew = DefaultClockSkew
+	}
+	if v.skew < 0 {
+		v.skew = 0
+	}
+	return v
+}
+
+// Authenticate validates the JWT sent as a bearer token. Bearer tokens that are not shaped
+// like a JWT are left to other authenticators and reported as ErrNoCredentials.
+func (v *JWTValidator) Authenticate(r *http.Request) (*Identity, error) {
+	token, ok := BearerToken(r)
+	if !ok || strings.Count(token, ".") != 2 {
+		return nil, ErrNoCredentials
+	}
+	return v.Validate(r.Context(), token)
+}
+
+// jwtHeader is the JOSE header of a compact JWS.
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// jwtClaims are the registered and scope claims the validator understands.
+type jwtClaims struct {
+	Issuer    string     `json:"iss"`
+	Subject   string     `json:"sub"`
+	Audience  stringList `json:"aud"`
+	Expiry    *float64   `json:"exp"`
+	NotBefore *float64   `json:"nbf"`
+	Scope     string     `json:"scope"`
+	Scp       stringList `json:"scp"`
+	ClientID  string     `json:"client_id"`
+	Azp       string     `json:"azp"`
+	Name      string     `json:"name"`
+}
+
+// Validate checks the token's signature and claims and returns the identity it carries.
+// Every failure wraps ErrInvalidCredentials, except for errors reading the key set.
+func (v *JWTValidator) Validate(ctx context.Context, token string) (*Identity, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidCredentials)
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: malformed token header: %v", ErrInvalidCredentials, err)
+	}
+	if header.Alg != AlgRS256 && header.Alg != AlgES256 {
+		return nil, fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidCredentials, header.Alg)
+	}
+
+	key, err := v.keys.key(ctx, header.Kid, header.Alg)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature", ErrInvalidCredentials)
+	}
+	if !verifySignature(key, parts[0]+"."+parts[1], sig) {
+		return nil, fmt.Errorf("%w: invalid signature", ErrInvalidCredentials)
+	}
+
+	var claims jwtClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: malformed token claims: %v", ErrInvalidCredentials, err)
+	}
+	if err := v.checkClaims(claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	id := &Identity{
+		ID:     firstNonEmpty(claims.Subject, claims.ClientID, claims.Azp),
+		Name:   claims.Name,
+		Method: MethodJWT,
+	}
+	for _, scope := range strings.Fields(claims.Scope + " " + strings.Join(claims.Scp, " ")) {
+		if !slices.Contains(id.Scopes, scope) {
+			id.Scopes = append(id.Scopes, scope)
+		}
+	}
+	if id.ID == "" {
+		return nil, fmt.Errorf("%w: token has no sub, client_id or azp claim", ErrInvalidCredentials)
+	}
+	return id, nil
+}
+
+// checkClaims validates the time, issuer and audience claims.
+func (v *JWTValidator) checkClaims(c jwtClaims) error {
+	now := v.now()
+
+	if c.Expiry == nil {
+		return errors.New("token has no exp claim")
+	}
+	if now.After(numericDate(*c.Expiry).Add(v.skew)) {
+		return errors.New("token is expired")
+	}
+	if c.NotBefore != nil && now.Add(v.skew).Before(numericDate(*c.NotBefore)) {
+		return errors.New("token is not valid yet")
+	}
+
+	if v.issuer != "" && c.Issuer != v.issuer {
+		return fmt.Errorf("unexpected issuer %q", c.Issuer)
+	}
+	if len(v.audience) > 0 && !slices.ContainsFunc(c.Audience, func(aud string) bool {
+		return slices.Contains(v.audience, aud)
+	}) {
+		return fmt.Errorf("unexpected audience %q", []string(c.Audience))
+	}
+	return nil
+}
+
+// verifySignature checks sig over signingInput with an RSA or P-256 key.
+func verifySignature(key crypto.PublicKey, signingInput string, sig []byte) bool {
+	digest := sha256.Sum256([]byte(signingInput))
+
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig) == nil
+	case *ecdsa.PublicKey:
+		// JWS encodes ECDSA signatures as the fixed-size concatenation of r and s.
+		if len(sig) != 64 {
+			return false
+		}
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		return ecdsa.Verify(key, digest[:], r, s)
+	}
+	return false
+}
+
+// decodeSegment decodes a base64url JSON segment of a token into v.
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// numericDate converts a JWT NumericDate, seconds since the epoch, to a time.
+func numericDate(seconds float64) time.Time {
+	whole, frac := math.Modf(seconds)
+	return time.Unix(int64(whole), int64(frac*1e9))
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// stringList decodes a claim that may be a single string or an array of strings.
+type stringList []string
+
+func (l *stringList) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*l = stringList{s}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
@@ -0,0 +1,365 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testSigner signs JWTs with a locally generated key.
+type testSigner struct {
+	kid string
+	alg string
+	key crypto.Signer
+}
+
+func newRSASigner(t *testing.T, kid string) testSigner {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return testSigner{kid: kid, alg: AlgRS256, key: key}
+}
+
+func newECSigner(t *testing.T, kid string) testSigner {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return testSigner{kid: kid, alg: AlgES256, key: key}
+}
+
+// jwk returns the signer's public key as a JSON Web Key.
+func (s testSigner) jwk() map[string]string {
+	b64 := base64.RawURLEncoding.EncodeToString
+	switch pub := s.key.Public().(type) {
+	case *rsa.PublicKey:
+		return map[string]string{"kty": "RSA", "kid": s.kid, "use": "sig",
+			"n": b64(pub.N.Bytes()), "e": b64(big.NewInt(int64(pub.E)).Bytes())}
+	case *ecdsa.PublicKey:
+		raw, _ := pub.Bytes()
+		return map[string]string{"kty": "EC", "kid": s.kid, "crv": "P-256",
+			"x": b64(raw[1:33]), "y": b64(raw[33:])}
+	}
+	return nil
+}
+
+func (s testSigner) sign(t *testing.T, claims map[string]any) string {
+	t.Helper()
+	header, err := json.Marshal(map[string]string{"alg": s.alg, "kid": s.kid, "typ": "JWT"})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(input))
+
+	var sig []byte
+	switch key := s.key.(type) {
+	case *rsa.PrivateKey:
+		sig, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+		require.NoError(t, err)
+		sig = make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+// jwksServer serves a key set that tests can rotate, counting fetches.
+type jwksServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	signers []testSigner
+	fetches atomic.Int32
+}
+
+func newJWKSServer(t *testing.T, signers ...testSigner) *jwksServer {
+	t.Helper()
+	s := &jwksServer{signers: signers}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.fetches.Add(1)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		_ = json.NewEncoder(w).Encode(jwksDocument(s.signers...))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) rotate(signers ...testSigner) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.signers = signers
+}
+
+func jwksDocument(signers ...testSigner) map[string]any {
+	keys := make([]map[string]string, 0, len(signers))
+	for _, s := range signers {
+		keys = append(keys, s.jwk())
+	}
+	return map[string]any{"keys": keys}
+}
+
+func validClaims() map[string]any {
+	return map[string]any{
+		"iss":   "https://issuer.example",
+		"aud":   "country-search",
+		"sub":   "service-a",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"scope": "countries:read profile",
+	}
+}
+
+// TestJWTValidator_Validate tests signature and claim checks for both algorithms.
+func TestJWTValidator_Validate(t *testing.T) {
+	rsaSigner := newRSASigner(t, "rsa-1")
+	ecSigner := newECSigner(t, "ec-1")
+	server := newJWKSServer(t, rsaSigner, ecSigner)
+
+	v := NewJWTValidator(JWTOptions{
+		Keys:      NewJWKS(server.URL, JWKSOptions{}),
+		Issuer:    "https://issuer.example",
+		Audience:  []string{"country-search"},
+		ClockSkew: 30 * time.Second,
+	})
+
+	with := func(changes map[string]any) map[string]any {
+		claims := validClaims()
+		for k, val := range changes {
+			if val == nil {
+				delete(claims, k)
+			} else {
+				claims[k] = val
+			}
+		}
+		return claims
+	}
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr string
+	}{
+		{"RS256", rsaSigner.sign(t, validClaims()), ""},
+		{"ES256", ecSigner.sign(t, validClaims()), ""},
+		{"audience list", rsaSigner.sign(t, with(map[string]any{"aud": []string{"other", "country-search"}})), ""},
+		{"expired within skew", rsaSigner.sign(t, with(map[string]any{"exp": now.Add(-10 * time.Second).Unix()})), ""},
+		{"expired", rsaSigner.sign(t, with(map[string]any{"exp": now.Add(-time.Minute).Unix()})), "expired"},
+		{"no exp", rsaSigner.sign(t, with(map[string]any{"exp": nil})), "no exp"},
+		{"not yet valid", rsaSigner.sign(t, with(map[string]any{"nbf": now.Add(time.Minute).Unix()})), "not valid yet"},
+		{"nbf within skew", rsaSigner.sign(t, with(map[string]any{"nbf": now.Add(10 * time.Second).Unix()})), ""},
+		{"wrong issuer", rsaSigner.sign(t, with(map[string]any{"iss": "https://evil.example"})), "issuer"},
+		{"wrong audience", rsaSigner.sign(t, with(map[string]any{"aud": "other"})), "audience"},
+		{"no subject", rsaSigner.sign(t, with(map[string]any{"sub": nil})), "no sub"},
+		{"unknown key", newRSASigner(t, "rsa-2").sign(t, validClaims()), "no RS256 signing key"},
+		{"wrong key type for kid", testSigner{kid: "rsa-1", alg: AlgES256, key: ecSigner.key}.sign(t, validClaims()), "no ES256 signing key"},
+		{"malformed", "a.b.c", "malformed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := v.Validate(context.Background(), tt.token)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.ErrorIs(t, err, ErrInvalidCredentials)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "service-a", id.ID)
+			assert.Equal(t, MethodJWT, id.Method)
+			assert.Equal(t, []string{ScopeCountriesRead, "profile"}, id.Scopes)
+		})
+	}
+}
+
+// TestJWTValidator_RejectsTampering tests that altered tokens and unsigned tokens are rejected.
+func TestJWTValidator_RejectsTampering(t *testing.T) {
+	signer := newRSASigner(t, "rsa-1")
+	server := newJWKSServer(t, signer)
+	v := NewJWTValidator(JWTOptions{Keys: NewJWKS(server.URL, JWKSOptions{})})
+
+	token := signer.sign(t, validClaims())
+	forged := validClaims()
+	forged["scope"] = "admin:cache"
+	payload, _ := json.Marshal(forged)
+	parts := strings.Split(token, ".")
+
+	_, err := v.Validate(context.Background(), parts[0]+"."+base64.RawURLEncoding.EncodeToString(payload)+"."+parts[2])
+	assert.ErrorContains(t, err, "invalid signature")
+
+	none := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`))
+	_, err = v.Validate(context.Background(), none+"."+parts[1]+".")
+	assert.ErrorContains(t, err, "unsupported algorithm")
+}
+
+// TestJWKS_Caching tests that keys are cached and refetched after the refresh interval.
+func TestJWKS_Caching(t *testing.T) {
+	signer := newECSigner(t, "ec-1")
+	server := newJWKSServer(t, signer)
+	keys := NewJWKS(server.URL, JWKSOptions{RefreshInterval: time.Hour})
+	now := time.Now()
+	keys.now = func() time.Time { return now }
+	v := NewJWTValidator(JWTOptions{Keys: keys})
+
+	for range 3 {
+		_, err := v.Validate(context.Background(), signer.sign(t, validClaims()))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), server.fetches.Load())
+
+	now = now.Add(time.Hour)
+	_, err := v.Validate(context.Background(), signer.sign(t, validClaims()))
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), server.fetches.Load())
+}
+
+// TestJWKS_Rotation tests that a token signed by a new key triggers a rate-limited refresh.
+func TestJWKS_Rotation(t *testing.T) {
+	oldSigner := newRSASigner(t, "old")
+	newSigner := newRSASigner(t, "new")
+	server := newJWKSServer(t, oldSigner)
+
+	keys := NewJWKS(server.URL, JWKSOptions{MinRefreshInterval: time.Minute})
+	now := time.Now()
+	keys.now = func() time.Time { return now }
+	v := NewJWTValidator(JWTOptions{Keys: keys})
+
+	_, err := v.Validate(context.Background(), oldSigner.sign(t, validClaims()))
+	require.NoError(t, err)
+
+	server.rotate(newSigner)
+	now = now.Add(2 * time.Minute)
+	_, err = v.Validate(context.Background(), newSigner.sign(t, validClaims()))
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), server.fetches.Load())
+
+	// Unknown keys do not trigger another fetch until the minimum interval has passed.
+	_, err = v.Validate(context.Background(), oldSigner.sign(t, validClaims()))
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	assert.Equal(t, int32(2), server.fetches.Load())
+}
+
+// TestJWKS_StaleKeysOnFailure tests that cached keys stay in use when a refresh fails.
+func TestJWKS_StaleKeysOnFailure(t *testing.T) {
+	signer := newRSASigner(t, "rsa-1")
+	server := newJWKSServer(t, signer)
+	keys := NewJWKS(server.URL, JWKSOptions{RefreshInterval: time.Minute})
+	now := time.Now()
+	keys.now = func() time.Time { return now }
+	v := NewJWTValidator(JWTOptions{Keys: keys})
+
+	_, err := v.Validate(context.Background(), signer.sign(t, validClaims()))
+	require.NoError(t, err)
+
+	server.rotate()
+	now = now.Add(2 * time.Minute)
+	_, err = v.Validate(context.Background(), signer.sign(t, validClaims()))
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), server.fetches.Load())
+
+	// A failed refresh is not retried until the minimum interval has passed.
+	_, err = v.Validate(context.Background(), signer.sign(t, validClaims()))
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), server.fetches.Load())
+
+	now = now.Add(time.Minute)
+	_, err = v.Validate(context.Background(), signer.sign(t, validClaims()))
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), server.fetches.Load())
+}
+
+// TestJWKS_ConcurrentFetch tests that lookups share one fetch and the lock is not held during it.
+func TestJWKS_ConcurrentFetch(t *testing.T) {
+	signer := newRSASigner(t, "rsa-1")
+	server := newJWKSServer(t, signer)
+	keys := NewJWKS(server.URL, JWKSOptions{})
+	v := NewJWTValidator(JWTOptions{Keys: keys})
+
+	// Holding the server's lock stalls the fetch until the lookups are waiting for it.
+	server.mu.Lock()
+	errs := make(chan error, 5)
+	for range cap(errs) {
+		go func() {
+			_, err := v.Validate(context.Background(), signer.sign(t, validClaims()))
+			errs <- err
+		}()
+	}
+	require.Eventually(t, func() bool { return server.fetches.Load() == 1 }, time.Second, time.Millisecond)
+	assert.Zero(t, keys.Len(), "the cached keys stay readable during a fetch")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := v.Validate(ctx, signer.sign(t, validClaims()))
+	assert.ErrorIs(t, err, context.Canceled)
+
+	server.mu.Unlock()
+	for range cap(errs) {
+		assert.NoError(t, <-errs)
+	}
+	assert.Equal(t, int32(1), server.fetches.Load())
+}
+
+// TestJWKS_File tests loading keys from a file and skipping unsupported keys.
+func TestJWKS_File(t *testing.T) {
+	signer := newECSigner(t, "ec-1")
+	doc := jwksDocument(signer)
+	doc["keys"] = append(doc["keys"].([]map[string]string),
+		map[string]string{"kty": "OKP", "crv": "Ed25519", "x": "abc"},
+		map[string]string{"kty": "RSA", "use": "enc", "n": "abc", "e": "AQAB"})
+	data, err := json.Marshal(doc)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	keys := NewJWKS(path, JWKSOptions{})
+	require.NoError(t, keys.Refresh(context.Background()))
+	assert.Equal(t, 1, keys.Len())
+
+	id, err := NewJWTValidator(JWTOptions{Keys: keys}).Validate(context.Background(), signer.sign(t, validClaims()))
+	require.NoError(t, err)
+	assert.Equal(t, "service-a", id.ID)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"keys":[]}`), 0o600))
+	assert.Error(t, keys.Refresh(context.Background()))
+}
+
+// TestJWTValidator_Authenticate tests that only JWT-shaped bearer tokens are claimed.
+func TestJWTValidator_Authenticate(t *testing.T) {
+	signer := newRSASigner(t, "rsa-1")
+	server := newJWKSServer(t, signer)
+	v := NewJWTValidator(JWTOptions{Keys: NewJWKS(server.URL, JWKSOptions{})})
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer plain-api-key")
+	_, err := v.Authenticate(r)
+	assert.ErrorIs(t, err, ErrNoCredentials)
+
+	r.Header.Set("Authorization", "Bearer "+signer.sign(t, validClaims()))
+	id, err := v.Authenticate(r)
+	require.NoError(t, err)
+	assert.Equal(t, "service-a", id.ID)
+}
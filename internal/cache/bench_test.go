@@ -0,0 +1,149 @@
+package cache
+
+import (
+	"bufio"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+const benchKeys = 1 << 12
+
+// benchmarkCache runs a parallel mix of Gets and Sets against c, where readPercent of the
+// operations are Gets, at each GOMAXPROCS value in procs.
+func benchmarkCache(b *testing.B, newCache func() Cache[string, int]) {
+	keys := make([]string, benchKeys)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("country-%d", i)
+	}
+
+	for _, procs := range []int{1, 4, 16} {
+		for _, readPercent := range []int{50, 90, 99} {
+			b.Run(fmt.Sprintf("procs=%d/reads=%d%%", procs, readPercent), func(b *testing.B) {
+				defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(procs))
+
+				c := newCache()
+				for i, key := range keys {
+					c.Set(key, i)
+				}
+
+				b.ReportAllocs()
+				b.ResetTimer()
+				b.RunParallel(func(pb *testing.PB) {
+					rng := rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+					for pb.Next() {
+						key := keys[rng.IntN(benchKeys)]
+						if rng.IntN(100) < readPercent {
+							c.Get(key)
+						} else {
+							c.Set(key, 0)
+						}
+					}
+				})
+			})
+		}
+	}
+}
+
+// BenchmarkInMemoryCache measures the single-lock cache under concurrent reads and writes.
+func BenchmarkInMemoryCache(b *testing.B) {
+	benchmarkCache(b, func() Cache[string, int] { return NewInMemoryCache[string, int]() })
+}
+
+// BenchmarkShardedCache measures the sharded cache under the same load as BenchmarkInMemoryCache.
+func BenchmarkShardedCache(b *testing.B) {
+	benchmarkCache(b, func() Cache[string, int] { return NewShardedCache[string, int](0) })
+}
+
+// syntheticTrace returns n cache keys shaped after production traffic, generated from a
+// fixed seed so every run replays the same trace: 70% of lookups go to 30 popular
+// countries with Zipf-distributed popularity, 18% are spread evenly over 220 other
+// countries and 12% are one-off misspellings.
+func syntheticTrace(n int) []string {
+	rng := rand.New(rand.NewPCG(1, 2))
+	popular := rand.NewZipf(rng, 1.1, 1, 29)
+	trace := make([]string, n)
+	for i := range trace {
+		switch p := rng.IntN(100); {
+		case p < 70:
+			trace[i] = fmt.Sprintf("popular-%02d", popular.Uint64())
+		case p < 88:
+			trace[i] = fmt.Sprintf("country-%03d", rng.IntN(220))
+		default:
+			trace[i] = fmt.Sprintf("misspelling-%d", i)
+		}
+	}
+	return trace
+}
+
+// loadTraces returns the synthetic trace and every testdata/*.trace file, such as keys
+// recorded from real traffic: one key per line, in the order they were looked up. Blank
+// lines and lines starting with # are skipped.
+func loadTraces(tb testing.TB) map[string][]string {
+	tb.Helper()
+	paths, err := filepath.Glob(filepath.Join("testdata", "*.trace"))
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	traces := map[string][]string{"synthetic": syntheticTrace(20000)}
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		var keys []string
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
+				keys = append(keys, line)
+			}
+		}
+		f.Close()
+		if err := scanner.Err(); err != nil {
+			tb.Fatalf("%s: %v", path, err)
+		}
+		name := strings.TrimSuffix(filepath.Base(path), ".trace")
+		if name == "synthetic" {
+			tb.Fatalf("%s: the name is taken by the generated trace", path)
+		}
+		traces[name] = keys
+	}
+	return traces
+}
+
+// replay looks up every key of trace in c, storing the keys it misses as a service would,
+// and returns the fraction of lookups that hit.
+func replay(c Cache[string, int], trace []string) float64 {
+	hits := 0
+	for _, key := range trace {
+		if _, found := c.Get(key); found {
+			hits++
+		} else {
+			c.Set(key, 0)
+		}
+	}
+	return float64(hits) / float64(len(trace))
+}
+
+// BenchmarkReplay replays each trace against every eviction policy at several cache sizes
+// and reports the hit ratio alongside the time per replay.
+func BenchmarkReplay(b *testing.B) {
+	for name, trace := range loadTraces(b) {
+		for _, size := range []int{25, 50, 100, 200} {
+			for _, policy := range Policies {
+				b.Run(fmt.Sprintf("trace=%s/size=%d/policy=%s", name, size, policy), func(b *testing.B) {
+					var ratio float64
+					for b.Loop() {
+						ratio = replay(NewInMemoryCache[string, int](WithEviction(policy, size)), trace)
+					}
+					b.ReportMetric(100*ratio, "hit%")
+				})
+			}
+		}
+	}
+}
@@ -1,35 +1,366 @@
 package cache
 
-import "sync"
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
 
-type Cache interface {
-	Get(key string) (interface{}, bool)
-	Set(key string, value interface{})
+// Cache stores values of type V under keys of type K.
+type Cache[K comparable, V any] interface {
+	Get(key K) (V, bool)
+	Set(key K, value V)
+	// Delete removes key and reports whether it was present.
+	Delete(key K) bool
+	// Keys returns the keys of the live entries in no particular order.
+	Keys() []K
+	Len() int
+	// Clear removes every entry.
+	Clear()
 }
 
-type InMemoryCache struct {
+// Inspector is implemented by caches that can describe their entries.
+type Inspector[K comparable, V any] interface {
+	// Entry describes the live entry stored under key without counting it as a hit.
+	Entry(key K) (EntryInfo[K, V], bool)
+	// Entries describes every live entry in no particular order.
+	Entries() []EntryInfo[K, V]
+}
+
+// ExpirySetter is implemented by caches that can store an entry with its own expiry.
+type ExpirySetter[K comparable, V any] interface {
+	// SetWithExpiry stores value under key like Set, but expires it at expiresAt if that
+	// comes before the cache's TTL runs out.
+	SetWithExpiry(key K, value V, expiresAt time.Time)
+}
+
+// EntryInfo describes a cached entry.
+type EntryInfo[K comparable, V any] struct {
+	Key      K
+	Value    V
+	StoredAt time.Time
+	// ExpiresAt is zero when entries never expire.
+	ExpiresAt time.Time
+	// Hits counts the lookups served by the entry since it was stored.
+	Hits int64
+}
+
+// entry is a cached value with the time it was stored.
+type entry[V any] struct {
+	value    V
+	storedAt time.Time
+	// expiresAt, if set, ends the entry's life early, whatever the TTL.
+	expiresAt time.Time
+	hits      atomic.Int64
+	// size approximates the bytes held by the entry and its key, for Stats.
+	size int64
+}
+
+type InMemoryCache[K comparable, V any] struct {
 	mu    sync.RWMutex
-	store map[string]interface{}
+	store map[K]*entry[V]
+
+	// ttl is read on every Get so SetTTL applies to entries already in the cache.
+	ttl   atomic.Int64
+	now   func() time.Time
+	stats counters
+
+	// policy, if set, bounds the number of entries. It has its own lock because hits,
+	// which only hold the read lock, update it too.
+	policyMu sync.Mutex
+	policy   policy[K]
+}
+
+// options holds the settings shared by every cache type.
+type options struct {
+	ttl        time.Duration
+	policy     Policy
+	maxEntries int
+}
+
+// Option customises an InMemoryCache.
+type Option func(*options)
+
+// WithTTL sets how long entries stay valid. Zero, the default, keeps entries forever.
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.ttl = ttl
+	}
+}
+
+// WithEviction limits the cache to maxEntries entries, evicting by policy when a Set would
+// exceed it; an empty policy means PolicyLRU. Zero or less, the default, leaves the cache
+// unbounded. The cache panics if policy is unknown.
+func WithEviction(policy Policy, maxEntries int) Option {
+	return func(o *options) {
+		o.policy = policy
+		o.maxEntries = maxEntries
+	}
 }
 
 // NewInMemoryCache creates a new instance of InMemoryCache
-func NewInMemoryCache() *InMemoryCache {
-	return &InMemoryCache{
-		store: make(map[string]interface{}),
+func NewInMemoryCache[K comparable, V any](opts ...Option) *InMemoryCache[K, V] {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
 	}
+
+	c := &InMemoryCache[K, V]{
+		store: make(map[K]*entry[V]),
+		now:   time.Now,
+	}
+	if o.maxEntries > 0 {
+		if o.policy == "" {
+			o.policy = PolicyLRU
+		}
+		c.policy = newPolicy[K](o.policy, o.maxEntries)
+	}
+	c.SetTTL(o.ttl)
+	return c
 }
 
-// Get retrieves a value from the cache by key
-func (c *InMemoryCache) Get(key string) (interface{}, bool) {
+// Get retrieves a value from the cache by key. Expired entries are removed and reported as missing.
+func (c *InMemoryCache[K, V]) Get(key K) (V, bool) {
+	c.mu.RLock()
+	e, exists := c.store[key]
+	c.mu.RUnlock()
+
+	var zero V
+	if !exists {
+		c.stats.misses.Add(1)
+		return zero, false
+	}
+	if c.expired(e) {
+		c.stats.misses.Add(1)
+		c.evict(key)
+		return zero, false
+	}
+	e.hits.Add(1)
+	c.stats.hits.Add(1)
+	if c.policy != nil {
+		c.policyMu.Lock()
+		c.policy.access(key)
+		c.policyMu.Unlock()
+	}
+	return e.value, true
+}
+
+// Len returns the number of entries in the cache, including expired entries not yet evicted.
+func (c *InMemoryCache[K, V]) Len() int {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	value, exists := c.store[key]
-	return value, exists
+	return len(c.store)
 }
 
 // Set stores a value in the cache with the specified key
-func (c *InMemoryCache) Set(key string, value interface{}) {
+func (c *InMemoryCache[K, V]) Set(key K, value V) {
+	// Sizing walks the value, so it happens before taking the lock.
+	e := &entry[V]{value: value, size: entrySize(key, value)}
+	c.stats.sets.Add(1)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e.storedAt = c.now()
+	c.put(key, e)
+}
+
+// SetWithExpiry stores value under key, expiring it at expiresAt or when the TTL runs out,
+// whichever comes first.
+func (c *InMemoryCache[K, V]) SetWithExpiry(key K, value V, expiresAt time.Time) {
+	e := &entry[V]{value: value, expiresAt: expiresAt, size: entrySize(key, value)}
+	c.stats.sets.Add(1)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e.storedAt = c.now()
+	c.put(key, e)
+}
+
+// Delete removes key from the cache and reports whether it was present.
+func (c *InMemoryCache[K, V]) Delete(key K) bool {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.store[key] = value
+	return c.remove(key)
+}
+
+// Keys returns the keys of the unexpired entries in no particular order.
+func (c *InMemoryCache[K, V]) Keys() []K {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	keys := make([]K, 0, len(c.store))
+	for key, e := range c.store {
+		if !c.expired(e) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Clear removes every entry from the cache.
+func (c *InMemoryCache[K, V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.store)
+	c.stats.bytes.Store(0)
+	if c.policy != nil {
+		c.policyMu.Lock()
+		c.policy.clear()
+		c.policyMu.Unlock()
+	}
+}
+
+// Entry describes the unexpired entry stored under key. Unlike Get, it does not count as a hit.
+func (c *InMemoryCache[K, V]) Entry(key K) (EntryInfo[K, V], bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	e, exists := c.store[key]
+	if !exists || c.expired(e) {
+		return EntryInfo[K, V]{}, false
+	}
+	return c.info(key, e), true
+}
+
+// Entries describes every unexpired entry in no particular order.
+func (c *InMemoryCache[K, V]) Entries() []EntryInfo[K, V] {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entries := make([]EntryInfo[K, V], 0, len(c.store))
+	for key, e := range c.store {
+		if !c.expired(e) {
+			entries = append(entries, c.info(key, e))
+		}
+	}
+	return entries
+}
+
+func (c *InMemoryCache[K, V]) info(key K, e *entry[V]) EntryInfo[K, V] {
+	info := EntryInfo[K, V]{Key: key, Value: e.value, StoredAt: e.storedAt, Hits: e.hits.Load()}
+	if ttl := c.TTL(); ttl > 0 {
+		info.ExpiresAt = e.storedAt.Add(ttl)
+	}
+	if !e.expiresAt.IsZero() && (info.ExpiresAt.IsZero() || e.expiresAt.Before(info.ExpiresAt)) {
+		info.ExpiresAt = e.expiresAt
+	}
+	return info
+}
+
+// TTL returns the current entry lifetime; zero means entries never expire.
+func (c *InMemoryCache[K, V]) TTL() time.Duration {
+	return time.Duration(c.ttl.Load())
+}
+
+// SetTTL changes the entry lifetime. It is safe to call while the cache is in use and
+// applies to existing entries as well as new ones.
+func (c *InMemoryCache[K, V]) SetTTL(ttl time.Duration) {
+	if ttl < 0 {
+		ttl = 0
+	}
+	c.ttl.Store(int64(ttl))
+}
+
+// DeleteExpired removes every expired entry and returns how many were removed.
+func (c *InMemoryCache[K, V]) DeleteExpired() int {
+	c.mu.Lock()
+	removed := 0
+	for key, e := range c.store {
+		if c.expired(e) {
+			c.remove(key)
+			removed++
+		}
+	}
+	c.mu.Unlock()
+
+	c.stats.expirations.Add(int64(removed))
+	return removed
+}
+
+// Stats returns the cache's activity counters, entry count and approximate size.
+func (c *InMemoryCache[K, V]) Stats() Stats {
+	return Stats{
+		Hits:        c.stats.hits.Load(),
+		Misses:      c.stats.misses.Load(),
+		Sets:        c.stats.sets.Load(),
+		Evictions:   c.stats.evictions.Load(),
+		Expirations: c.stats.expirations.Load(),
+		Entries:     c.Len(),
+		Bytes:       c.stats.bytes.Load(),
+	}
+}
+
+// put stores e under key, replacing any entry there, and evicts whatever the policy
+// chooses to stay within the size limit. The caller holds the write lock.
+func (c *InMemoryCache[K, V]) put(key K, e *entry[V]) {
+	if old, exists := c.store[key]; exists {
+		c.stats.bytes.Add(-old.size)
+	}
+	c.store[key] = e
+	c.stats.bytes.Add(e.size)
+	if c.policy == nil {
+		return
+	}
+
+	c.policyMu.Lock()
+	victims := c.policy.add(key)
+	c.policyMu.Unlock()
+	for _, victim := range victims {
+		if c.drop(victim) {
+			c.stats.evictions.Add(1)
+		}
+	}
+}
+
+// remove deletes key and reports whether it was present. The caller holds the write lock.
+func (c *InMemoryCache[K, V]) remove(key K) bool {
+	if c.policy != nil {
+		c.policyMu.Lock()
+		c.policy.remove(key)
+		c.policyMu.Unlock()
+	}
+	return c.drop(key)
+}
+
+// drop deletes key from the store alone and reports whether it was present. The caller
+// holds the write lock.
+func (c *InMemoryCache[K, V]) drop(key K) bool {
+	e, exists := c.store[key]
+	if exists {
+		delete(c.store, key)
+		c.stats.bytes.Add(-e.size)
+	}
+	return exists
+}
+
+// entrySize approximates the memory an entry for key and value holds.
+func entrySize[K comparable, V any](key K, value V) int64 {
+	return approxSize(key) + approxSize(value) + int64(unsafe.Sizeof(entry[V]{}))
+}
+
+// expired reports whether e has outlived the current TTL or its own expiry.
+func (c *InMemoryCache[K, V]) expired(e *entry[V]) bool {
+	now := c.now()
+	if !e.expiresAt.IsZero() && !now.Before(e.expiresAt) {
+		return true
+	}
+	ttl := c.TTL()
+	return ttl > 0 && now.Sub(e.storedAt) >= ttl
+}
+
+// evict removes key if it is still expired once the write lock is held, since another
+// goroutine may have refreshed it in the meantime.
+func (c *InMemoryCache[K, V]) evict(key K) {
+	c.mu.Lock()
+	e, exists := c.store[key]
+	removed := exists && c.expired(e)
+	if removed {
+		c.remove(key)
+	}
+	c.mu.Unlock()
+
+	if removed {
+		c.stats.expirations.Add(1)
+	}
 }
@@ -3,21 +3,42 @@ package cache
 import (
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
 
 // TestNewInMemoryCache tests the creation of a new InMemoryCache instance.
 func TestNewInMemoryCache(t *testing.T) {
-	c := NewInMemoryCache()
+	c := NewInMemoryCache[string, any]()
 
 	assert.NotNil(t, c)
 	assert.NotNil(t, c.store)
 }
 
+// TestCache_Typed tests that a typed cache returns values without type assertions.
+func TestCache_Typed(t *testing.T) {
+	type country struct{ Name string }
+	c := NewInMemoryCache[string, *country]()
+
+	c.Set("germany", &country{Name: "Germany"})
+	value, found := c.Get("germany")
+	assert.True(t, found)
+	assert.Equal(t, "Germany", value.Name)
+
+	value, found = c.Get("france")
+	assert.False(t, found)
+	assert.Nil(t, value)
+
+	counts := NewInMemoryCache[int, int]()
+	counts.Set(1, 10)
+	n, _ := counts.Get(1)
+	assert.Equal(t, 10, n)
+}
+
 // TestCache_SetAndGet tests setting and getting values in the cache.
 func TestCache_SetAndGet(t *testing.T) {
-	c := NewInMemoryCache()
+	c := NewInMemoryCache[string, any]()
 
 	// Test Set and Get
 	c.Set("key1", "value1")
@@ -29,7 +50,7 @@ func TestCache_SetAndGet(t *testing.T) {
 
 // TestCache_GetNotFound tests getting a value that does not exist in the cache.
 func TestCache_GetNotFound(t *testing.T) {
-	c := NewInMemoryCache()
+	c := NewInMemoryCache[string, any]()
 
 	value, found := c.Get("nonexistent")
 
@@ -39,7 +60,7 @@ func TestCache_GetNotFound(t *testing.T) {
 
 // TestCache_OverwriteValue tests overwriting an existing value in the cache.
 func TestCache_OverwriteValue(t *testing.T) {
-	c := NewInMemoryCache()
+	c := NewInMemoryCache[string, any]()
 
 	c.Set("key1", "value1")
 	c.Set("key1", "value2")
@@ -52,7 +73,7 @@ func TestCache_OverwriteValue(t *testing.T) {
 
 // TestCache_DifferentTypes tests setting and getting values of different types in the cache.
 func TestCache_DifferentTypes(t *testing.T) {
-	c := NewInMemoryCache()
+	c := NewInMemoryCache[string, any]()
 
 	// Test with different types
 	c.Set("string", "hello")
@@ -70,7 +91,7 @@ func TestCache_DifferentTypes(t *testing.T) {
 
 // TestCache_ConcurrentAccess tests concurrent access to the cache.
 func TestCache_ConcurrentAccess(t *testing.T) {
-	c := NewInMemoryCache()
+	c := NewInMemoryCache[string, any]()
 	var wg sync.WaitGroup
 
 	// Launch multiple goroutines to read and write concurrently
@@ -95,7 +116,7 @@ func TestCache_ConcurrentAccess(t *testing.T) {
 
 // TestCache_ConcurrentDifferentKeys tests concurrent access to different keys in the cache.
 func TestCache_ConcurrentDifferentKeys(t *testing.T) {
-	c := NewInMemoryCache()
+	c := NewInMemoryCache[string, any]()
 	var wg sync.WaitGroup
 
 	// Launch multiple goroutines to read and write different keys concurrently
@@ -111,3 +132,132 @@ func TestCache_ConcurrentDifferentKeys(t *testing.T) {
 
 	wg.Wait()
 }
+
+// TestCache_Len tests counting entries in the cache.
+func TestCache_Len(t *testing.T) {
+	c := NewInMemoryCache[string, any]()
+	assert.Equal(t, 0, c.Len())
+
+	c.Set("key1", "value1")
+	c.Set("key2", "value2")
+	c.Set("key1", "value3")
+
+	assert.Equal(t, 2, c.Len())
+}
+
+// TestCache_TTL tests that entries expire once they outlive the TTL.
+func TestCache_TTL(t *testing.T) {
+	now := time.Now()
+	c := NewInMemoryCache[string, any](WithTTL(time.Minute))
+	c.now = func() time.Time { return now }
+
+	c.Set("key1", "value1")
+
+	now = now.Add(59 * time.Second)
+	value, found := c.Get("key1")
+	assert.True(t, found)
+	assert.Equal(t, "value1", value)
+
+	now = now.Add(time.Second)
+	value, found = c.Get("key1")
+	assert.False(t, found)
+	assert.Nil(t, value)
+	assert.Equal(t, 0, c.Len())
+	assert.Equal(t, int64(1), c.Stats().Expirations)
+}
+
+// TestCache_SetTTL tests that changing the TTL applies to entries already stored.
+func TestCache_SetTTL(t *testing.T) {
+	now := time.Now()
+	c := NewInMemoryCache[string, any]()
+	c.now = func() time.Time { return now }
+
+	c.Set("key1", "value1")
+	now = now.Add(time.Hour)
+
+	_, found := c.Get("key1")
+	assert.True(t, found, "entries never expire without a TTL")
+
+	c.SetTTL(30 * time.Minute)
+	assert.Equal(t, 30*time.Minute, c.TTL())
+
+	_, found = c.Get("key1")
+	assert.False(t, found)
+
+	c.SetTTL(-time.Second)
+	assert.Equal(t, time.Duration(0), c.TTL())
+}
+
+// TestCache_DeleteExpired tests removing all expired entries at once.
+func TestCache_DeleteExpired(t *testing.T) {
+	now := time.Now()
+	c := NewInMemoryCache[string, any](WithTTL(time.Minute))
+	c.now = func() time.Time { return now }
+
+	c.Set("old1", 1)
+	c.Set("old2", 2)
+	now = now.Add(2 * time.Minute)
+	c.Set("fresh", 3)
+
+	assert.Equal(t, 2, c.DeleteExpired())
+	assert.Equal(t, 1, c.Len())
+	assert.Equal(t, int64(2), c.Stats().Expirations)
+
+	c.SetTTL(0)
+	assert.Equal(t, 0, c.DeleteExpired())
+}
+
+// TestCache_DeleteKeysClear tests deleting entries, listing keys and clearing the cache.
+func TestCache_DeleteKeysClear(t *testing.T) {
+	now := time.Now()
+	c := NewInMemoryCache[string, any](WithTTL(time.Minute))
+	c.now = func() time.Time { return now }
+
+	c.Set("old", 0)
+	now = now.Add(2 * time.Minute)
+	c.Set("b", 2)
+	c.Set("a", 1)
+
+	assert.ElementsMatch(t, []string{"a", "b"}, c.Keys(), "expired keys are not listed")
+
+	assert.True(t, c.Delete("a"))
+	assert.False(t, c.Delete("a"))
+	assert.Equal(t, []string{"b"}, c.Keys())
+
+	c.Clear()
+	assert.Equal(t, 0, c.Len())
+	assert.Empty(t, c.Keys())
+}
+
+// TestCache_Entries tests entry metadata, including hit counts and expiry.
+func TestCache_Entries(t *testing.T) {
+	now := time.Now()
+	c := NewInMemoryCache[string, any](WithTTL(time.Hour))
+	c.now = func() time.Time { return now }
+
+	c.Set("germany", "Berlin")
+	c.Set("france", "Paris")
+	c.Get("germany")
+	c.Get("germany")
+
+	info, found := c.Entry("germany")
+	assert.True(t, found)
+	assert.Equal(t, EntryInfo[string, any]{Key: "germany", Value: "Berlin", StoredAt: now, ExpiresAt: now.Add(time.Hour), Hits: 2}, info)
+
+	entries := c.Entries()
+	assert.Len(t, entries, 2)
+	for _, e := range entries {
+		if e.Key == "france" {
+			assert.Equal(t, int64(0), e.Hits)
+		}
+	}
+
+	c.Set("germany", "Berlin")
+	info, _ = c.Entry("germany")
+	assert.Equal(t, int64(0), info.Hits, "replacing an entry resets its hits")
+
+	now = now.Add(2 * time.Hour)
+	_, found = c.Entry("germany")
+	assert.False(t, found)
+	assert.Empty(t, c.Entries())
+}
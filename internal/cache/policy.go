@@ -0,0 +1,193 @@
+package cache
+
+import (
+	"container/list"
+	"fmt"
+)
+
+// Policy names the eviction policy of a cache with a size limit.
+type Policy string
+
+const (
+	// PolicyLRU evicts the least recently used entry.
+	PolicyLRU Policy = "lru"
+	// PolicyLFU evicts the least frequently used entry, the least recently used among
+	// equally frequent ones. Frequencies never decay, so entries that were popular once
+	// stay until they are deleted or expire.
+	PolicyLFU Policy = "lfu"
+	// PolicyTinyLFU is W-TinyLFU: new entries pass through a small LRU window and only
+	// displace an entry of the main cache when a frequency sketch says they are requested
+	// more often, so one-off lookups cannot flush the popular entries.
+	PolicyTinyLFU Policy = "tinylfu"
+)
+
+// Policies lists the supported eviction policies.
+var Policies = []Policy{PolicyLRU, PolicyLFU, PolicyTinyLFU}
+
+// policy decides which entries a cache with a size limit evicts. The cache serialises
+// calls to it.
+type policy[K comparable] interface {
+	// access records a hit on key.
+	access(key K)
+	// add records that key was stored and returns the keys to evict to stay within the
+	// limit, which include key itself when the policy declines to admit it.
+	add(key K) []K
+	// remove forgets key.
+	remove(key K)
+	// clear forgets every key.
+	clear()
+}
+
+// newPolicy creates the policy p for at most maxEntries entries. It panics if p is unknown.
+func newPolicy[K comparable](p Policy, maxEntries int) policy[K] {
+	switch p {
+	case PolicyLRU:
+		return newLRU[K](maxEntries)
+	case PolicyLFU:
+		return newLFU[K](maxEntries)
+	case PolicyTinyLFU:
+		return newTinyLFU[K](maxEntries)
+	}
+	panic(fmt.Sprintf("cache: unknown eviction policy %q", p))
+}
+
+// lru orders keys by recency; the front of order is the most recently used.
+type lru[K comparable] struct {
+	max   int
+	order *list.List
+	elems map[K]*list.Element
+}
+
+func newLRU[K comparable](maxEntries int) *lru[K] {
+	return &lru[K]{max: maxEntries, order: list.New(), elems: make(map[K]*list.Element)}
+}
+
+func (p *lru[K]) access(key K) {
+	if el, ok := p.elems[key]; ok {
+		p.order.MoveToFront(el)
+	}
+}
+
+func (p *lru[K]) add(key K) []K {
+	if el, ok := p.elems[key]; ok {
+		p.order.MoveToFront(el)
+		return nil
+	}
+	p.elems[key] = p.order.PushFront(key)
+	if p.order.Len() <= p.max {
+		return nil
+	}
+	victim := p.order.Remove(p.order.Back()).(K)
+	delete(p.elems, victim)
+	return []K{victim}
+}
+
+func (p *lru[K]) remove(key K) {
+	if el, ok := p.elems[key]; ok {
+		p.order.Remove(el)
+		delete(p.elems, key)
+	}
+}
+
+func (p *lru[K]) clear() {
+	p.order.Init()
+	clear(p.elems)
+}
+
+// lfuBucket holds the keys stored or hit freq times, most recently used first.
+type lfuBucket[K comparable] struct {
+	freq int
+	keys *list.List
+}
+
+// lfuNode is a key and the element of its bucket in lfu.buckets.
+type lfuNode[K comparable] struct {
+	key    K
+	bucket *list.Element
+}
+
+// lfu keeps its frequency buckets in a list ordered by frequency, following Shah, Mitra
+// and Matani's "An O(1) algorithm for implementing the LFU cache eviction scheme": a hit
+// moves a key to the next bucket, created if missing, and evictions take the oldest key
+// of the first bucket, so every operation takes constant time.
+type lfu[K comparable] struct {
+	max     int
+	elems   map[K]*list.Element
+	buckets *list.List
+}
+
+func newLFU[K comparable](maxEntries int) *lfu[K] {
+	return &lfu[K]{max: maxEntries, elems: make(map[K]*list.Element), buckets: list.New()}
+}
+
+func (p *lfu[K]) access(key K) {
+	el, ok := p.elems[key]
+	if !ok {
+		return
+	}
+	node := el.Value.(*lfuNode[K])
+	cur := node.bucket
+	freq := cur.Value.(*lfuBucket[K]).freq
+
+	next := cur.Next()
+	if next == nil || next.Value.(*lfuBucket[K]).freq != freq+1 {
+		next = p.buckets.InsertAfter(&lfuBucket[K]{freq: freq + 1, keys: list.New()}, cur)
+	}
+	p.unlink(el)
+	p.link(node, next)
+}
+
+func (p *lfu[K]) add(key K) []K {
+	if _, ok := p.elems[key]; ok {
+		p.access(key)
+		return nil
+	}
+
+	var victims []K
+	if len(p.elems) >= p.max {
+		victims = append(victims, p.evict())
+	}
+	first := p.buckets.Front()
+	if first == nil || first.Value.(*lfuBucket[K]).freq != 1 {
+		first = p.buckets.PushFront(&lfuBucket[K]{freq: 1, keys: list.New()})
+	}
+	p.link(&lfuNode[K]{key: key}, first)
+	return victims
+}
+
+func (p *lfu[K]) remove(key K) {
+	if el, ok := p.elems[key]; ok {
+		p.unlink(el)
+		delete(p.elems, key)
+	}
+}
+
+func (p *lfu[K]) clear() {
+	clear(p.elems)
+	p.buckets.Init()
+}
+
+// evict removes and returns the least recently used of the least frequent keys.
+func (p *lfu[K]) evict() K {
+	el := p.buckets.Front().Value.(*lfuBucket[K]).keys.Back()
+	node := p.unlink(el)
+	delete(p.elems, node.key)
+	return node.key
+}
+
+// link adds node to the front of bucket's keys.
+func (p *lfu[K]) link(node *lfuNode[K], bucket *list.Element) {
+	node.bucket = bucket
+	p.elems[node.key] = bucket.Value.(*lfuBucket[K]).keys.PushFront(node)
+}
+
+// unlink removes el from its bucket, dropping the bucket once empty.
+func (p *lfu[K]) unlink(el *list.Element) *lfuNode[K] {
+	node := el.Value.(*lfuNode[K])
+	b := node.bucket.Value.(*lfuBucket[K])
+	b.keys.Remove(el)
+	if b.keys.Len() == 0 {
+		p.buckets.Remove(node.bucket)
+	}
+	return node
+}
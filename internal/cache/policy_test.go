@@ -0,0 +1,110 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLRU tests that the least recently used key is evicted.
+func TestLRU(t *testing.T) {
+	p := newLRU[string](2)
+
+	assert.Empty(t, p.add("a"))
+	assert.Empty(t, p.add("b"))
+	p.access("a")
+	assert.Equal(t, []string{"b"}, p.add("c"))
+	assert.Empty(t, p.add("a"), "storing a key again does not evict")
+	assert.Equal(t, []string{"c"}, p.add("d"))
+
+	p.remove("a")
+	assert.Empty(t, p.add("e"))
+	p.clear()
+	assert.Empty(t, p.add("f"))
+	assert.Empty(t, p.add("g"))
+}
+
+// TestLFU tests that the least frequently used key is evicted, the oldest among ties.
+func TestLFU(t *testing.T) {
+	p := newLFU[string](3)
+
+	p.add("a")
+	p.add("b")
+	p.add("c")
+	p.access("a")
+	p.access("a")
+	p.access("c")
+	assert.Equal(t, []string{"b"}, p.add("d"))
+	assert.Equal(t, []string{"d"}, p.add("e"), "new keys are the least frequent")
+
+	p.access("e")
+	p.access("e")
+	assert.Equal(t, []string{"c"}, p.add("f"))
+
+	p.remove("f")
+	p.remove("a")
+	assert.Empty(t, p.add("g"))
+	assert.Empty(t, p.add("h"))
+	assert.Equal(t, []string{"g"}, p.add("i"))
+
+	// Emptied buckets are dropped, so the least frequent key is always in the first one.
+	for range 100 {
+		p.access("h")
+	}
+	p.remove("h")
+	assert.Equal(t, 2, p.buckets.Len())
+	assert.Equal(t, "i", p.evict())
+}
+
+// TestInMemoryCache_Eviction tests that a bounded cache keeps within its limit under every policy.
+func TestInMemoryCache_Eviction(t *testing.T) {
+	for _, policy := range Policies {
+		t.Run(string(policy), func(t *testing.T) {
+			c := NewInMemoryCache[string, int](WithEviction(policy, 10))
+			for i := range 100 {
+				key := fmt.Sprintf("key%02d", i)
+				c.Set(key, i)
+				c.Get(key)
+			}
+
+			stats := c.Stats()
+			assert.LessOrEqual(t, stats.Entries, 10)
+			assert.Equal(t, int64(100-stats.Entries), stats.Evictions)
+			assert.Len(t, c.Keys(), stats.Entries)
+			assert.Equal(t, int64(stats.Entries)*entrySize("key00", 0), stats.Bytes)
+
+			c.Clear()
+			for i := range 10 {
+				c.Set(fmt.Sprintf("new%d", i), i)
+			}
+			assert.Equal(t, 10, c.Len(), "clearing resets the policy")
+		})
+	}
+}
+
+// TestInMemoryCache_EvictionDefaults tests the LRU default and the panic on unknown policies.
+func TestInMemoryCache_EvictionDefaults(t *testing.T) {
+	c := NewInMemoryCache[string, int](WithEviction("", 2))
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Get("a")
+	c.Set("c", 3)
+	assert.ElementsMatch(t, []string{"a", "c"}, c.Keys())
+
+	assert.Panics(t, func() { NewInMemoryCache[string, int](WithEviction("mru", 2)) })
+	assert.NotPanics(t, func() { NewInMemoryCache[string, int](WithEviction("mru", 0)) })
+}
+
+// TestShardedCache_Eviction tests that the limit is split between the shards.
+func TestShardedCache_Eviction(t *testing.T) {
+	c := NewShardedCache[string, int](4, WithEviction(PolicyLRU, 10))
+	for i := range 100 {
+		c.Set(fmt.Sprintf("key%d", i), i)
+	}
+
+	assert.LessOrEqual(t, c.Len(), 12)
+	for _, s := range c.shards {
+		assert.LessOrEqual(t, s.Len(), 3)
+	}
+}
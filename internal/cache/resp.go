@@ -0,0 +1,344 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Defaults for RESPOptions.
+const (
+	DefaultRESPDialTimeout = time.Second
+	DefaultRESPPoolSize    = 4
+)
+
+// Limits on the replies readReply accepts, so a faulty or hostile server cannot make the
+// client allocate without bound.
+const (
+	maxRESPBulkLen  = 64 << 20
+	maxRESPArrayLen = 1 << 20
+)
+
+// RESPError is an error reply sent by the server, such as "ERR unknown command".
+type RESPError string
+
+func (e RESPError) Error() string { return string(e) }
+
+// RESPOptions configures a RESPClient.
+type RESPOptions struct {
+	// Addr is the host:port of the server.
+	Addr string
+	// Password, if set, is sent with AUTH on every new connection.
+	Password string
+	// DB, if not zero, is selected on every new connection.
+	DB int
+	// DialTimeout bounds connecting and authenticating. Defaults to DefaultRESPDialTimeout.
+	DialTimeout time.Duration
+	// PoolSize is the number of idle connections kept for reuse. Defaults to DefaultRESPPoolSize.
+	PoolSize int
+}
+
+// RESPClient is a minimal client for servers speaking the Redis serialization protocol
+// (RESP2), covering the commands the remote cache tier needs. It is safe for concurrent
+// use; connections are dialled on demand and pooled.
+type RESPClient struct {
+	opts RESPOptions
+	idle chan *respConn
+
+	mu     sync.Mutex
+	closed bool
+}
+
+type respConn struct {
+	net.Conn
+	r *bufio.Reader
+	w *bufio.Writer
+}
+
+// NewRESPClient creates a client for the server at opts.Addr. No connection is made until
+// the first command.
+func NewRESPClient(opts RESPOptions) *RESPClient {
+	if opts.DialTimeout <= 0 {
+		opts.DialTimeout = DefaultRESPDialTimeout
+	}
+	if opts.PoolSize <= 0 {
+		opts.PoolSize = DefaultRESPPoolSize
+	}
+	return &RESPClient{opts: opts, idle: make(chan *respConn, opts.PoolSize)}
+}
+
+// Do sends a command and returns its reply: a string for simple and bulk strings, nil for
+// a null bulk string, an int64 for integers and a []interface{} for arrays. Error replies
+// are returned as RESPError. The context deadline bounds the whole round trip.
+func (c *RESPClient) Do(ctx context.Context, args ...string) (interface{}, error) {
+	conn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := conn.do(ctx, args)
+	var respErr RESPError
+	if err != nil && !errors.As(err, &respErr) {
+		// The connection may hold a partial reply, so it cannot be reused.
+		conn.Close()
+		return nil, err
+	}
+	c.put(conn)
+	return reply, err
+}
+
+// Ping checks that the server is reachable.
+func (c *RESPClient) Ping(ctx context.Context) error {
+	_, err := c.Do(ctx, "PING")
+	return err
+}
+
+// Get returns the value stored under key and whether it exists.
+func (c *RESPClient) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := c.Do(ctx, "GET", key)
+	if err != nil || reply == nil {
+		return nil, false, err
+	}
+	s, ok := reply.(string)
+	if !ok {
+		return nil, false, fmt.Errorf("GET: unexpected reply %T", reply)
+	}
+	return []byte(s), true, nil
+}
+
+// Set stores value under key, expiring it after ttl unless ttl is zero.
+func (c *RESPClient) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	args := []string{"SET", key, string(value)}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(max(ttl.Milliseconds(), 1), 10))
+	}
+	_, err := c.Do(ctx, args...)
+	return err
+}
+
+// PTTL returns the time left before key expires, zero if it never does, and whether it
+// exists.
+func (c *RESPClient) PTTL(ctx context.Context, key string) (time.Duration, bool, error) {
+	reply, err := c.Do(ctx, "PTTL", key)
+	if err != nil {
+		return 0, false, err
+	}
+	ms, ok := reply.(int64)
+	if !ok {
+		return 0, false, fmt.Errorf("PTTL: unexpected reply %T", reply)
+	}
+	switch {
+	case ms == -2:
+		return 0, false, nil
+	case ms < 0:
+		return 0, true, nil
+	default:
+		return time.Duration(ms) * time.Millisecond, true, nil
+	}
+}
+
+// Del removes keys and returns how many existed.
+func (c *RESPClient) Del(ctx context.Context, keys ...string) (int64, error) {
+	reply, err := c.Do(ctx, append([]string{"DEL"}, keys...)...)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := reply.(int64)
+	if !ok {
+		return 0, fmt.Errorf("DEL: unexpected reply %T", reply)
+	}
+	return n, nil
+}
+
+// Scan returns every key matching the glob pattern, iterating SCAN until the cursor
+// wraps around.
+func (c *RESPClient) Scan(ctx context.Context, pattern string) ([]string, error) {
+	var keys []string
+	cursor := "0"
+	for {
+		next, page, err := c.ScanPage(ctx, cursor, pattern)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, page...)
+		if next == "0" {
+			return keys, nil
+		}
+		cursor = next
+	}
+}
+
+// ScanPage runs a single SCAN from cursor and returns the next cursor, "0" once the
+// iteration is complete, with the page of keys matching the glob pattern.
+func (c *RESPClient) ScanPage(ctx context.Context, cursor, pattern string) (string, []string, error) {
+	reply, err := c.Do(ctx, "SCAN", cursor, "MATCH", pattern, "COUNT", "100")
+	if err != nil {
+		return "", nil, err
+	}
+	parts, ok := reply.([]interface{})
+	if !ok || len(parts) != 2 {
+		return "", nil, fmt.Errorf("SCAN: unexpected reply %v", reply)
+	}
+	next, _ := parts[0].(string)
+	if next == "" {
+		next = "0"
+	}
+	batch, _ := parts[1].([]interface{})
+	keys := make([]string, 0, len(batch))
+	for _, k := range batch {
+		if s, ok := k.(string); ok {
+			keys = append(keys, s)
+		}
+	}
+	return next, keys, nil
+}
+
+// Close closes the idle connections. Connections in use are closed when returned.
+func (c *RESPClient) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	close(c.idle)
+	for conn := range c.idle {
+		conn.Close()
+	}
+	return nil
+}
+
+// get returns an idle connection or dials a new one.
+func (c *RESPClient) get(ctx context.Context) (*respConn, error) {
+	select {
+	case conn, ok := <-c.idle:
+		if ok {
+			return conn, nil
+		}
+		return nil, errors.New("client closed")
+	default:
+	}
+
+	dialCtx, cancel := context.WithTimeout(ctx, c.opts.DialTimeout)
+	defer cancel()
+
+	var d net.Dialer
+	nc, err := d.DialContext(dialCtx, "tcp", c.opts.Addr)
+	if err != nil {
+		return nil, err
+	}
+	conn := &respConn{Conn: nc, r: bufio.NewReader(nc), w: bufio.NewWriter(nc)}
+
+	if c.opts.Password != "" {
+		if _, err := conn.do(dialCtx, []string{"AUTH", c.opts.Password}); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("AUTH: %w", err)
+		}
+	}
+	if c.opts.DB != 0 {
+		if _, err := conn.do(dialCtx, []string{"SELECT", strconv.Itoa(c.opts.DB)}); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("SELECT: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+// put returns conn to the pool, closing it if the pool is full or the client closed.
+func (c *RESPClient) put(conn *respConn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		conn.Close()
+		return
+	}
+	select {
+	case c.idle <- conn:
+	default:
+		conn.Close()
+	}
+}
+
+// do writes one command and reads its reply within the context deadline.
+func (conn *respConn) do(ctx context.Context, args []string) (interface{}, error) {
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(conn.w, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(conn.w, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if err := conn.w.Flush(); err != nil {
+		return nil, err
+	}
+	return readReply(conn.r)
+}
+
+// readReply reads one RESP2 reply.
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("malformed reply %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, RESPError(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, fmt.Errorf("malformed bulk length %q", body)
+		}
+		if n == -1 {
+			return nil, nil
+		}
+		if n < 0 || n > maxRESPBulkLen {
+			return nil, fmt.Errorf("bulk length %d out of range", n)
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, fmt.Errorf("malformed array length %q", body)
+		}
+		if n == -1 {
+			return nil, nil
+		}
+		if n < 0 || n > maxRESPArrayLen {
+			return nil, fmt.Errorf("array length %d out of range", n)
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			if items[i], err = readReply(r); err != nil {
+				var respErr RESPError
+				if !errors.As(err, &respErr) {
+					return nil, err
+				}
+				items[i] = respErr
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("unknown reply type %q", kind)
+	}
+}
@@ -0,0 +1,320 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"maps"
+	"net"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRESP is an in-process server speaking enough RESP2 for RESPClient: PING, AUTH,
+// SELECT, GET, SET with PX, PTTL, DEL and SCAN.
+type fakeRESP struct {
+	ln       net.Listener
+	password string
+
+	mu       sync.Mutex
+	data     map[string]string
+	expires  map[string]time.Time
+	delay    time.Duration
+	commands []string
+	conns    map[net.Conn]bool
+}
+
+func newFakeRESP(t *testing.T, password string) *fakeRESP {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := &fakeRESP{
+		ln:       ln,
+		password: password,
+		data:     make(map[string]string),
+		expires:  make(map[string]time.Time),
+		conns:    make(map[net.Conn]bool),
+	}
+	go s.serve()
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *fakeRESP) Addr() string { return s.ln.Addr().String() }
+
+// Close stops the server and drops every connection.
+func (s *fakeRESP) Close() {
+	s.ln.Close()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+}
+
+// SetDelay makes the server wait d before every reply.
+func (s *fakeRESP) SetDelay(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delay = d
+}
+
+// Value returns the raw value stored under key.
+func (s *fakeRESP) Value(key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.data[key]
+	return v, ok
+}
+
+// Put stores value under key directly.
+func (s *fakeRESP) Put(key, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[key] = value
+}
+
+// Commands returns the names of the commands received so far.
+func (s *fakeRESP) Commands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.commands...)
+}
+
+func (s *fakeRESP) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns[conn] = true
+		s.mu.Unlock()
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeRESP) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	authed := s.password == ""
+
+	for {
+		reply, err := readReply(r)
+		if err != nil {
+			return
+		}
+		items, _ := reply.([]interface{})
+		args := make([]string, len(items))
+		for i, item := range items {
+			args[i], _ = item.(string)
+		}
+		if len(args) == 0 {
+			return
+		}
+
+		s.mu.Lock()
+		delay := s.delay
+		s.commands = append(s.commands, strings.ToUpper(args[0]))
+		s.mu.Unlock()
+		time.Sleep(delay)
+
+		var out string
+		switch cmd := strings.ToUpper(args[0]); {
+		case cmd == "AUTH":
+			authed = len(args) == 2 && args[1] == s.password
+			out = "+OK\r\n"
+			if !authed {
+				out = "-WRONGPASS invalid password\r\n"
+			}
+		case !authed:
+			out = "-NOAUTH Authentication required.\r\n"
+		default:
+			out = s.exec(cmd, args[1:])
+		}
+		if _, err := conn.Write([]byte(out)); err != nil {
+			return
+		}
+	}
+}
+
+func (s *fakeRESP) exec(cmd string, args []string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, at := range s.expires {
+		if !time.Now().Before(at) {
+			delete(s.data, key)
+			delete(s.expires, key)
+		}
+	}
+
+	switch cmd {
+	case "PING":
+		return "+PONG\r\n"
+	case "SELECT":
+		return "+OK\r\n"
+	case "GET":
+		v, ok := s.data[args[0]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return bulk(v)
+	case "SET":
+		s.data[args[0]] = args[1]
+		delete(s.expires, args[0])
+		if len(args) == 4 && strings.ToUpper(args[2]) == "PX" {
+			ms, _ := strconv.Atoi(args[3])
+			s.expires[args[0]] = time.Now().Add(time.Duration(ms) * time.Millisecond)
+		}
+		return "+OK\r\n"
+	case "PTTL":
+		if _, ok := s.data[args[0]]; !ok {
+			return ":-2\r\n"
+		}
+		at, ok := s.expires[args[0]]
+		if !ok {
+			return ":-1\r\n"
+		}
+		return fmt.Sprintf(":%d\r\n", time.Until(at).Milliseconds())
+	case "DEL":
+		n := 0
+		for _, key := range args {
+			if _, ok := s.data[key]; ok {
+				delete(s.data, key)
+				n++
+			}
+		}
+		return fmt.Sprintf(":%d\r\n", n)
+	case "SCAN":
+		// Each call examines COUNT of the sorted keys. The cursor holds the next key to
+		// examine, so keys deleted between calls do not make the scan skip others.
+		pattern, count := "*", 10
+		for i := 1; i+1 < len(args); i += 2 {
+			switch strings.ToUpper(args[i]) {
+			case "MATCH":
+				pattern = args[i+1]
+			case "COUNT":
+				count, _ = strconv.Atoi(args[i+1])
+			}
+		}
+		all := slices.Sorted(maps.Keys(s.data))
+		start := 0
+		if from, ok := strings.CutPrefix(args[0], "from:"); ok {
+			start, _ = slices.BinarySearch(all, from)
+		}
+		end := min(start+count, len(all))
+		var keys []string
+		for _, key := range all[start:end] {
+			if ok, _ := path.Match(pattern, key); ok {
+				keys = append(keys, key)
+			}
+		}
+		next := "0"
+		if end < len(all) {
+			next = "from:" + all[end]
+		}
+		out := "*2\r\n" + bulk(next) + fmt.Sprintf("*%d\r\n", len(keys))
+		for _, key := range keys {
+			out += bulk(key)
+		}
+		return out
+	default:
+		return "-ERR unknown command '" + cmd + "'\r\n"
+	}
+}
+
+func bulk(s string) string {
+	return fmt.Sprintf("$%d\r\n%s\r\n", len(s), s)
+}
+
+// TestRESPClient tests the commands used by the remote cache tier against the fake server.
+func TestRESPClient(t *testing.T) {
+	server := newFakeRESP(t, "secret")
+	client := NewRESPClient(RESPOptions{Addr: server.Addr(), Password: "secret", DB: 2})
+	defer client.Close()
+	ctx := context.Background()
+
+	require.NoError(t, client.Ping(ctx))
+
+	_, found, err := client.Get(ctx, "missing")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	require.NoError(t, client.Set(ctx, "a", []byte("line1\r\nline2"), 0))
+	require.NoError(t, client.Set(ctx, "b", []byte("2"), time.Hour))
+	value, found, err := client.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "line1\r\nline2", string(value), "values are binary safe")
+
+	ttl, found, err := client.PTTL(ctx, "b")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.InDelta(t, time.Hour, ttl, float64(time.Minute))
+	ttl, found, err = client.PTTL(ctx, "a")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Zero(t, ttl, "keys without expiry")
+	_, found, err = client.PTTL(ctx, "missing")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	keys, err := client.Scan(ctx, "*")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a", "b"}, keys)
+
+	n, err := client.Del(ctx, "a", "b", "c")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	_, err = client.Do(ctx, "NOPE")
+	assert.Equal(t, RESPError("ERR unknown command 'NOPE'"), err)
+	require.NoError(t, client.Ping(ctx), "error replies leave the connection usable")
+
+	assert.Equal(t, []string{"AUTH", "SELECT", "PING"}, server.Commands()[:3])
+}
+
+// TestRESPClient_Failures tests authentication failures, timeouts and unreachable servers.
+func TestRESPClient_Failures(t *testing.T) {
+	server := newFakeRESP(t, "secret")
+
+	wrong := NewRESPClient(RESPOptions{Addr: server.Addr(), Password: "wrong"})
+	assert.ErrorContains(t, wrong.Ping(context.Background()), "AUTH: WRONGPASS")
+
+	client := NewRESPClient(RESPOptions{Addr: server.Addr(), Password: "secret"})
+	server.SetDelay(50 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Error(t, client.Ping(ctx))
+
+	server.SetDelay(0)
+	server.Close()
+	assert.Error(t, client.Ping(context.Background()))
+
+	client.Close()
+	assert.ErrorContains(t, client.Ping(context.Background()), "client closed")
+}
+
+// TestReadReply_Lengths tests that out-of-range lengths are rejected before allocating.
+func TestReadReply_Lengths(t *testing.T) {
+	for _, reply := range []string{"$-5\r\n", "$999999999999\r\n", "*-3\r\n", "*99999999\r\n"} {
+		_, err := readReply(bufio.NewReader(strings.NewReader(reply)))
+		assert.ErrorContains(t, err, "out of range", reply)
+	}
+
+	for _, reply := range []string{"$-1\r\n", "*-1\r\n"} {
+		value, err := readReply(bufio.NewReader(strings.NewReader(reply)))
+		require.NoError(t, err, reply)
+		assert.Nil(t, value, "null replies")
+	}
+}
@@ -0,0 +1,190 @@
+package cache
+
+import (
+	"fmt"
+	"hash/maphash"
+	"math/bits"
+	"runtime"
+	"slices"
+	"time"
+)
+
+// ShardedCache spreads its entries over independently locked InMemoryCache shards chosen
+// by key hash, so writers only block readers of the same shard. It behaves like a single
+// InMemoryCache: TTLs, hit counts and snapshots work the same way.
+type ShardedCache[K comparable, V any] struct {
+	shards []*InMemoryCache[K, V]
+	mask   uint64
+	seed   maphash.Seed
+}
+
+// DefaultShards returns the shard count used when none is given: four per CPU.
+func DefaultShards() int {
+	return 4 * runtime.GOMAXPROCS(0)
+}
+
+// NewShardedCache creates a cache split into shards shards, rounded up to a power of two.
+// Zero or less uses DefaultShards. Options apply to every shard, except that a WithEviction
+// limit is split evenly between the shards, rounding up, and each shard evicts on its own.
+func NewShardedCache[K comparable, V any](shards int, opts ...Option) *ShardedCache[K, V] {
+	if shards <= 0 {
+		shards = DefaultShards()
+	}
+	n := 1 << bits.Len(uint(shards-1))
+	opts = append(slices.Clip(opts), func(o *options) {
+		o.maxEntries = (o.maxEntries + n - 1) / n
+	})
+
+	c := &ShardedCache[K, V]{
+		shards: make([]*InMemoryCache[K, V], n),
+		mask:   uint64(n - 1),
+		seed:   maphash.MakeSeed(),
+	}
+	for i := range c.shards {
+		c.shards[i] = NewInMemoryCache[K, V](opts...)
+	}
+	return c
+}
+
+// shard returns the shard holding key.
+func (c *ShardedCache[K, V]) shard(key K) *InMemoryCache[K, V] {
+	return c.shards[hashKey(c.seed, key)&c.mask]
+}
+
+// hashKey hashes key with seed.
+func hashKey[K comparable](seed maphash.Seed, key K) uint64 {
+	// maphash.String avoids the allocation Comparable makes for string keys.
+	if s, ok := any(key).(string); ok {
+		return maphash.String(seed, s)
+	}
+	return maphash.Comparable(seed, key)
+}
+
+// Shards returns the number of shards.
+func (c *ShardedCache[K, V]) Shards() int {
+	return len(c.shards)
+}
+
+// Get retrieves a value from the cache by key. Expired entries are removed and reported as missing.
+func (c *ShardedCache[K, V]) Get(key K) (V, bool) {
+	return c.shard(key).Get(key)
+}
+
+// Set stores a value in the cache with the specified key.
+func (c *ShardedCache[K, V]) Set(key K, value V) {
+	c.shard(key).Set(key, value)
+}
+
+// SetWithExpiry stores value under key, expiring it at expiresAt or when the TTL runs out,
+// whichever comes first.
+func (c *ShardedCache[K, V]) SetWithExpiry(key K, value V, expiresAt time.Time) {
+	c.shard(key).SetWithExpiry(key, value, expiresAt)
+}
+
+// Delete removes key from the cache and reports whether it was present.
+func (c *ShardedCache[K, V]) Delete(key K) bool {
+	return c.shard(key).Delete(key)
+}
+
+// Keys returns the keys of the unexpired entries in no particular order.
+func (c *ShardedCache[K, V]) Keys() []K {
+	var keys []K
+	for _, s := range c.shards {
+		keys = append(keys, s.Keys()...)
+	}
+	return keys
+}
+
+// Len returns the number of entries in the cache, including expired entries not yet evicted.
+func (c *ShardedCache[K, V]) Len() int {
+	n := 0
+	for _, s := range c.shards {
+		n += s.Len()
+	}
+	return n
+}
+
+// Clear removes every entry from the cache. Entries stored concurrently may survive.
+func (c *ShardedCache[K, V]) Clear() {
+	for _, s := range c.shards {
+		s.Clear()
+	}
+}
+
+// Entry describes the unexpired entry stored under key. Unlike Get, it does not count as a hit.
+func (c *ShardedCache[K, V]) Entry(key K) (EntryInfo[K, V], bool) {
+	return c.shard(key).Entry(key)
+}
+
+// Entries describes every unexpired entry in no particular order.
+func (c *ShardedCache[K, V]) Entries() []EntryInfo[K, V] {
+	var entries []EntryInfo[K, V]
+	for _, s := range c.shards {
+		entries = append(entries, s.Entries()...)
+	}
+	return entries
+}
+
+// TTL returns the current entry lifetime; zero means entries never expire.
+func (c *ShardedCache[K, V]) TTL() time.Duration {
+	return c.shards[0].TTL()
+}
+
+// SetTTL changes the entry lifetime of every shard.
+func (c *ShardedCache[K, V]) SetTTL(ttl time.Duration) {
+	for _, s := range c.shards {
+		s.SetTTL(ttl)
+	}
+}
+
+// DeleteExpired removes every expired entry and returns how many were removed.
+func (c *ShardedCache[K, V]) DeleteExpired() int {
+	removed := 0
+	for _, s := range c.shards {
+		removed += s.DeleteExpired()
+	}
+	return removed
+}
+
+// Stats returns the sum of every shard's statistics. Each shard keeps its own counters,
+// so concurrent lookups on different shards never touch the same ones.
+func (c *ShardedCache[K, V]) Stats() Stats {
+	var stats Stats
+	for _, s := range c.shards {
+		stats = stats.add(s.Stats())
+	}
+	return stats
+}
+
+// SaveSnapshot writes every unexpired entry to path in the same format as
+// InMemoryCache.SaveSnapshot; either cache can load the other's snapshots.
+func (c *ShardedCache[K, V]) SaveSnapshot(path string) (int, error) {
+	n, err := writeSnapshot(path, c.shards[0].now(), c.TTL(), c.Entries())
+	if err != nil {
+		return 0, fmt.Errorf("SaveSnapshot: %w", err)
+	}
+	return n, nil
+}
+
+// LoadSnapshot restores the entries saved in path, as InMemoryCache.LoadSnapshot does.
+func (c *ShardedCache[K, V]) LoadSnapshot(path string) (int, error) {
+	entries, err := readSnapshot[K, V](path, c.shards[0].now())
+	if err != nil {
+		return 0, fmt.Errorf("LoadSnapshot: %w", err)
+	}
+
+	perShard := make(map[*InMemoryCache[K, V]]map[K]*entry[V], len(c.shards))
+	for key, e := range entries {
+		s := c.shard(key)
+		if perShard[s] == nil {
+			perShard[s] = make(map[K]*entry[V])
+		}
+		perShard[s][key] = e
+	}
+
+	n := 0
+	for s, shardEntries := range perShard {
+		n += s.restore(shardEntries)
+	}
+	return n, nil
+}
@@ -0,0 +1,145 @@
+package cache
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setClock makes every shard of c read the time from now.
+func setClock[K comparable, V any](c *ShardedCache[K, V], now *time.Time) {
+	for _, s := range c.shards {
+		s.now = func() time.Time { return *now }
+	}
+}
+
+// TestNewShardedCache tests that shard counts are rounded up to a power of two.
+func TestNewShardedCache(t *testing.T) {
+	assert.Equal(t, 1, NewShardedCache[string, int](1).Shards())
+	assert.Equal(t, 8, NewShardedCache[string, int](5).Shards())
+	assert.Equal(t, 16, NewShardedCache[string, int](16).Shards())
+	assert.GreaterOrEqual(t, NewShardedCache[string, int](0).Shards(), DefaultShards())
+}
+
+// TestShardedCache tests that the sharded cache stores, lists and removes entries like a single cache.
+func TestShardedCache(t *testing.T) {
+	c := NewShardedCache[string, int](8)
+
+	want := make([]string, 0, 100)
+	for i := range 100 {
+		key := fmt.Sprintf("key%d", i)
+		c.Set(key, i)
+		want = append(want, key)
+	}
+
+	value, found := c.Get("key42")
+	assert.True(t, found)
+	assert.Equal(t, 42, value)
+	assert.Equal(t, 100, c.Len())
+	assert.ElementsMatch(t, want, c.Keys())
+	assert.Len(t, c.Entries(), 100)
+
+	nonEmpty := 0
+	for _, s := range c.shards {
+		if s.Len() > 0 {
+			nonEmpty++
+		}
+	}
+	assert.Greater(t, nonEmpty, 1, "keys are spread over shards")
+
+	assert.True(t, c.Delete("key42"))
+	assert.False(t, c.Delete("key42"))
+	_, found = c.Get("key42")
+	assert.False(t, found)
+
+	c.Clear()
+	assert.Equal(t, 0, c.Len())
+
+	ints := NewShardedCache[int, string](4)
+	ints.Set(7, "seven")
+	s, _ := ints.Get(7)
+	assert.Equal(t, "seven", s)
+}
+
+// TestShardedCache_TTL tests expiry, hit counts and expiration counts across shards.
+func TestShardedCache_TTL(t *testing.T) {
+	now := time.Now()
+	c := NewShardedCache[string, int](4, WithTTL(time.Minute))
+	setClock(c, &now)
+
+	for i := range 10 {
+		c.Set(fmt.Sprint(i), i)
+	}
+	c.Get("3")
+	info, found := c.Entry("3")
+	require.True(t, found)
+	assert.Equal(t, int64(1), info.Hits)
+	assert.Equal(t, now.Add(time.Minute), info.ExpiresAt)
+
+	now = now.Add(time.Minute)
+	assert.Empty(t, c.Keys())
+	assert.Equal(t, 10, c.DeleteExpired())
+	assert.Equal(t, int64(10), c.Stats().Expirations)
+
+	c.SetTTL(time.Hour)
+	assert.Equal(t, time.Hour, c.TTL())
+	c.Set("a", 1)
+	now = now.Add(30 * time.Minute)
+	_, found = c.Get("a")
+	assert.True(t, found, "SetTTL applies to every shard")
+}
+
+// TestShardedCache_Snapshot tests that sharded and single caches load each other's snapshots.
+func TestShardedCache_Snapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+
+	sharded := NewShardedCache[string, string](4)
+	sharded.Set("germany", "Berlin")
+	sharded.Set("france", "Paris")
+	n, err := sharded.SaveSnapshot(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	single := NewInMemoryCache[string, string]()
+	n, err = single.LoadSnapshot(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	single.Set("spain", "Madrid")
+	_, err = single.SaveSnapshot(path)
+	require.NoError(t, err)
+
+	restored := NewShardedCache[string, string](4)
+	n, err = restored.LoadSnapshot(path)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	value, _ := restored.Get("spain")
+	assert.Equal(t, "Madrid", value)
+}
+
+// TestShardedCache_ConcurrentAccess tests concurrent reads and writes across shards.
+func TestShardedCache_ConcurrentAccess(t *testing.T) {
+	c := NewShardedCache[string, int](0)
+	var wg sync.WaitGroup
+
+	for i := range 100 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			c.Set(fmt.Sprint(i%10), i)
+		}()
+		go func() {
+			defer wg.Done()
+			c.Get(fmt.Sprint(i % 10))
+			c.Keys()
+		}()
+	}
+
+	wg.Wait()
+	assert.Equal(t, 10, c.Len())
+}
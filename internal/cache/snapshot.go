@@ -0,0 +1,170 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SnapshotVersion is the snapshot file format written by SaveSnapshot.
+const SnapshotVersion = 2
+
+// ErrCorruptSnapshot is returned by LoadSnapshot for files that cannot be parsed or were
+// written in an unsupported format.
+var ErrCorruptSnapshot = errors.New("corrupt cache snapshot")
+
+// snapshot is the on-disk form of a cache.
+type snapshot[K comparable] struct {
+	Version int       `json:"version"`
+	SavedAt time.Time `json:"saved_at"`
+	// TTL is the cache TTL at the time of the snapshot, for reference.
+	TTL     time.Duration      `json:"ttl"`
+	Entries []snapshotEntry[K] `json:"entries"`
+}
+
+type snapshotEntry[K comparable] struct {
+	Key       K               `json:"key"`
+	Value     json.RawMessage `json:"value"`
+	StoredAt  time.Time       `json:"stored_at"`
+	ExpiresAt *time.Time      `json:"expires_at,omitempty"`
+}
+
+// SaveSnapshot writes every unexpired entry, with the time it was stored and when it
+// expires, to path as JSON. Keys and values must be JSON-encodable. The file is written
+// to a temporary file in the same directory and renamed into place, so readers never see
+// a partial snapshot. It returns the number of entries written.
+func (c *InMemoryCache[K, V]) SaveSnapshot(path string) (int, error) {
+	n, err := writeSnapshot(path, c.now(), c.TTL(), c.Entries())
+	if err != nil {
+		return 0, fmt.Errorf("SaveSnapshot: %w", err)
+	}
+	return n, nil
+}
+
+// LoadSnapshot restores the entries saved in path by SaveSnapshot, keeping the time each
+// was stored so TTLs carry on from before the restart. Entries that expired, either by
+// their recorded expiry or by the cache's current TTL, are discarded, as are values that
+// no longer decode into V. A missing file restores nothing; an unreadable one returns an
+// error wrapping ErrCorruptSnapshot and leaves the cache untouched. It returns the number
+// of entries restored.
+func (c *InMemoryCache[K, V]) LoadSnapshot(path string) (int, error) {
+	entries, err := readSnapshot[K, V](path, c.now())
+	if err != nil {
+		return 0, fmt.Errorf("LoadSnapshot: %w", err)
+	}
+	return c.restore(entries), nil
+}
+
+// restore adds the unexpired entries to the cache, keeping any entry already stored under
+// the same key, and returns how many were added.
+func (c *InMemoryCache[K, V]) restore(entries map[K]*entry[V]) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := 0
+	for key, e := range entries {
+		if c.expired(e) {
+			continue
+		}
+		// Entries stored since startup are newer than anything in the snapshot.
+		if _, exists := c.store[key]; !exists {
+			e.size = entrySize(key, e.value)
+			c.put(key, e)
+			n++
+		}
+	}
+	return n
+}
+
+// writeSnapshot atomically writes entries to path and returns how many were written.
+func writeSnapshot[K comparable, V any](path string, savedAt time.Time, ttl time.Duration, entries []EntryInfo[K, V]) (int, error) {
+	snap := snapshot[K]{Version: SnapshotVersion, SavedAt: savedAt.UTC(), TTL: ttl}
+	for _, info := range entries {
+		value, err := json.Marshal(info.Value)
+		if err != nil {
+			return 0, fmt.Errorf("key %v: %w", info.Key, err)
+		}
+		e := snapshotEntry[K]{Key: info.Key, Value: value, StoredAt: info.StoredAt.UTC()}
+		if !info.ExpiresAt.IsZero() {
+			expiresAt := info.ExpiresAt.UTC()
+			e.ExpiresAt = &expiresAt
+		}
+		snap.Entries = append(snap.Entries, e)
+	}
+
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return 0, err
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		return 0, err
+	}
+	return len(snap.Entries), nil
+}
+
+// readSnapshot returns the entries in the snapshot at path that had not expired by now
+// and still decode into V. A missing file has no entries.
+func readSnapshot[K comparable, V any](path string, now time.Time) (map[K]*entry[V], error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var snap snapshot[K]
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCorruptSnapshot, err)
+	}
+	if snap.Version != SnapshotVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrCorruptSnapshot, snap.Version)
+	}
+
+	entries := make(map[K]*entry[V], len(snap.Entries))
+	for _, e := range snap.Entries {
+		if e.ExpiresAt != nil && !now.Before(*e.ExpiresAt) {
+			continue
+		}
+		var value V
+		if err := json.Unmarshal(e.Value, &value); err != nil {
+			continue
+		}
+		restored := &entry[V]{value: value, storedAt: e.StoredAt}
+		// Keep expiries set per entry, i.e. earlier than the TTL alone would give.
+		if e.ExpiresAt != nil && (snap.TTL <= 0 || e.ExpiresAt.Before(e.StoredAt.Add(snap.TTL))) {
+			restored.expiresAt = *e.ExpiresAt
+		}
+		entries[e.Key] = restored
+	}
+	return entries, nil
+}
+
+// writeFileAtomic replaces path with data via a synced temporary file and a rename.
+func writeFileAtomic(path string, data []byte) (err error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err = tmp.Write(data); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
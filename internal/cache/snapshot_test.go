@@ -0,0 +1,128 @@
+package cache
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCache_Snapshot tests that a snapshot round-trips entries with their timestamps.
+func TestCache_Snapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	now := time.Now().Truncate(time.Second)
+
+	c := NewInMemoryCache[string, string](WithTTL(time.Hour))
+	c.now = func() time.Time { return now }
+	c.Set("germany", "Berlin")
+	c.Set("france", "Paris")
+
+	n, err := c.SaveSnapshot(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	matches, _ := filepath.Glob(path + ".tmp-*")
+	assert.Empty(t, matches, "temporary files are renamed into place")
+
+	now = now.Add(10 * time.Minute)
+	restored := NewInMemoryCache[string, string](WithTTL(time.Hour))
+	restored.now = func() time.Time { return now }
+	n, err = restored.LoadSnapshot(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	info, found := restored.Entry("germany")
+	require.True(t, found)
+	assert.Equal(t, "Berlin", info.Value)
+	assert.True(t, info.StoredAt.Equal(now.Add(-10*time.Minute)), "the original store time is kept")
+	assert.True(t, info.ExpiresAt.Equal(now.Add(50*time.Minute)))
+}
+
+// TestCache_Snapshot_EntryExpiry tests that expiries set per entry survive a snapshot.
+func TestCache_Snapshot_EntryExpiry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	now := time.Now().Truncate(time.Second)
+
+	c := NewInMemoryCache[string, string](WithTTL(time.Hour))
+	c.now = func() time.Time { return now }
+	c.SetWithExpiry("germany", "Berlin", now.Add(5*time.Minute))
+	_, err := c.SaveSnapshot(path)
+	require.NoError(t, err)
+
+	restored := NewInMemoryCache[string, string](WithTTL(2 * time.Hour))
+	restored.now = func() time.Time { return now }
+	_, err = restored.LoadSnapshot(path)
+	require.NoError(t, err)
+
+	info, found := restored.Entry("germany")
+	require.True(t, found)
+	assert.True(t, info.ExpiresAt.Equal(now.Add(5*time.Minute)))
+	now = now.Add(5 * time.Minute)
+	assert.Equal(t, 1, restored.DeleteExpired())
+}
+
+// TestCache_LoadSnapshot_Expired tests that entries expired by either their recorded expiry
+// or the current TTL are discarded, and entries stored since startup are kept.
+func TestCache_LoadSnapshot_Expired(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	now := time.Now()
+
+	c := NewInMemoryCache[string, string](WithTTL(time.Hour))
+	c.now = func() time.Time { return now }
+	c.Set("old", "a")
+	now = now.Add(30 * time.Minute)
+	c.Set("recent", "b")
+	c.Set("fresh", "c")
+	_, err := c.SaveSnapshot(path)
+	require.NoError(t, err)
+
+	now = now.Add(45 * time.Minute)
+	restored := NewInMemoryCache[string, string](WithTTL(time.Hour))
+	restored.now = func() time.Time { return now }
+	restored.Set("fresh", "newer")
+	n, err := restored.LoadSnapshot(path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.ElementsMatch(t, []string{"fresh", "recent"}, restored.Keys())
+	value, _ := restored.Get("fresh")
+	assert.Equal(t, "newer", value)
+
+	shorter := NewInMemoryCache[string, string](WithTTL(time.Minute))
+	shorter.now = func() time.Time { return now }
+	n, err = shorter.LoadSnapshot(path)
+	require.NoError(t, err)
+	assert.Zero(t, n, "the current TTL applies to restored entries")
+}
+
+// TestCache_LoadSnapshot_Invalid tests that missing, corrupt and unsupported files are tolerated.
+func TestCache_LoadSnapshot_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	c := NewInMemoryCache[string, string]()
+
+	n, err := c.LoadSnapshot(filepath.Join(dir, "missing.json"))
+	assert.NoError(t, err)
+	assert.Zero(t, n)
+
+	for name, content := range map[string]string{
+		"truncated.json": `{"version":2,"entries":[{"key":"a"`,
+		"version.json":   `{"version":99,"entries":[]}`,
+	} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		_, err := c.LoadSnapshot(path)
+		assert.True(t, errors.Is(err, ErrCorruptSnapshot), name)
+	}
+
+	path := filepath.Join(dir, "values.json")
+	require.NoError(t, os.WriteFile(path, []byte(
+		`{"version":2,"entries":[{"key":"a","value":"x","stored_at":"2030-01-01T00:00:00Z"},{"key":"b","value":7,"stored_at":"2030-01-01T00:00:00Z"}]}`,
+	), 0o600))
+	n, err = c.LoadSnapshot(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n, "values that fail to decode are skipped")
+	assert.Equal(t, []string{"a"}, c.Keys())
+}
@@ -0,0 +1,127 @@
+package cache
+
+import (
+	"reflect"
+	"sync/atomic"
+)
+
+// Stats is a point-in-time view of a cache's activity. The counters only grow.
+type Stats struct {
+	// Hits and Misses count Get calls that did and did not find a live entry.
+	Hits   int64
+	Misses int64
+	// Sets counts Set calls.
+	Sets int64
+	// Evictions counts entries removed to make room for others; caches without a size
+	// limit never evict.
+	Evictions int64
+	// Expirations counts entries removed because they outlived the TTL.
+	Expirations int64
+	// Entries is the number of entries held, including expired entries not yet removed.
+	Entries int
+	// Bytes approximates the memory held by the entries' keys, values and bookkeeping.
+	Bytes int64
+}
+
+// HitRatio returns the fraction of lookups that hit, or zero before any lookup.
+func (s Stats) HitRatio() float64 {
+	if total := s.Hits + s.Misses; total > 0 {
+		return float64(s.Hits) / float64(total)
+	}
+	return 0
+}
+
+// add returns the sum of s and o, for caches made of several parts.
+func (s Stats) add(o Stats) Stats {
+	return Stats{
+		Hits:        s.Hits + o.Hits,
+		Misses:      s.Misses + o.Misses,
+		Sets:        s.Sets + o.Sets,
+		Evictions:   s.Evictions + o.Evictions,
+		Expirations: s.Expirations + o.Expirations,
+		Entries:     s.Entries + o.Entries,
+		Bytes:       s.Bytes + o.Bytes,
+	}
+}
+
+// StatsReporter is implemented by caches that track their activity.
+type StatsReporter interface {
+	Stats() Stats
+}
+
+// counters holds a cache's statistics. They are atomics rather than fields guarded by the
+// cache lock so that hits, which only hold the read lock, never need the write lock. The
+// hit and miss counters are padded onto their own cache lines since every Get updates one.
+type counters struct {
+	hits        atomic.Int64
+	_           [56]byte
+	misses      atomic.Int64
+	_           [56]byte
+	sets        atomic.Int64
+	evictions   atomic.Int64
+	expirations atomic.Int64
+	bytes       atomic.Int64
+}
+
+// maxSizeDepth bounds how deep approxSize follows references, which also stops it at cycles.
+const maxSizeDepth = 8
+
+// approxSize estimates the bytes held by v: its own size plus whatever it references
+// through pointers, interfaces, strings, slices and maps. Memory shared between values is
+// counted once per reference, and map and allocator overheads are ignored.
+func approxSize(v any) int64 {
+	if v == nil {
+		return 0
+	}
+	rv := reflect.ValueOf(v)
+	return int64(rv.Type().Size()) + referencedSize(rv, 0)
+}
+
+// referencedSize returns the bytes v references outside its own inline storage.
+func referencedSize(v reflect.Value, depth int) int64 {
+	if depth > maxSizeDepth {
+		return 0
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return 0
+		}
+		elem := v.Elem()
+		return int64(elem.Type().Size()) + referencedSize(elem, depth+1)
+	case reflect.String:
+		return int64(v.Len())
+	case reflect.Slice:
+		n := int64(v.Cap()) * int64(v.Type().Elem().Size())
+		for i := 0; i < v.Len(); i++ {
+			n += referencedSize(v.Index(i), depth+1)
+		}
+		return n
+	case reflect.Array:
+		var n int64
+		for i := 0; i < v.Len(); i++ {
+			n += referencedSize(v.Index(i), depth+1)
+		}
+		return n
+	case reflect.Struct:
+		var n int64
+		for i := 0; i < v.NumField(); i++ {
+			n += referencedSize(v.Field(i), depth+1)
+		}
+		return n
+	case reflect.Map:
+		if v.IsNil() {
+			return 0
+		}
+		t := v.Type()
+		n := int64(v.Len()) * int64(t.Key().Size()+t.Elem().Size())
+		iter := v.MapRange()
+		for iter.Next() {
+			n += referencedSize(iter.Key(), depth+1) + referencedSize(iter.Value(), depth+1)
+		}
+		return n
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,118 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestInMemoryCache_Stats tests the counters and size tracking of the in-memory cache.
+func TestInMemoryCache_Stats(t *testing.T) {
+	now := time.Now()
+	c := NewInMemoryCache[string, string](WithTTL(time.Minute))
+	c.now = func() time.Time { return now }
+	assert.Equal(t, Stats{}, c.Stats())
+
+	c.Set("a", "apple")
+	c.Set("b", "banana")
+	c.Get("a")
+	c.Get("a")
+	c.Get("missing")
+
+	stats := c.Stats()
+	assert.Equal(t, int64(2), stats.Hits)
+	assert.Equal(t, int64(1), stats.Misses)
+	assert.Equal(t, int64(2), stats.Sets)
+	assert.Equal(t, 2, stats.Entries)
+	assert.InDelta(t, 2.0/3, stats.HitRatio(), 1e-9)
+	assert.Positive(t, stats.Bytes)
+
+	// Replacing an entry swaps its size rather than adding to it.
+	c.Set("b", "blueberry")
+	assert.Equal(t, stats.Bytes+3, c.Stats().Bytes)
+
+	now = now.Add(time.Minute)
+	c.Get("a")
+	assert.Equal(t, 1, c.DeleteExpired())
+
+	stats = c.Stats()
+	assert.Equal(t, int64(2), stats.Misses, "expired entries are misses")
+	assert.Equal(t, int64(2), stats.Expirations)
+	assert.Zero(t, stats.Evictions)
+	assert.Zero(t, stats.Entries)
+	assert.Zero(t, stats.Bytes)
+
+	c.Set("c", "cherry")
+	assert.True(t, c.Delete("c"))
+	assert.Zero(t, c.Stats().Bytes)
+	c.Set("c", "cherry")
+	c.Clear()
+	assert.Zero(t, c.Stats().Bytes)
+}
+
+// TestInMemoryCache_StatsConcurrent tests that counters stay exact under concurrent use.
+func TestInMemoryCache_StatsConcurrent(t *testing.T) {
+	c := NewInMemoryCache[int, int]()
+	c.Set(0, 0)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				c.Get(i % 2)
+			}
+		}()
+	}
+	wg.Wait()
+
+	stats := c.Stats()
+	assert.Equal(t, int64(4000), stats.Hits)
+	assert.Equal(t, int64(4000), stats.Misses)
+}
+
+// TestShardedCache_Stats tests that the sharded cache sums its shards' statistics.
+func TestShardedCache_Stats(t *testing.T) {
+	c := NewShardedCache[string, int](4)
+	single := NewInMemoryCache[string, int]()
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key%d", i)
+		c.Set(key, i)
+		single.Set(key, i)
+	}
+	c.Get("key1")
+	c.Get("missing")
+
+	stats := c.Stats()
+	assert.Equal(t, int64(1), stats.Hits)
+	assert.Equal(t, int64(1), stats.Misses)
+	assert.Equal(t, int64(100), stats.Sets)
+	assert.Equal(t, 100, stats.Entries)
+	assert.Equal(t, single.Stats().Bytes, stats.Bytes)
+}
+
+// TestApproxSize tests the memory estimate of common value shapes.
+func TestApproxSize(t *testing.T) {
+	type country struct {
+		Name       string
+		Population int
+		Tags       []string
+	}
+
+	assert.Zero(t, approxSize(nil))
+	assert.Equal(t, int64(8), approxSize(42))
+	assert.Equal(t, int64(16+5), approxSize("hello"))
+	assert.Equal(t, int64(24+2*16+1+2), approxSize([]string{"a", "bc"}))
+
+	c := &country{Name: "Peru", Population: 1, Tags: []string{"andes"}}
+	assert.Equal(t, int64(8+(16+8+24)+4+16+5), approxSize(c))
+
+	type node struct{ next *node }
+	loop := &node{}
+	loop.next = loop
+	assert.Positive(t, approxSize(loop), "cycles are cut off")
+}
@@ -0,0 +1,290 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/sj1815/golang-country-search/internal/logging"
+)
+
+// Defaults for TieredOptions.
+const (
+	DefaultRemoteTimeout       = 100 * time.Millisecond
+	DefaultRemoteRetryInterval = 5 * time.Second
+)
+
+// TieredOptions configures a TieredCache.
+type TieredOptions struct {
+	// Remote is the shared store behind the local cache.
+	Remote *RESPClient
+	// Prefix namespaces keys in the remote store, e.g. "country-search:".
+	Prefix string
+	// TTL is how long entries live in the remote store; zero keeps them until deleted.
+	TTL time.Duration
+	// Timeout bounds each remote operation. Defaults to DefaultRemoteTimeout.
+	Timeout time.Duration
+	// RetryInterval is how long the cache stays local-only after a remote failure before
+	// trying the remote store again. Defaults to DefaultRemoteRetryInterval.
+	RetryInterval time.Duration
+	// Logger reports the remote store becoming unavailable and recovering. Defaults to slog.Default().
+	Logger *slog.Logger
+}
+
+// TieredCache layers a local cache over a remote store shared by every replica. Gets are
+// served locally when possible and otherwise from the remote store, whose entries are
+// then kept locally until their remote expiry at the latest; writes and deletes go to both. Values are stored remotely as JSON.
+//
+// The remote store is best effort: when it fails or times out the cache keeps working
+// with the local tier alone, skipping the remote store for RetryInterval before trying it
+// again.
+type TieredCache[V any] struct {
+	local  Cache[string, V]
+	remote *RESPClient
+	prefix string
+	logger *slog.Logger
+
+	ttl           atomic.Int64
+	timeout       time.Duration
+	retryInterval time.Duration
+	now           func() time.Time
+
+	// downUntil is the UnixNano time before which the remote store is skipped; zero while healthy.
+	downUntil atomic.Int64
+}
+
+// NewTieredCache creates a cache layering local over opts.Remote.
+func NewTieredCache[V any](local Cache[string, V], opts TieredOptions) *TieredCache[V] {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultRemoteTimeout
+	}
+	if opts.RetryInterval <= 0 {
+		opts.RetryInterval = DefaultRemoteRetryInterval
+	}
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+
+	c := &TieredCache[V]{
+		local:         local,
+		remote:        opts.Remote,
+		prefix:        opts.Prefix,
+		logger:        opts.Logger,
+		timeout:       opts.Timeout,
+		retryInterval: opts.RetryInterval,
+		now:           time.Now,
+	}
+	c.SetTTL(opts.TTL)
+	return c
+}
+
+// Get returns the local entry for key, falling back to the remote store.
+func (c *TieredCache[V]) Get(key string) (V, bool) {
+	if value, found := c.local.Get(key); found {
+		return value, true
+	}
+
+	var zero V
+	if !c.remoteUp() {
+		return zero, false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	data, found, err := c.remote.Get(ctx, c.prefix+key)
+	c.observe("GET", err)
+	if err != nil || !found {
+		return zero, false
+	}
+
+	var value V
+	if err := json.Unmarshal(data, &value); err != nil {
+		// Written by an incompatible version; treat it as a miss so it gets replaced.
+		return zero, false
+	}
+	c.keepLocally(ctx, key, value)
+	return value, true
+}
+
+// keepLocally stores a remote hit in the local tier for no longer than the remote store
+// keeps it, so the entry cannot outlive its remote expiry. Entries whose remaining lifetime
+// is unknown, or that the local tier cannot expire early, are not kept.
+func (c *TieredCache[V]) keepLocally(ctx context.Context, key string, value V) {
+	ttl, found, err := c.remote.PTTL(ctx, c.prefix+key)
+	c.observe("PTTL", err)
+	if err != nil || !found {
+		return
+	}
+	if ttl == 0 {
+		c.local.Set(key, value)
+		return
+	}
+	if setter, ok := c.local.(ExpirySetter[string, V]); ok {
+		setter.SetWithExpiry(key, value, c.now().Add(ttl))
+	}
+}
+
+// Set stores value locally and in the remote store.
+func (c *TieredCache[V]) Set(key string, value V) {
+	c.local.Set(key, value)
+	if !c.remoteUp() {
+		return
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		c.logger.Warn("failed to encode remote cache entry", slog.String("key", key), logging.Error(err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	c.observe("SET", c.remote.Set(ctx, c.prefix+key, data, c.TTL()))
+}
+
+// Delete removes key from both tiers and reports whether either held it.
+func (c *TieredCache[V]) Delete(key string) bool {
+	deleted := c.local.Delete(key)
+	if !c.remoteUp() {
+		return deleted
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	n, err := c.remote.Del(ctx, c.prefix+key)
+	c.observe("DEL", err)
+	return deleted || n > 0
+}
+
+// Keys returns the keys held in the local tier.
+func (c *TieredCache[V]) Keys() []string {
+	return c.local.Keys()
+}
+
+// Len returns the number of entries in the local tier.
+func (c *TieredCache[V]) Len() int {
+	return c.local.Len()
+}
+
+// Clear removes every entry from the local tier and every key under the prefix from the
+// remote store, deleting each page of SCAN results as it arrives so that every remote
+// operation stays within the timeout. Without a prefix the remote store is left alone,
+// since its keys may belong to other applications. Failures are logged.
+func (c *TieredCache[V]) Clear() {
+	c.local.Clear()
+	if c.prefix == "" {
+		c.logger.Warn("remote cache not flushed: no key prefix to limit the flush to")
+		return
+	}
+	if !c.remoteUp() {
+		return
+	}
+
+	deleted, err := c.clearRemote()
+	if err != nil {
+		c.logger.Error("remote cache flush failed", slog.Int64("deleted", deleted), logging.Error(err))
+	}
+	c.observe("CLEAR", err)
+}
+
+// clearRemote deletes every remote key under the prefix and returns how many it deleted.
+func (c *TieredCache[V]) clearRemote() (int64, error) {
+	pattern := escapeGlob(c.prefix) + "*"
+	var deleted int64
+	cursor := "0"
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+		next, keys, err := c.remote.ScanPage(ctx, cursor, pattern)
+		cancel()
+		if err != nil {
+			return deleted, fmt.Errorf("clearRemote: %w", err)
+		}
+
+		if len(keys) > 0 {
+			ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+			n, err := c.remote.Del(ctx, keys...)
+			cancel()
+			if err != nil {
+				return deleted, fmt.Errorf("clearRemote: %w", err)
+			}
+			deleted += n
+		}
+
+		if next == "0" {
+			return deleted, nil
+		}
+		cursor = next
+	}
+}
+
+// Entry describes the local entry stored under key, if the local tier is an Inspector.
+func (c *TieredCache[V]) Entry(key string) (EntryInfo[string, V], bool) {
+	if inspector, ok := c.local.(Inspector[string, V]); ok {
+		return inspector.Entry(key)
+	}
+	return EntryInfo[string, V]{}, false
+}
+
+// Entries describes the local entries, if the local tier is an Inspector.
+func (c *TieredCache[V]) Entries() []EntryInfo[string, V] {
+	if inspector, ok := c.local.(Inspector[string, V]); ok {
+		return inspector.Entries()
+	}
+	return nil
+}
+
+// Stats returns the statistics of the local tier, if it reports any. Remote hits show up
+// as local misses followed by sets.
+func (c *TieredCache[V]) Stats() Stats {
+	if reporter, ok := c.local.(StatsReporter); ok {
+		return reporter.Stats()
+	}
+	return Stats{Entries: c.local.Len()}
+}
+
+// TTL returns the lifetime of remote entries; zero means they never expire.
+func (c *TieredCache[V]) TTL() time.Duration {
+	return time.Duration(c.ttl.Load())
+}
+
+// SetTTL changes the lifetime of entries written to the remote store from now on.
+func (c *TieredCache[V]) SetTTL(ttl time.Duration) {
+	c.ttl.Store(int64(max(ttl, 0)))
+}
+
+// RemoteAvailable reports whether the remote store is in use, i.e. it has not failed
+// within the last RetryInterval.
+func (c *TieredCache[V]) RemoteAvailable() bool {
+	return c.downUntil.Load() == 0
+}
+
+// remoteUp reports whether the remote store should be tried.
+func (c *TieredCache[V]) remoteUp() bool {
+	until := c.downUntil.Load()
+	return until == 0 || c.now().UnixNano() >= until
+}
+
+// observe records the outcome of a remote operation, switching to local-only mode on
+// failure and back once the remote store answers again.
+func (c *TieredCache[V]) observe(op string, err error) {
+	if err != nil {
+		until := c.now().Add(c.retryInterval).UnixNano()
+		if c.downUntil.Swap(until) == 0 {
+			c.logger.Warn("remote cache unavailable, using local cache only",
+				slog.String("op", op), slog.Duration("retry_in", c.retryInterval), logging.Error(err))
+		}
+		return
+	}
+	if c.downUntil.Swap(0) != 0 {
+		c.logger.Info("remote cache available again")
+	}
+}
+
+// escapeGlob escapes the characters SCAN MATCH treats as wildcards.
+func escapeGlob(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`).Replace(s)
+}
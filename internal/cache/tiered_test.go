@@ -0,0 +1,180 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCountry struct {
+	Name       string `json:"name"`
+	Population int    `json:"population"`
+}
+
+func newTestTiered(t *testing.T, server *fakeRESP, opts TieredOptions) (*TieredCache[*testCountry], *InMemoryCache[string, *testCountry]) {
+	t.Helper()
+	remote := NewRESPClient(RESPOptions{Addr: server.Addr()})
+	t.Cleanup(func() { remote.Close() })
+
+	opts.Remote = remote
+	opts.Prefix = "test:"
+	local := NewInMemoryCache[string, *testCountry]()
+	return NewTieredCache(local, opts), local
+}
+
+// TestTieredCache tests that entries written by one replica are served to another.
+func TestTieredCache(t *testing.T) {
+	server := newFakeRESP(t, "")
+	replica1, _ := newTestTiered(t, server, TieredOptions{TTL: time.Hour})
+	replica2, local2 := newTestTiered(t, server, TieredOptions{})
+
+	replica1.Set("germany", &testCountry{Name: "Germany", Population: 83240525})
+	raw, ok := server.Value("test:germany")
+	require.True(t, ok)
+	assert.JSONEq(t, `{"name":"Germany","population":83240525}`, raw)
+
+	value, found := replica2.Get("germany")
+	require.True(t, found)
+	assert.Equal(t, &testCountry{Name: "Germany", Population: 83240525}, value)
+	_, found = local2.Get("germany")
+	assert.True(t, found, "remote hits are kept locally")
+
+	_, found = replica2.Get("france")
+	assert.False(t, found)
+
+	assert.True(t, replica2.Delete("germany"))
+	_, ok = server.Value("test:germany")
+	assert.False(t, ok)
+
+	replica1.Set("spain", &testCountry{Name: "Spain"})
+	server.Put("other:key", "kept")
+	replica2.Clear()
+	_, ok = server.Value("test:spain")
+	assert.False(t, ok, "Clear removes remote keys under the prefix")
+	_, ok = server.Value("other:key")
+	assert.True(t, ok, "keys outside the prefix are kept")
+}
+
+// TestTieredCache_ClearPages tests that Clear deletes keys spread over many SCAN pages.
+func TestTieredCache_ClearPages(t *testing.T) {
+	server := newFakeRESP(t, "")
+	c, _ := newTestTiered(t, server, TieredOptions{})
+	for i := range 500 {
+		server.Put(fmt.Sprintf("test:key%03d", i), "{}")
+		server.Put(fmt.Sprintf("other:key%03d", i), "kept")
+	}
+
+	c.Clear()
+	keys, err := c.remote.Scan(context.Background(), "*")
+	require.NoError(t, err)
+	assert.Len(t, keys, 500)
+	for _, key := range keys {
+		assert.True(t, strings.HasPrefix(key, "other:"), key)
+	}
+	dels := 0
+	for _, cmd := range server.Commands() {
+		if cmd == "DEL" {
+			dels++
+		}
+	}
+	assert.Greater(t, dels, 1, "keys are deleted a page at a time")
+	assert.True(t, c.RemoteAvailable())
+}
+
+// TestTieredCache_ClearWithoutPrefix tests that Clear never flushes a whole remote database.
+func TestTieredCache_ClearWithoutPrefix(t *testing.T) {
+	server := newFakeRESP(t, "")
+	remote := NewRESPClient(RESPOptions{Addr: server.Addr()})
+	t.Cleanup(func() { remote.Close() })
+	var logs bytes.Buffer
+	c := NewTieredCache(NewInMemoryCache[string, *testCountry](), TieredOptions{
+		Remote: remote,
+		Logger: slog.New(slog.NewTextHandler(&logs, nil)),
+	})
+
+	server.Put("someone:else", "kept")
+	c.Clear()
+	_, ok := server.Value("someone:else")
+	assert.True(t, ok)
+	assert.Contains(t, logs.String(), "remote cache not flushed")
+	assert.NotContains(t, server.Commands(), "SCAN")
+}
+
+// TestTieredCache_RemoteUnavailable tests graceful degradation to the local tier and recovery.
+func TestTieredCache_RemoteUnavailable(t *testing.T) {
+	server := newFakeRESP(t, "")
+	var logs bytes.Buffer
+	c, _ := newTestTiered(t, server, TieredOptions{
+		Timeout:       20 * time.Millisecond,
+		RetryInterval: time.Minute,
+		Logger:        slog.New(slog.NewTextHandler(&logs, nil)),
+	})
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	server.SetDelay(100 * time.Millisecond)
+	start := time.Now()
+	c.Set("germany", &testCountry{Name: "Germany"})
+	assert.Less(t, time.Since(start), 90*time.Millisecond, "remote operations time out")
+	assert.False(t, c.RemoteAvailable())
+	assert.Contains(t, logs.String(), "remote cache unavailable")
+
+	// While the remote store is down, it is skipped and the local tier still works.
+	server.SetDelay(0)
+	commands := len(server.Commands())
+	value, found := c.Get("germany")
+	require.True(t, found)
+	assert.Equal(t, "Germany", value.Name)
+	_, found = c.Get("france")
+	assert.False(t, found)
+	c.Set("spain", &testCountry{Name: "Spain"})
+	assert.Len(t, server.Commands(), commands, "the remote store is not contacted")
+
+	now = now.Add(time.Minute)
+	c.Set("italy", &testCountry{Name: "Italy"})
+	assert.True(t, c.RemoteAvailable())
+	assert.Contains(t, logs.String(), "remote cache available again")
+	_, ok := server.Value("test:italy")
+	assert.True(t, ok)
+}
+
+// TestTieredCache_IncompatibleValue tests that remote values that do not decode are misses.
+func TestTieredCache_IncompatibleValue(t *testing.T) {
+	server := newFakeRESP(t, "")
+	c, _ := newTestTiered(t, server, TieredOptions{})
+	server.Put("test:germany", "not json")
+
+	_, found := c.Get("germany")
+	assert.False(t, found)
+	assert.True(t, c.RemoteAvailable(), "bad values do not mark the remote store down")
+}
+
+// TestTieredCache_RemoteExpiry tests that remote hits are kept locally no longer than the
+// remote store keeps them.
+func TestTieredCache_RemoteExpiry(t *testing.T) {
+	server := newFakeRESP(t, "")
+	writer, _ := newTestTiered(t, server, TieredOptions{TTL: time.Minute})
+	reader, local := newTestTiered(t, server, TieredOptions{})
+	local.SetTTL(time.Hour)
+
+	writer.Set("germany", &testCountry{Name: "Germany"})
+	_, found := reader.Get("germany")
+	require.True(t, found)
+
+	info, ok := local.Entry("germany")
+	require.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), info.ExpiresAt, 5*time.Second,
+		"the local entry expires with the remote one, not after the local TTL")
+
+	now := time.Now().Add(2 * time.Minute)
+	local.now = func() time.Time { return now }
+	_, found = local.Get("germany")
+	assert.False(t, found)
+}
@@ -0,0 +1,233 @@
+package cache
+
+import (
+	"container/list"
+	"hash/maphash"
+	"math/bits"
+)
+
+// W-TinyLFU divides the cache into a window holding 1% of the entries and a main area
+// holding the rest, 80% of which is protected from eviction.
+const (
+	windowPercent    = 1
+	protectedPercent = 80
+)
+
+// segment is the part of a W-TinyLFU cache an entry is in.
+type segment uint8
+
+const (
+	inWindow segment = iota
+	inProbation
+	inProtected
+)
+
+type tinyLFUNode[K comparable] struct {
+	key K
+	seg segment
+}
+
+// tinyLFU implements W-TinyLFU as described by Einziger, Friedman and Manes in "TinyLFU: A
+// Highly Efficient Cache Admission Policy". New keys enter an LRU window. A key leaving
+// the window joins the main area's probation segment if there is room, and otherwise only
+// if the sketch estimates it more frequent than the probation segment's LRU key, which is
+// evicted in its place. A hit in probation promotes a key to the protected segment, whose
+// LRU keys are demoted back to probation when it is full. The sketch ages, so keys that
+// were popular once lose their advantage.
+type tinyLFU[K comparable] struct {
+	seed   maphash.Seed
+	sketch *countMinSketch
+
+	windowMax    int
+	mainMax      int
+	protectedMax int
+
+	window    *list.List
+	probation *list.List
+	protected *list.List
+	elems     map[K]*list.Element
+}
+
+func newTinyLFU[K comparable](maxEntries int) *tinyLFU[K] {
+	windowMax := max(maxEntries*windowPercent/100, 1)
+	mainMax := max(maxEntries-windowMax, 0)
+	return &tinyLFU[K]{
+		seed:         maphash.MakeSeed(),
+		sketch:       newCountMinSketch(maxEntries),
+		windowMax:    windowMax,
+		mainMax:      mainMax,
+		protectedMax: mainMax * protectedPercent / 100,
+		window:       list.New(),
+		probation:    list.New(),
+		protected:    list.New(),
+		elems:        make(map[K]*list.Element),
+	}
+}
+
+func (p *tinyLFU[K]) access(key K) {
+	p.sketch.increment(hashKey(p.seed, key))
+
+	el, ok := p.elems[key]
+	if !ok {
+		return
+	}
+	node := el.Value.(*tinyLFUNode[K])
+	switch node.seg {
+	case inWindow:
+		p.window.MoveToFront(el)
+	case inProtected:
+		p.protected.MoveToFront(el)
+	case inProbation:
+		p.probation.Remove(el)
+		node.seg = inProtected
+		p.elems[key] = p.protected.PushFront(node)
+		if p.protected.Len() > p.protectedMax {
+			demoted := p.protected.Remove(p.protected.Back()).(*tinyLFUNode[K])
+			demoted.seg = inProbation
+			p.elems[demoted.key] = p.probation.PushFront(demoted)
+		}
+	}
+}
+
+func (p *tinyLFU[K]) add(key K) []K {
+	if _, ok := p.elems[key]; ok {
+		p.access(key)
+		return nil
+	}
+
+	p.sketch.increment(hashKey(p.seed, key))
+	p.elems[key] = p.window.PushFront(&tinyLFUNode[K]{key: key, seg: inWindow})
+	if p.window.Len() <= p.windowMax {
+		return nil
+	}
+
+	candidate := p.window.Remove(p.window.Back()).(*tinyLFUNode[K])
+	if p.probation.Len()+p.protected.Len() < p.mainMax {
+		p.admit(candidate)
+		return nil
+	}
+
+	victimEl := p.probation.Back()
+	if victimEl == nil {
+		victimEl = p.protected.Back()
+	}
+	if victimEl == nil {
+		delete(p.elems, candidate.key)
+		return []K{candidate.key}
+	}
+	victim := victimEl.Value.(*tinyLFUNode[K])
+	if p.sketch.estimate(hashKey(p.seed, candidate.key)) <= p.sketch.estimate(hashKey(p.seed, victim.key)) {
+		delete(p.elems, candidate.key)
+		return []K{candidate.key}
+	}
+	p.remove(victim.key)
+	p.admit(candidate)
+	return []K{victim.key}
+}
+
+func (p *tinyLFU[K]) remove(key K) {
+	el, ok := p.elems[key]
+	if !ok {
+		return
+	}
+	p.segment(el.Value.(*tinyLFUNode[K]).seg).Remove(el)
+	delete(p.elems, key)
+}
+
+func (p *tinyLFU[K]) clear() {
+	p.window.Init()
+	p.probation.Init()
+	p.protected.Init()
+	clear(p.elems)
+	p.sketch.clear()
+}
+
+// admit moves node, which has left the window, to the front of probation.
+func (p *tinyLFU[K]) admit(node *tinyLFUNode[K]) {
+	node.seg = inProbation
+	p.elems[node.key] = p.probation.PushFront(node)
+}
+
+func (p *tinyLFU[K]) segment(seg segment) *list.List {
+	switch seg {
+	case inProbation:
+		return p.probation
+	case inProtected:
+		return p.protected
+	default:
+		return p.window
+	}
+}
+
+// Sketch dimensions: every key maps to one counter in each of sketchDepth rows of at least
+// sketchWidth counters per cache entry, counters saturate at sketchMaxCount, and every
+// counter is halved once the sketch has counted sketchSamples occurrences per cache entry.
+const (
+	sketchDepth    = 4
+	sketchWidth    = 4
+	sketchMaxCount = 15
+	sketchSamples  = 10
+)
+
+// countMinSketch estimates how often hashed keys were seen in constant space. Estimates
+// are the minimum over the key's counters, so collisions can only inflate them.
+type countMinSketch struct {
+	counters   []uint8
+	mask       uint64
+	additions  int
+	sampleSize int
+}
+
+// newCountMinSketch creates a sketch sized for a cache of capacity entries.
+func newCountMinSketch(capacity int) *countMinSketch {
+	capacity = max(capacity, 1)
+	width := 1 << bits.Len(uint(max(sketchWidth*capacity, 16)-1))
+	return &countMinSketch{
+		counters:   make([]uint8, sketchDepth*width),
+		mask:       uint64(width - 1),
+		sampleSize: sketchSamples * capacity,
+	}
+}
+
+// increment counts one occurrence of h, halving every counter once enough were counted.
+func (s *countMinSketch) increment(h uint64) {
+	for row := range sketchDepth {
+		if i := s.index(h, row); s.counters[i] < sketchMaxCount {
+			s.counters[i]++
+		}
+	}
+	s.additions++
+	if s.additions >= s.sampleSize {
+		s.age()
+	}
+}
+
+// estimate returns the approximate number of occurrences of h since counters were last halved.
+func (s *countMinSketch) estimate(h uint64) uint8 {
+	n := uint8(sketchMaxCount)
+	for row := range sketchDepth {
+		n = min(n, s.counters[s.index(h, row)])
+	}
+	return n
+}
+
+// age halves every counter so that past popularity fades.
+func (s *countMinSketch) age() {
+	for i := range s.counters {
+		s.counters[i] /= 2
+	}
+	s.additions /= 2
+}
+
+func (s *countMinSketch) clear() {
+	clear(s.counters)
+	s.additions = 0
+}
+
+// index returns the position of h's counter in row, using double hashing to derive a
+// different hash per row.
+func (s *countMinSketch) index(h uint64, row int) int {
+	h1, h2 := h, bits.RotateLeft64(h, 32)|1
+	width := int(s.mask) + 1
+	return row*width + int((h1+uint64(row)*h2)&s.mask)
+}
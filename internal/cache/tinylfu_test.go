@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestTinyLFU tests that one-off keys pass through the window without displacing frequent keys.
+func TestTinyLFU(t *testing.T) {
+	p := newTinyLFU[string](100)
+	assert.Equal(t, 1, p.windowMax)
+	assert.Equal(t, 99, p.mainMax)
+	assert.Equal(t, 79, p.protectedMax)
+	// A sketch far wider than needed makes hash collisions, and so the test, deterministic enough.
+	p.sketch = newCountMinSketch(1 << 20)
+
+	for i := range 100 {
+		p.add(fmt.Sprintf("popular%d", i))
+	}
+	for i := range 100 {
+		for range 10 {
+			p.access(fmt.Sprintf("popular%d", i))
+		}
+	}
+	assert.Len(t, p.elems, 100)
+	assert.Equal(t, 79, p.protected.Len())
+	assert.Equal(t, 20, p.probation.Len())
+
+	for i := range 50 {
+		key := fmt.Sprintf("rare%d", i)
+		victims := p.add(key)
+		if i > 0 {
+			assert.Equal(t, []string{fmt.Sprintf("rare%d", i-1)}, victims, "rare keys are not admitted")
+		}
+	}
+
+	// A key seen often enough displaces the probation segment's oldest key.
+	for range 15 {
+		p.access("rising")
+	}
+	assert.Equal(t, []string{"rare49"}, p.add("rising"))
+	assert.Equal(t, []string{"popular0"}, p.add("next"))
+	assert.Contains(t, p.elems, "rising")
+
+	p.remove("rising")
+	assert.NotContains(t, p.elems, "rising")
+	p.clear()
+	assert.Empty(t, p.elems)
+	assert.Zero(t, p.sketch.estimate(hashKey(p.seed, "popular1")))
+}
+
+// TestTinyLFU_Small tests that a cache of one entry behaves as an LRU of one.
+func TestTinyLFU_Small(t *testing.T) {
+	p := newTinyLFU[string](1)
+
+	assert.Empty(t, p.add("a"))
+	assert.Equal(t, []string{"a"}, p.add("b"))
+	assert.Len(t, p.elems, 1)
+}
+
+// TestCountMinSketch tests frequency estimates and their ageing.
+func TestCountMinSketch(t *testing.T) {
+	s := newCountMinSketch(100)
+	assert.Len(t, s.counters, sketchDepth*512)
+	assert.Equal(t, 1000, s.sampleSize)
+
+	for i := range 20 {
+		s.increment(1)
+		if i < 4 {
+			s.increment(2)
+		}
+	}
+	assert.Equal(t, uint8(sketchMaxCount), s.estimate(1), "counters saturate")
+	assert.Equal(t, uint8(4), s.estimate(2))
+	assert.Zero(t, s.estimate(3))
+
+	s.age()
+	assert.Equal(t, uint8(7), s.estimate(1))
+	assert.Equal(t, uint8(2), s.estimate(2))
+	assert.Equal(t, 12, s.additions)
+
+	for i := range uint64(s.sampleSize) {
+		s.increment(i + 100)
+	}
+	assert.Less(t, s.additions, s.sampleSize, "the sketch ages itself")
+}
+
+// TestTinyLFU_Replay tests that W-TinyLFU beats LRU on the synthetic trace, whose one-off
+// lookups flush an LRU. Recorded traces are only reported: how the policies compare on
+// them is a measurement, not an invariant.
+func TestTinyLFU_Replay(t *testing.T) {
+	for name, trace := range loadTraces(t) {
+		lruRatio := replay(NewInMemoryCache[string, int](WithEviction(PolicyLRU, 50)), trace)
+		tinyRatio := replay(NewInMemoryCache[string, int](WithEviction(PolicyTinyLFU, 50)), trace)
+		if name == "synthetic" {
+			assert.Greater(t, tinyRatio, lruRatio, name)
+			continue
+		}
+		t.Logf("%s: hit ratio %.1f%% with LRU, %.1f%% with W-TinyLFU", name, 100*lruRatio, 100*tinyRatio)
+	}
+}
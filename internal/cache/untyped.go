@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"slices"
+	"strings"
+)
+
+// AnyCache is the untyped interface that predates Cache: string keys and interface{}
+// values. Untyped adapts a typed cache to it for callers that have not migrated yet.
+type AnyCache = Cache[string, interface{}]
+
+// Untyped adapts c to AnyCache. As before the migration, Keys are listed in ascending
+// order. Set only stores values of type V; any other value removes the entry held under
+// the key instead, so readers never see a stale value. The result is an
+// Inspector[string, interface{}] when c is an Inspector[string, V].
+func Untyped[V any](c Cache[string, V]) AnyCache {
+	u := untyped[V]{c: c}
+	if inspector, ok := c.(Inspector[string, V]); ok {
+		return untypedInspector[V]{untyped: u, inspector: inspector}
+	}
+	return u
+}
+
+// untyped adapts a Cache[string, V] to AnyCache.
+type untyped[V any] struct {
+	c Cache[string, V]
+}
+
+func (u untyped[V]) Get(key string) (interface{}, bool) {
+	value, found := u.c.Get(key)
+	if !found {
+		return nil, false
+	}
+	return value, true
+}
+
+func (u untyped[V]) Set(key string, value interface{}) {
+	v, ok := value.(V)
+	if !ok {
+		u.c.Delete(key)
+		return
+	}
+	u.c.Set(key, v)
+}
+
+func (u untyped[V]) Delete(key string) bool {
+	return u.c.Delete(key)
+}
+
+func (u untyped[V]) Keys() []string {
+	keys := u.c.Keys()
+	slices.Sort(keys)
+	return keys
+}
+
+func (u untyped[V]) Len() int {
+	return u.c.Len()
+}
+
+func (u untyped[V]) Clear() {
+	u.c.Clear()
+}
+
+// untypedInspector adds Inspector to untyped for caches that support it.
+type untypedInspector[V any] struct {
+	untyped[V]
+	inspector Inspector[string, V]
+}
+
+func (u untypedInspector[V]) Entry(key string) (EntryInfo[string, interface{}], bool) {
+	info, found := u.inspector.Entry(key)
+	if !found {
+		return EntryInfo[string, interface{}]{}, false
+	}
+	return untypedInfo(info), true
+}
+
+// Entries describes every live entry, ordered by key.
+func (u untypedInspector[V]) Entries() []EntryInfo[string, interface{}] {
+	entries := u.inspector.Entries()
+	out := make([]EntryInfo[string, interface{}], 0, len(entries))
+	for _, info := range entries {
+		out = append(out, untypedInfo(info))
+	}
+	slices.SortFunc(out, func(a, b EntryInfo[string, interface{}]) int { return strings.Compare(a.Key, b.Key) })
+	return out
+}
+
+func untypedInfo[V any](info EntryInfo[string, V]) EntryInfo[string, interface{}] {
+	return EntryInfo[string, interface{}]{
+		Key:       info.Key,
+		Value:     info.Value,
+		StoredAt:  info.StoredAt,
+		ExpiresAt: info.ExpiresAt,
+		Hits:      info.Hits,
+	}
+}
@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestUntyped tests the adapter from a typed cache to the untyped interface.
+func TestUntyped(t *testing.T) {
+	typed := NewInMemoryCache[string, int]()
+	c := Untyped[int](typed)
+
+	c.Set("b", 2)
+	c.Set("a", 1)
+	value, found := c.Get("a")
+	assert.True(t, found)
+	assert.Equal(t, 1, value)
+	assert.Equal(t, []string{"a", "b"}, c.Keys(), "keys are listed in ascending order")
+
+	c.Set("a", "one")
+	_, found = typed.Get("a")
+	assert.False(t, found, "a value of the wrong type removes the entry")
+
+	value, found = c.Get("missing")
+	assert.False(t, found)
+	assert.Nil(t, value)
+
+	assert.True(t, c.Delete("b"))
+	assert.Equal(t, 0, c.Len())
+}
+
+// TestUntyped_Inspector tests that the adapter describes entries when the typed cache can.
+func TestUntyped_Inspector(t *testing.T) {
+	now := time.Now()
+	typed := NewInMemoryCache[string, string](WithTTL(time.Hour))
+	typed.now = func() time.Time { return now }
+	typed.Set("germany", "Berlin")
+	typed.Set("france", "Paris")
+
+	inspector, ok := Untyped[string](typed).(Inspector[string, interface{}])
+	require.True(t, ok)
+
+	info, found := inspector.Entry("germany")
+	assert.True(t, found)
+	assert.Equal(t, EntryInfo[string, interface{}]{Key: "germany", Value: "Berlin", StoredAt: now, ExpiresAt: now.Add(time.Hour)}, info)
+
+	entries := inspector.Entries()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "france", entries[0].Key, "entries are ordered by key")
+
+	_, ok = Untyped[string](struct{ Cache[string, string] }{typed}).(Inspector[string, interface{}])
+	assert.False(t, ok, "caches that cannot inspect are not wrapped as inspectors")
+}
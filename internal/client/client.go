@@ -3,12 +3,26 @@ package client
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/sj1815/golang-country-search/internal/logging"
+	"github.com/sj1815/golang-country-search/internal/metrics"
 	"github.com/sj1815/golang-country-search/internal/model"
+	"github.com/sj1815/golang-country-search/internal/ratelimit"
+	"github.com/sj1815/golang-country-search/internal/requestid"
+	"github.com/sj1815/golang-country-search/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -16,56 +30,230 @@ const (
 	BaseURL        = "https://restcountries.com/v3.1"
 )
 
+// ErrNotFound is returned when the REST Countries API knows no country matching the
+// request.
+var ErrNotFound = errors.New("not found")
+
 type CountryClient interface {
 	SearchCountryByName(ctx context.Context, name string) ([]model.RESTCountryResponse, error)
+	ListCountriesByCode(ctx context.Context, codes []string) ([]model.RESTCountryResponse, error)
+	ListAllCountries(ctx context.Context) ([]model.RESTCountryResponse, error)
 }
 
 type HTTPClient struct {
-	baseURL    string
-	httpClient *http.Client
+	baseURL        string
+	httpClient     *http.Client
+	logger         *slog.Logger
+	metrics        *metrics.Metrics
+	tracerProvider trace.TracerProvider
+
+	// limiter and slots throttle outbound requests; nil means unlimited.
+	limiter *ratelimit.Limiter
+	slots   chan struct{}
+	queued  atomic.Int64
+}
+
+// Option configures optional dependencies of the HTTP client.
+type Option func(*HTTPClient)
+
+// WithLogger sets the logger used for upstream calls. Defaults to slog.Default().
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *HTTPClient) {
+		c.logger = logger
+	}
+}
+
+// WithMetrics records upstream call counts and latency in m.
+func WithMetrics(m *metrics.Metrics) Option {
+	return func(c *HTTPClient) {
+		c.metrics = m
+	}
+}
+
+// WithTracerProvider creates client spans for upstream calls from tp.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *HTTPClient) {
+		c.tracerProvider = tp
+	}
 }
 
 // NewHTTPClient creates a new instance of HTTPClient with the specified timeout.
-func NewHTTPClient(timeout time.Duration) *HTTPClient {
+func NewHTTPClient(timeout time.Duration, opts ...Option) *HTTPClient {
 	if timeout == 0 {
 		timeout = DefaultTimeout
 	}
 
-	return &HTTPClient{
+	c := &HTTPClient{
 		baseURL: BaseURL,
 		httpClient: &http.Client{
 			Timeout: timeout,
 		},
+		logger: slog.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// log returns the client's logger, falling back to the default logger.
+func (c *HTTPClient) log() *slog.Logger {
+	if c.logger == nil {
+		return slog.Default()
 	}
+	return c.logger
 }
 
+// allFields selects the fields used by model.RESTCountryResponse; the list endpoints
+// require an explicit field list.
+const allFields = "name,cca2,cca3,altSpellings,capital,currencies,population"
+
 // SearchCountryByName searches for a country by its full name using the REST Countries API.
 func (c *HTTPClient) SearchCountryByName(ctx context.Context, name string) ([]model.RESTCountryResponse, error) {
 	endpoint := fmt.Sprintf("%s/name/%s?fullText=true", c.baseURL, url.PathEscape(name))
+	countries, err := c.get(ctx, "GET restcountries /name", endpoint, name)
+	if err != nil {
+		return nil, fmt.Errorf("SearchCountryByName: %w", err)
+	}
+	return countries, nil
+}
+
+// ListCountriesByCode fetches the countries with the given ISO 3166-1 alpha-2 or alpha-3
+// codes in a single request. Unknown codes are left out of the result.
+func (c *HTTPClient) ListCountriesByCode(ctx context.Context, codes []string) ([]model.RESTCountryResponse, error) {
+	endpoint := fmt.Sprintf("%s/alpha?codes=%s&fields=%s", c.baseURL, url.QueryEscape(strings.Join(codes, ",")), allFields)
+	countries, err := c.get(ctx, "GET restcountries /alpha", endpoint, "")
+	if err != nil {
+		return nil, fmt.Errorf("ListCountriesByCode: %w", err)
+	}
+	return countries, nil
+}
+
+// ListAllCountries fetches every country known to the REST Countries API.
+func (c *HTTPClient) ListAllCountries(ctx context.Context) ([]model.RESTCountryResponse, error) {
+	countries, err := c.get(ctx, "GET restcountries /all", c.baseURL+"/all?fields="+allFields, "")
+	if err != nil {
+		return nil, fmt.Errorf("ListAllCountries: %w", err)
+	}
+	return countries, nil
+}
+
+// get calls endpoint inside a client span, subject to the outbound throttle, and decodes
+// the list of countries it returns. country, if set, names the country being looked up.
+func (c *HTTPClient) get(ctx context.Context, spanName, endpoint, country string) (countries []model.RESTCountryResponse, err error) {
+	attrs := []attribute.KeyValue{
+		attribute.String("http.request.method", http.MethodGet),
+		attribute.String("url.full", endpoint),
+	}
+	logAttrs := []any{slog.String("url", endpoint)}
+	if country != "" {
+		attrs = append(attrs, attribute.String(logging.KeyCountry, country))
+		logAttrs = []any{slog.String(logging.KeyCountry, country)}
+	}
+
+	ctx, span := tracing.Tracer(c.tracerProvider).Start(ctx, spanName,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
 	if err != nil {
-		return nil, fmt.Errorf("SearchCountryByName: failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
+	// Forward the caller's request ID and trace context so upstream calls can be correlated with ours.
+	if id := requestid.FromContext(ctx); id != "" {
+		req.Header.Set(requestid.Header, id)
+	}
+	tracing.Propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	release, wait, err := c.acquire(ctx)
+	span.SetAttributes(attribute.Float64("queue.wait_ms", float64(wait)/float64(time.Millisecond)))
+	if err != nil {
+		c.metrics.UpstreamRequest(metrics.OutcomeThrottled, 0)
+		c.log().WarnContext(ctx, "upstream request throttled",
+			append(logAttrs, slog.Duration("queue_wait", wait), logging.Error(err))...)
+		return nil, err
+	}
+	defer release()
+
+	start := time.Now()
+	outcome := metrics.OutcomeSuccess
+	defer func() {
+		c.metrics.UpstreamRequest(outcome, time.Since(start))
+	}()
+
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("SearchCountryByName: request execution failed: %w", err)
+		outcome = metrics.OutcomeNetwork
+		if ctx.Err() != nil {
+			outcome = metrics.OutcomeCanceled
+		}
+		c.log().WarnContext(ctx, "upstream request failed",
+			append(logAttrs, logging.Latency(time.Since(start)), logging.Error(err))...)
+		return nil, fmt.Errorf("request execution failed: %w", err)
 	}
 	defer resp.Body.Close()
 
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+
+	c.log().DebugContext(ctx, "upstream request completed",
+		append(logAttrs, slog.Int("status", resp.StatusCode), logging.Latency(time.Since(start)))...)
+
 	if resp.StatusCode == http.StatusNotFound {
-		return nil, fmt.Errorf("SearchCountryByName: country not found: %s", name)
+		outcome = metrics.OutcomeNotFound
+		if country == "" {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("country %w: %s", ErrNotFound, country)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("SearchCountryByName: unexpected status code: %d", resp.StatusCode)
+		outcome = metrics.OutcomeHTTPError
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
-	var countries []model.RESTCountryResponse
 	if err := json.NewDecoder(resp.Body).Decode(&countries); err != nil {
-		return nil, fmt.Errorf("SearchCountryByName: failed to decode response: %w", err)
+		outcome = metrics.OutcomeDecodeError
+		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
 	return countries, nil
 }
+
+// Ping checks that the REST Countries API is reachable. Any response below 500 counts as
+// reachable, since only the connection and the upstream's own health matter here.
+func (c *HTTPClient) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/alpha/us?fields=cca3", nil)
+	if err != nil {
+		return fmt.Errorf("Ping: failed to create request: %w", err)
+	}
+
+	release, _, err := c.acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("Ping: %w", err)
+	}
+	defer release()
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("Ping: request execution failed: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("Ping: unexpected status code: %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -7,8 +7,13 @@ import (
 	"testing"
 	"time"
 
+	"github.com/sj1815/golang-country-search/internal/metrics"
+	"github.com/sj1815/golang-country-search/internal/requestid"
+	"github.com/sj1815/golang-country-search/internal/tracing"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // TestNewHTTPClient tests the NewHTTPClient function.
@@ -81,6 +86,7 @@ func TestHTTPClient_SearchCountryByName_NotFound(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, countries)
 	assert.Contains(t, err.Error(), "country not found")
+	assert.ErrorIs(t, err, ErrNotFound)
 }
 
 // TestHTTPClient_SearchCountryByName_ServerError tests the SearchCountryByName method for a server error response.
@@ -170,3 +176,160 @@ func TestHTTPClient_SearchCountryByName_URLEncoding(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "United States", countries[0].Name.Common)
 }
+
+// TestHTTPClient_SearchCountryByName_ForwardsRequestID tests that the request ID in the context is sent upstream.
+func TestHTTPClient_SearchCountryByName_ForwardsRequestID(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "req-123", r.Header.Get(requestid.Header))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"name": {"common": "Germany"}}]`))
+	}))
+	defer server.Close()
+
+	client := &HTTPClient{
+		baseURL:    server.URL + "/v3.1",
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+	}
+
+	ctx := requestid.NewContext(context.Background(), "req-123")
+	_, err := client.SearchCountryByName(ctx, "Germany")
+
+	require.NoError(t, err)
+}
+
+// TestHTTPClient_SearchCountryByName_Metrics tests that upstream calls are counted by outcome.
+func TestHTTPClient_SearchCountryByName_Metrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v3.1/name/Germany":
+			w.Write([]byte(`[{"name": {"common": "Germany"}}]`))
+		case "/v3.1/name/Broken":
+			w.Write([]byte(`not json`))
+		case "/v3.1/name/Down":
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	m := metrics.New()
+	client := NewHTTPClient(5*time.Second, WithMetrics(m))
+	client.baseURL = server.URL + "/v3.1"
+
+	for _, name := range []string{"Germany", "Germany", "Nowhere", "Broken", "Down"} {
+		client.SearchCountryByName(context.Background(), name)
+	}
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+
+	assert.Contains(t, body, `country_search_upstream_requests_total{outcome="success"} 2`)
+	assert.Contains(t, body, `country_search_upstream_requests_total{outcome="not_found"} 1`)
+	assert.Contains(t, body, `country_search_upstream_requests_total{outcome="decode_error"} 1`)
+	assert.Contains(t, body, `country_search_upstream_requests_total{outcome="http_error"} 1`)
+}
+
+// TestHTTPClient_SearchCountryByName_Tracing tests that a client span is created and its context is injected upstream.
+func TestHTTPClient_SearchCountryByName_Tracing(t *testing.T) {
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.Write([]byte(`[{"name": {"common": "Germany"}}]`))
+	}))
+	defer server.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := tracing.NewProvider(recorder, tracing.Options{SampleRatio: 1})
+
+	client := NewHTTPClient(5*time.Second, WithTracerProvider(tp))
+	client.baseURL = server.URL + "/v3.1"
+
+	_, err := client.SearchCountryByName(context.Background(), "Germany")
+	require.NoError(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, trace.SpanKindClient, spans[0].SpanKind())
+
+	sc := spans[0].SpanContext()
+	assert.Equal(t, "00-"+sc.TraceID().String()+"-"+sc.SpanID().String()+"-01", traceparent)
+}
+
+// TestHTTPClient_Ping tests upstream reachability checks.
+func TestHTTPClient_Ping(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v3.1/alpha/us", r.URL.Path)
+		w.WriteHeader(status)
+	}))
+
+	client := &HTTPClient{
+		baseURL:    server.URL + "/v3.1",
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+	}
+
+	assert.NoError(t, client.Ping(context.Background()))
+
+	status = http.StatusNotFound
+	assert.NoError(t, client.Ping(context.Background()))
+
+	status = http.StatusServiceUnavailable
+	assert.ErrorContains(t, client.Ping(context.Background()), "unexpected status code")
+
+	server.Close()
+	assert.ErrorContains(t, client.Ping(context.Background()), "request execution failed")
+}
+
+// TestHTTPClient_ListCountriesByCode tests fetching several countries by code in one request.
+func TestHTTPClient_ListCountriesByCode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v3.1/alpha", r.URL.Path)
+		assert.Equal(t, "de,fra", r.URL.Query().Get("codes"))
+		assert.Equal(t, allFields, r.URL.Query().Get("fields"))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"name": {"common": "Germany"}}, {"name": {"common": "France"}}]`))
+	}))
+	defer server.Close()
+
+	client := &HTTPClient{
+		baseURL:    server.URL + "/v3.1",
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+	}
+
+	countries, err := client.ListCountriesByCode(context.Background(), []string{"de", "fra"})
+
+	require.NoError(t, err)
+	require.Len(t, countries, 2)
+	assert.Equal(t, "France", countries[1].Name.Common)
+}
+
+// TestHTTPClient_ListAllCountries tests fetching every country and reporting upstream errors.
+func TestHTTPClient_ListAllCountries(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v3.1/all", r.URL.Path)
+		assert.Equal(t, allFields, r.URL.Query().Get("fields"))
+
+		w.WriteHeader(status)
+		w.Write([]byte(`[{"name": {"common": "Peru"}, "population": 32971846}]`))
+	}))
+	defer server.Close()
+
+	client := &HTTPClient{
+		baseURL:    server.URL + "/v3.1",
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+	}
+
+	countries, err := client.ListAllCountries(context.Background())
+	require.NoError(t, err)
+	require.Len(t, countries, 1)
+	assert.Equal(t, 32971846, countries[0].Population)
+
+	status = http.StatusBadGateway
+	_, err = client.ListAllCountries(context.Background())
+	assert.ErrorContains(t, err, "ListAllCountries: unexpected status code: 502")
+}
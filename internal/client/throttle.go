@@ -0,0 +1,73 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sj1815/golang-country-search/internal/ratelimit"
+)
+
+// ErrThrottled is returned when a call could not get its turn at the upstream API before
+// the caller's context ended.
+var ErrThrottled = errors.New("upstream request throttled")
+
+// WithRateLimit caps outbound requests to limit. Callers over the limit queue until their
+// turn comes or their context ends.
+func WithRateLimit(limit ratelimit.Limit) Option {
+	return func(c *HTTPClient) {
+		if limit.Rate > 0 && limit.Burst > 0 {
+			c.limiter = ratelimit.New(limit)
+		}
+	}
+}
+
+// WithMaxConcurrency caps the number of requests in flight to the upstream API at n.
+// Callers over the cap queue until a request finishes or their context ends.
+func WithMaxConcurrency(n int) Option {
+	return func(c *HTTPClient) {
+		if n > 0 {
+			c.slots = make(chan struct{}, n)
+		}
+	}
+}
+
+// QueueDepth returns the number of callers currently waiting for their turn.
+func (c *HTTPClient) QueueDepth() int {
+	return int(c.queued.Load())
+}
+
+// acquire waits for the outbound rate limit and a free connection slot. On success the
+// caller must call release once the request is done.
+func (c *HTTPClient) acquire(ctx context.Context) (release func(), wait time.Duration, err error) {
+	if c.limiter == nil && c.slots == nil {
+		return func() {}, 0, nil
+	}
+
+	start := time.Now()
+	c.queued.Add(1)
+	c.metrics.UpstreamQueued()
+	defer func() {
+		wait = time.Since(start)
+		c.queued.Add(-1)
+		c.metrics.UpstreamDequeued(wait)
+	}()
+
+	if c.limiter != nil {
+		if err := c.limiter.Wait(ctx, ""); err != nil {
+			return nil, 0, fmt.Errorf("%w: %w", ErrThrottled, err)
+		}
+	}
+
+	if c.slots == nil {
+		return func() {}, 0, nil
+	}
+
+	select {
+	case c.slots <- struct{}{}:
+		return func() { <-c.slots }, 0, nil
+	case <-ctx.Done():
+		return nil, 0, fmt.Errorf("%w: %w", ErrThrottled, ctx.Err())
+	}
+}
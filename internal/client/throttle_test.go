@@ -0,0 +1,115 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sj1815/golang-country-search/internal/logging"
+	"github.com/sj1815/golang-country-search/internal/metrics"
+	"github.com/sj1815/golang-country-search/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func countryServer(t *testing.T, handle func()) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handle()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[{"name":{"common":"Germany"}}]`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestHTTPClient_MaxConcurrency tests that no more than the configured number of requests are in flight.
+func TestHTTPClient_MaxConcurrency(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	release := make(chan struct{})
+
+	server := countryServer(t, func() {
+		n := inFlight.Add(1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		<-release
+		inFlight.Add(-1)
+	})
+
+	client := NewHTTPClient(5*time.Second, WithLogger(logging.Discard()), WithMaxConcurrency(2))
+	client.baseURL = server.URL
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.SearchCountryByName(context.Background(), "Germany")
+			assert.NoError(t, err)
+		}()
+	}
+
+	assert.Eventually(t, func() bool {
+		return inFlight.Load() == 2 && client.QueueDepth() == 3
+	}, time.Second, 5*time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(2), maxInFlight.Load())
+	assert.Equal(t, 0, client.QueueDepth())
+}
+
+// TestHTTPClient_RateLimit tests that outbound requests are spaced out to the configured rate.
+func TestHTTPClient_RateLimit(t *testing.T) {
+	var calls atomic.Int32
+	server := countryServer(t, func() { calls.Add(1) })
+
+	client := NewHTTPClient(5*time.Second, WithLogger(logging.Discard()),
+		WithRateLimit(ratelimit.Limit{Rate: 20, Burst: 1}))
+	client.baseURL = server.URL
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		_, err := client.SearchCountryByName(context.Background(), "Germany")
+		require.NoError(t, err)
+	}
+
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+// TestHTTPClient_Throttled tests that callers whose deadline passes while queued get ErrThrottled.
+func TestHTTPClient_Throttled(t *testing.T) {
+	var calls atomic.Int32
+	server := countryServer(t, func() { calls.Add(1) })
+
+	m := metrics.New()
+	client := NewHTTPClient(5*time.Second, WithLogger(logging.Discard()), WithMetrics(m),
+		WithRateLimit(ratelimit.Limit{Rate: 1, Burst: 1}))
+	client.baseURL = server.URL
+
+	_, err := client.SearchCountryByName(context.Background(), "Germany")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err = client.SearchCountryByName(ctx, "Germany")
+	assert.ErrorIs(t, err, ErrThrottled)
+	assert.ErrorIs(t, err, ratelimit.ErrWouldExceedDeadline)
+	assert.Equal(t, int32(1), calls.Load())
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, rec.Body.String(), `country_search_upstream_requests_total{outcome="throttled"} 1`)
+	assert.Contains(t, rec.Body.String(), "country_search_upstream_queue_wait_seconds_count 2")
+	assert.Contains(t, rec.Body.String(), "country_search_upstream_queue_depth 0")
+}
@@ -1,22 +1,312 @@
 package config
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/sj1815/golang-country-search/internal/auth"
 	"github.com/sj1815/golang-country-search/internal/cache"
 	"github.com/sj1815/golang-country-search/internal/client"
 	"github.com/sj1815/golang-country-search/internal/handler"
+	"github.com/sj1815/golang-country-search/internal/health"
+	"github.com/sj1815/golang-country-search/internal/logging"
+	"github.com/sj1815/golang-country-search/internal/metrics"
+	"github.com/sj1815/golang-country-search/internal/middleware"
+	"github.com/sj1815/golang-country-search/internal/model"
+	"github.com/sj1815/golang-country-search/internal/peer"
+	"github.com/sj1815/golang-country-search/internal/ratelimit"
+	"github.com/sj1815/golang-country-search/internal/requestid"
+	"github.com/sj1815/golang-country-search/internal/router"
 	"github.com/sj1815/golang-country-search/internal/service"
+	"github.com/sj1815/golang-country-search/internal/tracing"
 )
 
 type Config struct {
-	ServerPort         string
-	HTTPClientTimeout  time.Duration
-	ServerReadTimeout  time.Duration
-	ServerWriteTimeout time.Duration
-	ShutdownTimeout    time.Duration
+	ServerPort         string            `yaml:"port" toml:"port"`
+	HTTPClientTimeout  time.Duration     `yaml:"http_client_timeout" toml:"http_client_timeout"`
+	ServerReadTimeout  time.Duration     `yaml:"read_timeout" toml:"read_timeout"`
+	ServerWriteTimeout time.Duration     `yaml:"write_timeout" toml:"write_timeout"`
+	ShutdownTimeout    time.Duration     `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+	Compression        CompressionConfig `yaml:"compression" toml:"compression"`
+	Logging            LoggingConfig     `yaml:"logging" toml:"logging"`
+	Metrics            MetricsConfig     `yaml:"metrics" toml:"metrics"`
+	Tracing            TracingConfig     `yaml:"tracing" toml:"tracing"`
+	Health             HealthConfig      `yaml:"health" toml:"health"`
+	Cache              CacheConfig       `yaml:"cache" toml:"cache"`
+	Peers              PeersConfig       `yaml:"peers" toml:"peers"`
+	Reload             ReloadConfig      `yaml:"reload" toml:"reload"`
+	RateLimit          RateLimitConfig   `yaml:"rate_limit" toml:"rate_limit"`
+	Upstream           UpstreamConfig    `yaml:"upstream" toml:"upstream"`
+	Auth               AuthConfig        `yaml:"auth" toml:"auth"`
+	CORS               CORSConfig        `yaml:"cors" toml:"cors"`
+	Admin              AdminConfig       `yaml:"admin" toml:"admin"`
+	Warmup             WarmupConfig      `yaml:"warmup" toml:"warmup"`
+
+	// File is the config file the configuration was loaded from, if any.
+	File string `yaml:"-" toml:"-"`
+}
+
+// CompressionConfig controls response compression.
+type CompressionConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+	// MinSize is the smallest response body, in bytes, that gets compressed.
+	MinSize int `yaml:"min_size" toml:"min_size"`
+}
+
+// LoggingConfig controls structured log output.
+type LoggingConfig struct {
+	// Level is the minimum level logged: debug, info, warn or error. Reloadable.
+	Level string `yaml:"level" toml:"level"`
+	// Format is the output format: json or text.
+	Format string `yaml:"format" toml:"format"`
+}
+
+// MetricsConfig controls the Prometheus metrics endpoint.
+type MetricsConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+	// Path is the route serving the metrics in the Prometheus text format.
+	Path string `yaml:"path" toml:"path"`
+}
+
+// TracingConfig controls OpenTelemetry tracing.
+type TracingConfig struct {
+	// Exporter selects where spans go: none, stdout, file or otlp.
+	Exporter string `yaml:"exporter" toml:"exporter"`
+	// Endpoint is the OTLP/HTTP collector URL used by the otlp exporter.
+	Endpoint string `yaml:"endpoint" toml:"endpoint"`
+	// FilePath is the file spans are written to by the file exporter.
+	FilePath string `yaml:"file_path" toml:"file_path"`
+	// SampleRatio is the fraction of new traces that are sampled, between 0 and 1.
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio"`
+	// ServiceName is reported as the service.name resource attribute.
+	ServiceName string `yaml:"service_name" toml:"service_name"`
+}
+
+// HealthConfig controls the liveness and readiness endpoints.
+type HealthConfig struct {
+	LivenessPath  string `yaml:"liveness_path" toml:"liveness_path"`
+	ReadinessPath string `yaml:"readiness_path" toml:"readiness_path"`
+	// CheckTimeout bounds each readiness check.
+	CheckTimeout time.Duration `yaml:"check_timeout" toml:"check_timeout"`
+	// UpstreamCheckInterval is how long an upstream reachability result is reused.
+	UpstreamCheckInterval time.Duration `yaml:"upstream_check_interval" toml:"upstream_check_interval"`
+	// UpstreamCritical makes readiness fail, rather than degrade, when restcountries.com is unreachable.
+	UpstreamCritical bool `yaml:"upstream_critical" toml:"upstream_critical"`
+	// DrainDelay is how long readiness reports draining before the server stops accepting connections.
+	DrainDelay time.Duration `yaml:"drain_delay" toml:"drain_delay"`
+}
+
+// Secret is a setting that must not be revealed. It formats as "[redacted]" when set, so
+// it can be logged safely, and can only be set from the config file or the environment,
+// never from a flag visible in the process list.
+type Secret string
+
+// String hides the secret, reporting only whether it is set.
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return "[redacted]"
+}
+
+// CacheConfig controls the country cache.
+type CacheConfig struct {
+	// TTL is how long a cached country stays valid; zero keeps entries forever. Reloadable.
+	TTL time.Duration `yaml:"ttl" toml:"ttl"`
+	// SweepInterval is how often expired entries that were never read again are removed;
+	// zero leaves them until they are read.
+	SweepInterval time.Duration `yaml:"sweep_interval" toml:"sweep_interval"`
+	// SnapshotPath is the file the cache is saved to and restored from across restarts;
+	// empty disables snapshots.
+	SnapshotPath string `yaml:"snapshot_path" toml:"snapshot_path"`
+	// SnapshotInterval is how often the cache is saved while running; zero saves only at shutdown.
+	SnapshotInterval time.Duration `yaml:"snapshot_interval" toml:"snapshot_interval"`
+	// Shards splits the cache into independently locked shards, rounded up to a power of two,
+	// to reduce lock contention; zero keeps a single lock.
+	Shards int `yaml:"shards" toml:"shards"`
+	// MaxEntries limits the number of cached countries, split evenly between the shards;
+	// zero leaves the cache unbounded.
+	MaxEntries int `yaml:"max_entries" toml:"max_entries"`
+	// Policy chooses the entries evicted once MaxEntries is reached: lru, lfu or tinylfu.
+	Policy string `yaml:"policy" toml:"policy"`
+	// Remote is a cache tier shared by every replica behind the local cache.
+	Remote RemoteCacheConfig `yaml:"remote" toml:"remote"`
+}
+
+// RemoteCacheConfig controls the shared cache tier, a server speaking the Redis protocol.
+type RemoteCacheConfig struct {
+	// Addr is the host:port of the server; empty disables the remote tier.
+	Addr     string `yaml:"addr" toml:"addr"`
+	Password Secret `yaml:"password" toml:"password"`
+	DB       int    `yaml:"db" toml:"db"`
+	// Prefix namespaces this service's keys on the server.
+	Prefix string `yaml:"prefix" toml:"prefix"`
+	// Timeout bounds each remote operation.
+	Timeout time.Duration `yaml:"timeout" toml:"timeout"`
+	// RetryInterval is how long the service uses the local cache alone after the remote
+	// tier fails, before trying it again.
+	RetryInterval time.Duration `yaml:"retry_interval" toml:"retry_interval"`
 }
 
+// PeersConfig controls sharing the cache among replicas without an external store: each
+// country is owned by one replica, picked by consistent hashing, and the others fetch it
+// from the owner instead of calling restcountries.com themselves.
+type PeersConfig struct {
+	// Self is this replica's base URL, e.g. "http://10.0.0.1:8000", exactly as it appears
+	// in URLs or the SRV records; empty disables peering.
+	Self string `yaml:"self" toml:"self"`
+	// URLs lists the base URLs of every replica.
+	URLs []string `yaml:"urls" toml:"urls"`
+	// SRV is a DNS SRV name listing every replica, used instead of URLs. Targets get the
+	// scheme of Self.
+	SRV string `yaml:"srv" toml:"srv"`
+	// RefreshInterval is how often the SRV records are looked up again.
+	RefreshInterval time.Duration `yaml:"refresh_interval" toml:"refresh_interval"`
+	// Timeout bounds each request to a peer; when it passes, the country is looked up locally.
+	Timeout time.Duration `yaml:"timeout" toml:"timeout"`
+	// Replicas is the number of points each replica gets on the hash ring.
+	Replicas int `yaml:"replicas" toml:"replicas"`
+	// Secret is shared by every replica and required on each peer request, so that only
+	// replicas can use the peer endpoint.
+	Secret Secret `yaml:"secret" toml:"secret"`
+}
+
+// Enabled reports whether the cache is shared among peers.
+func (c PeersConfig) Enabled() bool {
+	return c.Self != ""
+}
+
+// ReloadConfig controls how the configuration file is reloaded at runtime.
+type ReloadConfig struct {
+	// WatchInterval is how often the config file is checked for changes; zero disables
+	// watching, leaving SIGHUP as the only reload trigger.
+	WatchInterval time.Duration `yaml:"watch_interval" toml:"watch_interval"`
+}
+
+// RateLimitConfig controls per-client limits on inbound requests.
+type RateLimitConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+	// KeyBy selects how unauthenticated clients are told apart: ip, api_key or header.
+	// Authenticated clients are always limited by client ID, so api_key limits the others by
+	// IP; it requires auth to be enabled. header trusts Header when a trusted proxy sends it and falls back to the client IP.
+	KeyBy string `yaml:"key_by" toml:"key_by"`
+	// Header is the request header identifying the client when KeyBy is header. It must be
+	// set by a proxy in TrustedProxies; clients sending it directly are keyed by IP.
+	Header string `yaml:"header" toml:"header"`
+	// TrustedProxies lists the CIDR ranges or addresses whose X-Forwarded-For is believed.
+	TrustedProxies []string `yaml:"trusted_proxies" toml:"trusted_proxies"`
+	// Rate is the sustained number of requests per second allowed per client. Reloadable.
+	Rate float64 `yaml:"rate" toml:"rate"`
+	// Burst is the number of requests a client may make at once. Reloadable.
+	Burst int `yaml:"burst" toml:"burst"`
+	// IdleTimeout is how long an unused client's state is kept.
+	IdleTimeout time.Duration `yaml:"idle_timeout" toml:"idle_timeout"`
+	// Routes overrides Rate and Burst for individual route patterns, and rate limits routes
+	// other than country search. Limits of listed routes are reloadable; adding a route is not.
+	Routes map[string]RouteLimit `yaml:"routes" toml:"routes"`
+}
+
+// RouteLimit is the rate limit applied to a single route.
+type RouteLimit struct {
+	Rate  float64 `yaml:"rate" toml:"rate"`
+	Burst int     `yaml:"burst" toml:"burst"`
+}
+
+// UpstreamConfig throttles calls to the REST Countries API.
+type UpstreamConfig struct {
+	// RateLimit is the sustained number of requests per second sent upstream; zero disables it.
+	RateLimit float64 `yaml:"rate_limit" toml:"rate_limit"`
+	// Burst is the number of requests that may be sent upstream at once under RateLimit.
+	Burst int `yaml:"burst" toml:"burst"`
+	// MaxConcurrent caps the requests in flight upstream; zero disables the cap.
+	MaxConcurrent int `yaml:"max_concurrent" toml:"max_concurrent"`
+}
+
+// AuthConfig controls API key and JWT authentication.
+type AuthConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+	// KeysFile is a YAML file listing API keys, preferably by hash.
+	KeysFile string `yaml:"keys_file" toml:"keys_file"`
+	// Keys lists API keys inline, in addition to those in KeysFile.
+	Keys []auth.APIKey `yaml:"keys" toml:"keys"`
+	// Routes maps route patterns to the scopes a client needs to call them. Routes not
+	// listed need no authentication.
+	Routes map[string][]string `yaml:"routes" toml:"routes"`
+	// Tiers defines the rate limits applied to keys by tier, in place of the route limits.
+	Tiers map[string]RouteLimit `yaml:"tiers" toml:"tiers"`
+	JWT   JWTConfig             `yaml:"jwt" toml:"jwt"`
+}
+
+// JWTConfig controls validation of JWT bearer tokens.
+type JWTConfig struct {
+	// JWKS is the file path or http(s) URL of the JSON Web Key Set tokens are signed with.
+	// JWT authentication is enabled when it is set.
+	JWKS     string   `yaml:"jwks" toml:"jwks"`
+	Issuer   string   `yaml:"issuer" toml:"issuer"`
+	Audience []string `yaml:"audience" toml:"audience"`
+	// ClockSkew is the leeway allowed when checking exp and nbf; zero allows none.
+	ClockSkew time.Duration `yaml:"clock_skew" toml:"clock_skew"`
+	// RefreshInterval is how long the key set is cached before it is read again.
+	RefreshInterval time.Duration `yaml:"refresh_interval" toml:"refresh_interval"`
+}
+
+// CORSConfig controls Cross-Origin Resource Sharing for browser clients.
+type CORSConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+	// AllowedOrigins lists the allowed origins; "*" matches part of a host, as in
+	// "https://*.example.com", or any origin on its own.
+	AllowedOrigins   []string `yaml:"allowed_origins" toml:"allowed_origins"`
+	AllowedMethods   []string `yaml:"allowed_methods" toml:"allowed_methods"`
+	AllowedHeaders   []string `yaml:"allowed_headers" toml:"allowed_headers"`
+	ExposedHeaders   []string `yaml:"exposed_headers" toml:"exposed_headers"`
+	AllowCredentials bool     `yaml:"allow_credentials" toml:"allow_credentials"`
+	// MaxAge is how long browsers may cache preflight responses.
+	MaxAge time.Duration `yaml:"max_age" toml:"max_age"`
+}
+
+// AdminConfig controls the cache admin API.
+type AdminConfig struct {
+	// Enabled serves the admin API under /admin/cache/ to clients with the admin:cache
+	// scope. It requires authentication to be enabled.
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+}
+
+// WarmupConfig controls prefetching countries into the cache at startup.
+type WarmupConfig struct {
+	// Countries are names looked up one by one, as searches for them would be.
+	Countries []string `yaml:"countries" toml:"countries"`
+	// Codes are ISO 3166-1 alpha-2 or alpha-3 codes fetched in a single request.
+	Codes []string `yaml:"codes" toml:"codes"`
+	// All fetches every country from the upstream /all endpoint.
+	All bool `yaml:"all" toml:"all"`
+	// Concurrency bounds the name lookups in flight.
+	Concurrency int `yaml:"concurrency" toml:"concurrency"`
+	// Timeout is how long readiness waits for the warm-up before reporting ready regardless.
+	Timeout time.Duration `yaml:"timeout" toml:"timeout"`
+}
+
+// Enabled reports whether any countries are configured for warm-up.
+func (c WarmupConfig) Enabled() bool {
+	return c.All || len(c.Countries) > 0 || len(c.Codes) > 0
+}
+
+// Rate limit client keys accepted in RateLimitConfig.KeyBy.
+const (
+	KeyByIP     = "ip"
+	KeyByAPIKey = "api_key"
+	KeyByHeader = "header"
+)
+
 func DefaultConfig() *Config {
 	return &Config{
 		ServerPort:         ":8000",
@@ -24,21 +314,604 @@ func DefaultConfig() *Config {
 		ServerReadTimeout:  15 * time.Second,
 		ServerWriteTimeout: 15 * time.Second,
 		ShutdownTimeout:    10 * time.Second,
+		Compression: CompressionConfig{
+			Enabled: true,
+			MinSize: 1024,
+		},
+		Logging: LoggingConfig{
+			Level:  "info",
+			Format: logging.FormatJSON,
+		},
+		Metrics: MetricsConfig{
+			Enabled: true,
+			Path:    "/metrics",
+		},
+		Tracing: TracingConfig{
+			Exporter:    tracing.ExporterNone,
+			SampleRatio: 1,
+			ServiceName: "country-search",
+		},
+		Health: HealthConfig{
+			LivenessPath:          "/healthz",
+			ReadinessPath:         "/readyz",
+			CheckTimeout:          2 * time.Second,
+			UpstreamCheckInterval: 30 * time.Second,
+			DrainDelay:            5 * time.Second,
+		},
+		RateLimit: RateLimitConfig{
+			KeyBy:       KeyByIP,
+			Rate:        10,
+			Burst:       20,
+			IdleTimeout: ratelimit.DefaultIdleTimeout,
+		},
+		Auth: AuthConfig{
+			Routes: map[string][]string{
+				router.CountrySearchRoute: {auth.ScopeCountriesRead},
+			},
+			JWT: JWTConfig{
+				ClockSkew:       auth.DefaultClockSkew,
+				RefreshInterval: auth.DefaultJWKSRefreshInterval,
+			},
+		},
+		CORS: CORSConfig{
+			AllowedMethods: []string{http.MethodGet, http.MethodHead},
+			AllowedHeaders: []string{"Authorization", "Content-Type", auth.APIKeyHeader, requestid.Header},
+			ExposedHeaders: []string{
+				middleware.HeaderRateLimitLimit, middleware.HeaderRateLimitRemaining, middleware.HeaderRateLimitReset,
+				"Retry-After", requestid.Header,
+			},
+			MaxAge: 10 * time.Minute,
+		},
+		Cache: CacheConfig{
+			SweepInterval:    time.Minute,
+			SnapshotInterval: 5 * time.Minute,
+			Policy:           string(cache.PolicyTinyLFU),
+			Remote: RemoteCacheConfig{
+				Prefix:        "country-search:",
+				Timeout:       cache.DefaultRemoteTimeout,
+				RetryInterval: cache.DefaultRemoteRetryInterval,
+			},
+		},
+		Peers: PeersConfig{
+			RefreshInterval: 30 * time.Second,
+			Timeout:         peer.DefaultTimeout,
+			Replicas:        peer.DefaultReplicas,
+		},
+		Upstream: UpstreamConfig{
+			RateLimit:     10,
+			Burst:         10,
+			MaxConcurrent: 10,
+		},
+		Warmup: WarmupConfig{
+			Concurrency: service.DefaultWarmupConcurrency,
+			Timeout:     30 * time.Second,
+		},
 	}
 }
 
+// countryStore is the country cache, sharded or not, with the controls Dependencies needs.
+type countryStore interface {
+	cache.Cache[string, *model.Country]
+	cache.Inspector[string, *model.Country]
+	cache.StatsReporter
+	TTL() time.Duration
+	SetTTL(ttl time.Duration)
+	SaveSnapshot(path string) (int, error)
+	LoadSnapshot(path string) (int, error)
+	DeleteExpired() int
+}
+
 type Dependencies struct {
 	CountryHandler *handler.CountryHandler
+	Middleware     []router.Middleware
+	Logger         *slog.Logger
+	// LogLevel controls the level of Logger and can be changed at runtime.
+	LogLevel *slog.LevelVar
+	// Metrics is nil when metrics are disabled.
+	Metrics *metrics.Metrics
+	// MetricsPath is the route serving Metrics.
+	MetricsPath string
+	// Tracing is a no-op provider when tracing is disabled.
+	Tracing *tracing.Provider
+	Health  *health.Checker
+
+	cache         countryStore
+	tiered        *cache.TieredCache[*model.Country]
+	remoteCache   *cache.RESPClient
+	rateLimiters  map[string]*ratelimit.Limiter
+	tierLimiters  map[string]map[string]*ratelimit.Limiter
+	rateLimitKey  ratelimit.KeyFunc
+	authenticator auth.Authenticator
+	cacheAdmin    *handler.CacheAdminHandler
+	peers         *peer.Pool
+	discoverPeers func(ctx context.Context)
+	warmup        func(ctx context.Context)
+	warmupDone    chan struct{}
+	snapshotPath  string
+	snapshotEvery time.Duration
+	sweepEvery    time.Duration
+	routeScopes   map[string][]string
+	livenessPath  string
+	readinessPath string
 }
 
 // InitDependencies initializes and returns the application dependencies based on the provided configuration.
-func InitDependencies(cfg *Config) *Dependencies {
-	countryCache := cache.NewInMemoryCache()
-	httpClient := client.NewHTTPClient(cfg.HTTPClientTimeout)
-	countryService := service.NewCountryService(httpClient, countryCache)
-	countryHandler := handler.NewCountryHandler(countryService)
+func InitDependencies(cfg *Config) (*Dependencies, error) {
+	logLevel := new(slog.LevelVar)
+	if level, err := logging.ParseLevel(cfg.Logging.Level); err == nil {
+		logLevel.Set(level)
+	}
+	logger := logging.New(os.Stderr, cfg.Logging.Format, logLevel)
+
+	var appMetrics *metrics.Metrics
+	if cfg.Metrics.Enabled {
+		appMetrics = metrics.New()
+	}
+
+	tracerProvider, err := tracing.Setup(context.Background(), tracing.Options{
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		FilePath:    cfg.Tracing.FilePath,
+		SampleRatio: cfg.Tracing.SampleRatio,
+		ServiceName: cfg.Tracing.ServiceName,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("InitDependencies: failed to set up tracing: %w", err)
+	}
+
+	var countryCache countryStore
+	cacheOpts := []cache.Option{cache.WithTTL(cfg.Cache.TTL)}
+	if cfg.Cache.MaxEntries > 0 {
+		cacheOpts = append(cacheOpts, cache.WithEviction(cache.Policy(cfg.Cache.Policy), cfg.Cache.MaxEntries))
+	}
+	if cfg.Cache.Shards > 0 {
+		countryCache = cache.NewShardedCache[string, *model.Country](cfg.Cache.Shards, cacheOpts...)
+	} else {
+		countryCache = cache.NewInMemoryCache[string, *model.Country](cacheOpts...)
+	}
+	appMetrics.ObserveCacheStats(countryCache.Stats)
+	if path := cfg.Cache.SnapshotPath; path != "" {
+		// A bad snapshot only costs a cold cache, so it never stops startup.
+		n, err := countryCache.LoadSnapshot(path)
+		if err != nil {
+			logger.Warn("failed to restore cache snapshot", slog.String("path", path), logging.Error(err))
+		} else {
+			logger.Info("cache snapshot restored", slog.String("path", path), slog.Int("entries", n))
+		}
+	}
 
-	return &Dependencies{
+	// Entries are keyed by alpha-3 code; restored ones make their names resolvable again.
+	aliases := service.NewAliases()
+	for _, entry := range countryCache.Entries() {
+		if entry.Value != nil && entry.Value.Code != "" {
+			aliases.Add(service.NormalizeName(entry.Value.Name), entry.Value.Code)
+		}
+	}
+
+	// The service uses the local cache directly, or through the shared tier when configured.
+	var serviceCache cache.Cache[string, *model.Country] = countryCache
+	var tiered *cache.TieredCache[*model.Country]
+	var remoteCache *cache.RESPClient
+	if remote := cfg.Cache.Remote; remote.Addr != "" {
+		remoteCache = cache.NewRESPClient(cache.RESPOptions{Addr: remote.Addr, Password: string(remote.Password), DB: remote.DB})
+		tiered = cache.NewTieredCache(countryCache, cache.TieredOptions{
+			Remote:        remoteCache,
+			Prefix:        remote.Prefix,
+			TTL:           cfg.Cache.TTL,
+			Timeout:       remote.Timeout,
+			RetryInterval: remote.RetryInterval,
+			Logger:        logger,
+		})
+		serviceCache = tiered
+	}
+
+	httpClient := client.NewHTTPClient(cfg.HTTPClientTimeout,
+		client.WithLogger(logger), client.WithMetrics(appMetrics), client.WithTracerProvider(tracerProvider),
+		client.WithRateLimit(ratelimit.Limit{Rate: cfg.Upstream.RateLimit, Burst: cfg.Upstream.Burst}),
+		client.WithMaxConcurrency(cfg.Upstream.MaxConcurrent))
+	serviceOpts := []service.Option{
+		service.WithLogger(logger), service.WithMetrics(appMetrics), service.WithTracerProvider(tracerProvider),
+		service.WithAliases(aliases),
+	}
+	var countryService service.CountryService
+	var peerPool *peer.Pool
+	if cfg.Peers.Enabled() {
+		peerPool = peer.NewPool(peer.PoolOptions{
+			Self:   cfg.Peers.Self,
+			Secret: string(cfg.Peers.Secret),
+			Getter: func(ctx context.Context, key string) (*model.Country, error) {
+				return countryService.SearchCountry(ctx, key)
+			},
+			Refresher: func(ctx context.Context, key string) (*model.Country, error) {
+				return countryService.RefreshCountry(ctx, key)
+			},
+			Replicas: cfg.Peers.Replicas,
+			Client:   &http.Client{Timeout: cfg.Peers.Timeout},
+			Logger:   logger,
+		})
+		serviceOpts = append(serviceOpts, service.WithPeers(peerPool))
+	}
+	countryService = service.NewCountryService(httpClient, serviceCache, serviceOpts...)
+	countryHandler := handler.NewCountryHandler(countryService, handler.WithLogger(logger))
+
+	checker := health.NewChecker(cfg.Health.CheckTimeout)
+	checker.Register("upstream", cfg.Health.UpstreamCritical,
+		health.Cached(cfg.Health.UpstreamCheckInterval, httpClient.Ping))
+	if remoteCache != nil {
+		// Searches fall back to the local cache, so an unreachable remote tier only degrades.
+		checker.Register("remote_cache", false, remoteCache.Ping)
+	}
+
+	deps := &Dependencies{
 		CountryHandler: countryHandler,
+		Logger:         logger,
+		LogLevel:       logLevel,
+		Metrics:        appMetrics,
+		MetricsPath:    cfg.Metrics.Path,
+		Tracing:        tracerProvider,
+		Health:         checker,
+		cache:          countryCache,
+		tiered:         tiered,
+		remoteCache:    remoteCache,
+		peers:          peerPool,
+		snapshotPath:   cfg.Cache.SnapshotPath,
+		snapshotEvery:  cfg.Cache.SnapshotInterval,
+		sweepEvery:     cfg.Cache.SweepInterval,
+		livenessPath:   cfg.Health.LivenessPath,
+		readinessPath:  cfg.Health.ReadinessPath,
 	}
+	deps.Middleware = buildMiddleware(cfg, deps)
+
+	if peerPool != nil {
+		if len(cfg.Peers.URLs) > 0 {
+			peerPool.Set(cfg.Peers.URLs...)
+		}
+		if srv := cfg.Peers.SRV; srv != "" {
+			scheme, _, _ := strings.Cut(cfg.Peers.Self, "://")
+			deps.discoverPeers = func(ctx context.Context) {
+				peerPool.Discover(ctx, cfg.Peers.RefreshInterval, func(ctx context.Context) ([]string, error) {
+					return peer.LookupSRV(ctx, net.DefaultResolver, scheme, srv)
+				})
+			}
+		}
+	}
+
+	if cfg.Warmup.Enabled() {
+		deps.warmupDone = make(chan struct{})
+		deps.warmup = func(ctx context.Context) {
+			ctx, cancel := context.WithTimeout(ctx, cfg.Warmup.Timeout)
+			defer cancel()
+			service.Warmup(ctx, httpClient, serviceCache, service.WarmupOptions{
+				Names:       cfg.Warmup.Countries,
+				Codes:       cfg.Warmup.Codes,
+				All:         cfg.Warmup.All,
+				Concurrency: cfg.Warmup.Concurrency,
+				Aliases:     aliases,
+				Logger:      logger,
+			})
+		}
+		// Hold readiness so traffic only arrives once the cache is warm.
+		checker.Register("warmup", true, func(ctx context.Context) error {
+			select {
+			case <-deps.warmupDone:
+				return nil
+			default:
+				return errors.New("cache warm-up in progress")
+			}
+		})
+	}
+
+	if cfg.RateLimit.Enabled {
+		trusted, err := ratelimit.ParseTrustedProxies(cfg.RateLimit.TrustedProxies)
+		if err != nil {
+			return nil, fmt.Errorf("InitDependencies: %w", err)
+		}
+		deps.rateLimitKey = rateLimitKey(cfg.RateLimit, trusted)
+
+		deps.rateLimiters = make(map[string]*ratelimit.Limiter)
+		deps.tierLimiters = make(map[string]map[string]*ratelimit.Limiter)
+		for pattern, limit := range rateLimits(cfg.RateLimit) {
+			deps.rateLimiters[pattern] = ratelimit.New(limit, ratelimit.WithIdleTimeout(cfg.RateLimit.IdleTimeout))
+
+			// Tiers only matter for authenticated clients.
+			if !cfg.Auth.Enabled || len(cfg.Auth.Tiers) == 0 {
+				continue
+			}
+			deps.tierLimiters[pattern] = make(map[string]*ratelimit.Limiter, len(cfg.Auth.Tiers))
+			for tier, limit := range cfg.Auth.Tiers {
+				deps.tierLimiters[pattern][tier] = ratelimit.New(ratelimit.Limit{Rate: limit.Rate, Burst: limit.Burst},
+					ratelimit.WithIdleTimeout(cfg.RateLimit.IdleTimeout))
+			}
+		}
+	}
+
+	if cfg.Auth.Enabled {
+		authenticator, err := newAuthenticator(cfg, logger)
+		if err != nil {
+			return nil, fmt.Errorf("InitDependencies: %w", err)
+		}
+		deps.authenticator = authenticator
+		deps.routeScopes = cfg.Auth.Routes
+
+		if cfg.Admin.Enabled {
+			deps.cacheAdmin = handler.NewCacheAdminHandler(serviceCache, countryService, logger)
+		}
+	}
+
+	return deps, nil
+}
+
+// WarmUp prefetches the configured countries into the cache, returning once the warm-up
+// finishes, its timeout passes or ctx is done. Readiness fails until it returns. It does
+// nothing when no warm-up is configured.
+func (d *Dependencies) WarmUp(ctx context.Context) {
+	if d.warmup == nil {
+		return
+	}
+	defer close(d.warmupDone)
+	d.warmup(ctx)
+}
+
+// DiscoverPeers keeps the cache peers in sync with their SRV records until ctx is done.
+// It returns immediately unless peers are discovered through DNS.
+func (d *Dependencies) DiscoverPeers(ctx context.Context) {
+	if d.discoverPeers == nil {
+		return
+	}
+	d.discoverPeers(ctx)
+}
+
+// RunSnapshots saves the cache to the snapshot file every snapshot interval until ctx is
+// done. It returns immediately when snapshots are disabled or only taken at shutdown.
+func (d *Dependencies) RunSnapshots(ctx context.Context) {
+	if d.snapshotPath == "" || d.snapshotEvery <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(d.snapshotEvery)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = d.saveSnapshot()
+		}
+	}
+}
+
+// RunExpirySweep removes expired cache entries every sweep interval until ctx is done, so
+// countries that are not searched again do not hold memory. It returns immediately when
+// sweeping is disabled.
+func (d *Dependencies) RunExpirySweep(ctx context.Context) {
+	if d.sweepEvery <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(d.sweepEvery)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if n := d.cache.DeleteExpired(); n > 0 {
+				d.Logger.Debug("expired cache entries removed", slog.Int("entries", n))
+			}
+		}
+	}
+}
+
+// saveSnapshot writes the cache to the snapshot file, logging the outcome.
+func (d *Dependencies) saveSnapshot() error {
+	n, err := d.cache.SaveSnapshot(d.snapshotPath)
+	if err != nil {
+		d.Logger.Warn("failed to save cache snapshot", slog.String("path", d.snapshotPath), logging.Error(err))
+		return err
+	}
+	d.Logger.Debug("cache snapshot saved", slog.String("path", d.snapshotPath), slog.Int("entries", n))
+	return nil
+}
+
+// Close stops background cache refreshes, saves a final cache snapshot, if enabled, then
+// flushes and releases resources held by the dependencies.
+func (d *Dependencies) Close(ctx context.Context) error {
+	var errs []error
+	// Stop admin refreshes first so they do not write to the cache after the snapshot.
+	if d.cacheAdmin != nil {
+		if err := d.cacheAdmin.Close(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("Close: %w", err))
+		}
+	}
+	if d.snapshotPath != "" {
+		if err := d.saveSnapshot(); err != nil {
+			errs = append(errs, fmt.Errorf("Close: %w", err))
+		}
+	}
+	if d.remoteCache != nil {
+		if err := d.remoteCache.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("Close: %w", err))
+		}
+	}
+	if err := d.Tracing.Shutdown(ctx); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// RouterOptions returns the router options for the middleware stack and the optional
+// endpoints enabled by the configuration.
+func (d *Dependencies) RouterOptions() []router.Option {
+	opts := []router.Option{router.WithMiddleware(d.Middleware...)}
+
+	if d.livenessPath != "" {
+		opts = append(opts, router.WithRoute(d.livenessPath, d.Health.LivenessHandler()))
+	}
+	if d.readinessPath != "" {
+		opts = append(opts, router.WithRoute(d.readinessPath, d.Health.ReadinessHandler()))
+	}
+
+	if d.Metrics != nil {
+		opts = append(opts, router.WithRoute(d.MetricsPath, d.Metrics.Handler()))
+	}
+
+	if d.peers != nil {
+		// Peers fetch the countries this replica owns; see peer.Pool. The pool checks the
+		// shared secret itself, since replicas hold no client credentials.
+		opts = append(opts, router.WithRoute(peer.BasePath, d.peers))
+	}
+
+	if d.cacheAdmin != nil {
+		opts = append(opts, router.WithRoute(handler.CacheAdminPrefix, d.cacheAdmin,
+			middleware.Authenticate(middleware.AuthOptions{
+				Authenticator: d.authenticator,
+				Scopes:        []string{auth.ScopeAdminCache},
+				Logger:        d.Logger,
+				OnResult:      d.Metrics.AuthResult,
+			})))
+	}
+
+	// Authentication runs before rate limiting so clients are limited by identity and tier.
+	patterns := slices.Concat(slices.Collect(maps.Keys(d.routeScopes)), slices.Collect(maps.Keys(d.rateLimiters)))
+	slices.Sort(patterns)
+	for _, pattern := range slices.Compact(patterns) {
+		var stack []router.Middleware
+		if scopes, ok := d.routeScopes[pattern]; ok {
+			stack = append(stack, middleware.Authenticate(middleware.AuthOptions{
+				Authenticator: d.authenticator,
+				Scopes:        scopes,
+				Logger:        d.Logger,
+				OnResult:      d.Metrics.AuthResult,
+			}))
+		}
+		if limiter, ok := d.rateLimiters[pattern]; ok {
+			stack = append(stack, middleware.RateLimit(middleware.RateLimitOptions{
+				Limiter:   limiter,
+				Tiers:     d.tierLimiters[pattern],
+				Key:       d.rateLimitKey,
+				Logger:    d.Logger,
+				OnLimited: d.Metrics.RateLimited,
+			}))
+		}
+		opts = append(opts, router.WithRouteMiddleware(pattern, stack...))
+	}
+
+	return opts
+}
+
+// loadAPIKeys returns the inline API keys followed by those in the keys file.
+func loadAPIKeys(cfg AuthConfig) ([]auth.APIKey, error) {
+	keys := slices.Clone(cfg.Keys)
+	if cfg.KeysFile != "" {
+		fileKeys, err := auth.LoadKeysFile(cfg.KeysFile)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, fileKeys...)
+	}
+	return keys, nil
+}
+
+// loadKeyStore builds the API key store from the inline keys and the keys file.
+func loadKeyStore(cfg AuthConfig) (*auth.KeyStore, error) {
+	keys, err := loadAPIKeys(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return auth.NewKeyStore(keys)
+}
+
+// newAuthenticator returns the configured authenticators: JWTs when a key set is
+// configured, followed by API keys when any are configured.
+func newAuthenticator(cfg *Config, logger *slog.Logger) (auth.Authenticator, error) {
+	var chain auth.Chain
+
+	if jwt := cfg.Auth.JWT; jwt.JWKS != "" {
+		skew := jwt.ClockSkew
+		if skew == 0 {
+			skew = -1 // zero means no leeway here, not the validator's default
+		}
+		keys := auth.NewJWKS(jwt.JWKS, auth.JWKSOptions{
+			Client:          &http.Client{Timeout: cfg.HTTPClientTimeout},
+			RefreshInterval: jwt.RefreshInterval,
+			Logger:          logger,
+		})
+		chain = append(chain, auth.NewJWTValidator(auth.JWTOptions{
+			Keys:      keys,
+			Issuer:    jwt.Issuer,
+			Audience:  jwt.Audience,
+			ClockSkew: skew,
+		}))
+	}
+
+	keyStore, err := loadKeyStore(cfg.Auth)
+	if err != nil {
+		return nil, err
+	}
+	if keyStore.Len() > 0 {
+		chain = append(chain, keyStore)
+	}
+
+	return chain, nil
+}
+
+// rateLimits returns the limit of every rate limited route. Country search always gets the
+// default limit unless it is overridden; other routes are limited only when listed.
+func rateLimits(cfg RateLimitConfig) map[string]ratelimit.Limit {
+	limits := map[string]ratelimit.Limit{
+		router.CountrySearchRoute: {Rate: cfg.Rate, Burst: cfg.Burst},
+	}
+	for pattern, limit := range cfg.Routes {
+		limits[pattern] = ratelimit.Limit{Rate: limit.Rate, Burst: limit.Burst}
+	}
+	return limits
+}
+
+// rateLimitKey returns the function identifying unauthenticated clients for rate limiting.
+// The raw X-API-Key header is never used: anyone could vary it to get a fresh bucket, and
+// clients with a valid key are limited by their authenticated client ID already.
+func rateLimitKey(cfg RateLimitConfig, trusted []netip.Prefix) ratelimit.KeyFunc {
+	byIP := ratelimit.KeyByIP(trusted)
+	if cfg.KeyBy == KeyByHeader {
+		return ratelimit.KeyByHeader(cfg.Header, trusted, byIP)
+	}
+	return byIP
+}
+
+// buildMiddleware assembles the global middleware stack enabled by the configuration, outermost first.
+func buildMiddleware(cfg *Config, deps *Dependencies) []router.Middleware {
+	// Request IDs come first so every other middleware can log them.
+	stack := []router.Middleware{middleware.RequestID()}
+
+	// The server span starts before metrics and compression so it covers the whole request.
+	stack = append(stack, middleware.Tracing(deps.Tracing))
+
+	// Metrics wrap everything below so they observe the final status, including recovered panics.
+	if deps.Metrics != nil {
+		stack = append(stack, middleware.Metrics(deps.Metrics))
+	}
+
+	// CORS answers preflights here, before they reach route authentication and rate limiting.
+	if cfg.CORS.Enabled {
+		stack = append(stack, middleware.CORS(middleware.CORSOptions{
+			AllowedOrigins:   cfg.CORS.AllowedOrigins,
+			AllowedMethods:   cfg.CORS.AllowedMethods,
+			AllowedHeaders:   cfg.CORS.AllowedHeaders,
+			ExposedHeaders:   cfg.CORS.ExposedHeaders,
+			AllowCredentials: cfg.CORS.AllowCredentials,
+			MaxAge:           cfg.CORS.MaxAge,
+		}))
+	}
+
+	if cfg.Compression.Enabled {
+		stack = append(stack, middleware.Compress(cfg.Compression.MinSize))
+	}
+
+	// Recovery sits inside compression so a panic never flushes a half-buffered body.
+	stack = append(stack, middleware.Recover(middleware.RecoverOptions{
+		Logger:  deps.Logger,
+		OnPanic: deps.Metrics.PanicRecovered,
+	}))
+
+	return stack
 }
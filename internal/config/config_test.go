@@ -1,10 +1,25 @@
 package config
 
 import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sj1815/golang-country-search/internal/auth"
+	"github.com/sj1815/golang-country-search/internal/cache"
+	"github.com/sj1815/golang-country-search/internal/model"
+	"github.com/sj1815/golang-country-search/internal/peer"
+	"github.com/sj1815/golang-country-search/internal/ratelimit"
+	"github.com/sj1815/golang-country-search/internal/router"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -16,12 +31,24 @@ func TestDefaultConfig(t *testing.T) {
 	assert.Equal(t, 15*time.Second, cfg.ServerReadTimeout)
 	assert.Equal(t, 15*time.Second, cfg.ServerWriteTimeout)
 	assert.Equal(t, 10*time.Second, cfg.ShutdownTimeout)
+	assert.True(t, cfg.Compression.Enabled)
+	assert.Equal(t, 1024, cfg.Compression.MinSize)
+	assert.Equal(t, "info", cfg.Logging.Level)
+	assert.Equal(t, "json", cfg.Logging.Format)
+	assert.True(t, cfg.Metrics.Enabled)
+	assert.Equal(t, "/metrics", cfg.Metrics.Path)
+	assert.Equal(t, "none", cfg.Tracing.Exporter)
+	assert.Equal(t, 1.0, cfg.Tracing.SampleRatio)
+	assert.Equal(t, "/healthz", cfg.Health.LivenessPath)
+	assert.Equal(t, "/readyz", cfg.Health.ReadinessPath)
+	assert.Equal(t, 5*time.Second, cfg.Health.DrainDelay)
 }
 
 func TestInitDependencies(t *testing.T) {
 	cfg := DefaultConfig()
 
-	deps := InitDependencies(cfg)
+	deps, err := InitDependencies(cfg)
+	require.NoError(t, err)
 
 	assert.NotNil(t, deps)
 	assert.NotNil(t, deps.CountryHandler)
@@ -36,8 +63,414 @@ func TestInitDependencies_WithCustomConfig(t *testing.T) {
 		ShutdownTimeout:    5 * time.Second,
 	}
 
-	deps := InitDependencies(cfg)
+	deps, err := InitDependencies(cfg)
+	require.NoError(t, err)
 
 	assert.NotNil(t, deps)
 	assert.NotNil(t, deps.CountryHandler)
 }
+
+func TestInitDependencies_Middleware(t *testing.T) {
+	cfg := DefaultConfig()
+
+	deps, err := InitDependencies(cfg)
+	require.NoError(t, err)
+	assert.Len(t, deps.Middleware, 5)
+
+	cfg.Compression.Enabled = false
+	cfg.Metrics.Enabled = false
+	deps, err = InitDependencies(cfg)
+	require.NoError(t, err)
+	assert.Len(t, deps.Middleware, 3)
+}
+
+func TestDependencies_RouterOptions(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.RateLimit.Enabled = true
+	deps, err := InitDependencies(cfg)
+	require.NoError(t, err)
+
+	assert.NotNil(t, deps.Metrics)
+	assert.Len(t, deps.RouterOptions(), 5)
+
+	cfg.Metrics.Enabled = false
+	deps, err = InitDependencies(cfg)
+	require.NoError(t, err)
+
+	assert.Nil(t, deps.Metrics)
+	assert.Len(t, deps.RouterOptions(), 4)
+
+	cfg.RateLimit.Enabled = false
+	deps, err = InitDependencies(cfg)
+	require.NoError(t, err)
+
+	assert.Len(t, deps.RouterOptions(), 3)
+}
+
+func TestInitDependencies_Logger(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Logging.Level = "debug"
+
+	deps, err := InitDependencies(cfg)
+	require.NoError(t, err)
+
+	assert.NotNil(t, deps.Logger)
+	assert.Equal(t, slog.LevelDebug, deps.LogLevel.Level())
+	assert.True(t, deps.Logger.Enabled(context.Background(), slog.LevelDebug))
+}
+
+func TestInitDependencies_Tracing(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Tracing.Exporter = "file"
+	cfg.Tracing.FilePath = filepath.Join(t.TempDir(), "spans.json")
+
+	deps, err := InitDependencies(cfg)
+	require.NoError(t, err)
+	assert.NotNil(t, deps.Tracing)
+	assert.NoError(t, deps.Close(context.Background()))
+
+	cfg.Tracing.Exporter = "carrier-pigeon"
+	_, err = InitDependencies(cfg)
+	assert.Error(t, err)
+}
+
+func TestInitDependencies_RateLimit(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.RateLimit.Enabled = true
+	cfg.RateLimit.Rate = 1
+	cfg.RateLimit.Burst = 1
+	cfg.RateLimit.Routes = map[string]RouteLimit{"/healthz": {Rate: 5, Burst: 5}}
+
+	deps, err := InitDependencies(cfg)
+	require.NoError(t, err)
+
+	require.Len(t, deps.rateLimiters, 2)
+	assert.Equal(t, ratelimit.Limit{Rate: 1, Burst: 1}, deps.rateLimiters[router.CountrySearchRoute].Limit())
+	assert.Equal(t, ratelimit.Limit{Rate: 5, Burst: 5}, deps.rateLimiters["/healthz"].Limit())
+
+	handler := router.NewRouter(deps.CountryHandler, deps.RouterOptions()...)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "5", rec.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "4", rec.Header().Get("RateLimit-Remaining"))
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, router.CountrySearchRoute, nil))
+	assert.Equal(t, "1", rec.Header().Get("RateLimit-Limit"))
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, router.CountrySearchRoute, nil))
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+}
+
+func TestInitDependencies_RateLimitKey(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.RateLimit.Enabled = true
+	cfg.RateLimit.KeyBy = KeyByAPIKey
+	cfg.RateLimit.Burst = 1
+
+	deps, err := InitDependencies(cfg)
+	require.NoError(t, err)
+	handler := router.NewRouter(deps.CountryHandler, deps.RouterOptions()...)
+
+	send := func(key string) int {
+		req := httptest.NewRequest(http.MethodGet, router.CountrySearchRoute, nil)
+		req.Header.Set(auth.APIKeyHeader, key)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	assert.Equal(t, http.StatusBadRequest, send("first"))
+	assert.Equal(t, http.StatusTooManyRequests, send("second"), "unverified keys do not get their own bucket")
+}
+
+func TestInitDependencies_Auth(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Auth.Enabled = true
+	cfg.RateLimit.Enabled = true
+	cfg.Auth.Keys = []auth.APIKey{
+		{ID: "free", Key: "free-key", Scopes: []string{auth.ScopeCountriesRead}},
+		{ID: "pro", Key: "pro-key", Scopes: []string{auth.ScopeCountriesRead}, Tier: "pro"},
+	}
+	cfg.Auth.Tiers = map[string]RouteLimit{"pro": {Rate: 100, Burst: 200}}
+
+	deps, err := InitDependencies(cfg)
+	require.NoError(t, err)
+	handler := router.NewRouter(deps.CountryHandler, deps.RouterOptions()...)
+
+	send := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, router.CountrySearchRoute, nil)
+		if key != "" {
+			req.Header.Set(auth.APIKeyHeader, key)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := send("")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Empty(t, rec.Header().Get("RateLimit-Limit"))
+
+	rec = send("free-key")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "20", rec.Header().Get("RateLimit-Limit"))
+
+	rec = send("pro-key")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "200", rec.Header().Get("RateLimit-Limit"))
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestInitDependencies_AuthJWT(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Auth.Enabled = true
+	cfg.Auth.JWT.JWKS = "https://issuer.example/.well-known/jwks.json"
+
+	deps, err := InitDependencies(cfg)
+	require.NoError(t, err)
+	require.IsType(t, auth.Chain{}, deps.authenticator)
+	assert.Len(t, deps.authenticator, 1)
+
+	cfg.Auth.Keys = []auth.APIKey{{ID: "free", Key: "free-key"}}
+	deps, err = InitDependencies(cfg)
+	require.NoError(t, err)
+	assert.Len(t, deps.authenticator, 2)
+}
+
+func TestInitDependencies_CORS(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.CORS.Enabled = true
+	cfg.CORS.AllowedOrigins = []string{"https://dashboard.example.com"}
+	cfg.Auth.Enabled = true
+	cfg.Auth.Keys = []auth.APIKey{{ID: "dashboard", Key: "key", Scopes: []string{auth.ScopeCountriesRead}}}
+
+	deps, err := InitDependencies(cfg)
+	require.NoError(t, err)
+	handler := router.NewRouter(deps.CountryHandler, deps.RouterOptions()...)
+
+	// Preflights never carry credentials, so they must be answered before authentication.
+	req := httptest.NewRequest(http.MethodOptions, router.CountrySearchRoute, nil)
+	req.Header.Set("Origin", "https://dashboard.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	req.Header.Set("Access-Control-Request-Headers", "X-API-Key")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "https://dashboard.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "X-API-Key", rec.Header().Get("Access-Control-Allow-Headers"))
+
+	// Errors carry CORS headers too, so the dashboard can read them.
+	req = httptest.NewRequest(http.MethodGet, router.CountrySearchRoute, nil)
+	req.Header.Set("Origin", "https://dashboard.example.com")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "https://dashboard.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Contains(t, rec.Header().Get("Access-Control-Expose-Headers"), "RateLimit-Remaining")
+}
+
+func TestInitDependencies_CacheAdmin(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Admin.Enabled = true
+	cfg.Auth.Enabled = true
+	cfg.Auth.Keys = []auth.APIKey{
+		{ID: "reader", Key: "reader-key", Scopes: []string{auth.ScopeCountriesRead}},
+		{ID: "ops", Key: "ops-key", Scopes: []string{auth.ScopeAdminCache}},
+	}
+
+	deps, err := InitDependencies(cfg)
+	require.NoError(t, err)
+	handler := router.NewRouter(deps.CountryHandler, deps.RouterOptions()...)
+	deps.cache.Set("germany", &model.Country{Name: "Germany", Capital: "Berlin"})
+
+	send := func(method, target, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		if key != "" {
+			req.Header.Set(auth.APIKeyHeader, key)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, send(http.MethodGet, "/admin/cache/entries", "").Code)
+	assert.Equal(t, http.StatusForbidden, send(http.MethodGet, "/admin/cache/entries", "reader-key").Code)
+
+	rec := send(http.MethodGet, "/admin/cache/entries", "ops-key")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"key":"germany"`)
+
+	assert.Equal(t, http.StatusNoContent, send(http.MethodDelete, "/admin/cache/entries/germany", "ops-key").Code)
+	assert.Equal(t, 0, deps.cache.Len())
+}
+
+func TestInitDependencies_Warmup(t *testing.T) {
+	deps, err := InitDependencies(DefaultConfig())
+	require.NoError(t, err)
+	deps.WarmUp(context.Background())
+	assert.NotContains(t, deps.Health.Check(context.Background()).Checks, "warmup")
+
+	cfg := DefaultConfig()
+	cfg.Warmup.Codes = []string{"de"}
+	deps, err = InitDependencies(cfg)
+	require.NoError(t, err)
+
+	result := deps.Health.Check(context.Background()).Checks["warmup"]
+	assert.Equal(t, "cache warm-up in progress", result.Error)
+	assert.True(t, result.Critical)
+
+	// A canceled warm-up still releases readiness.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	deps.WarmUp(ctx)
+	assert.Empty(t, deps.Health.Check(context.Background()).Checks["warmup"].Error)
+}
+
+func TestInitDependencies_CacheSnapshot(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Cache.SnapshotPath = filepath.Join(t.TempDir(), "cache.json")
+
+	deps, err := InitDependencies(cfg)
+	require.NoError(t, err)
+	deps.cache.Set("DEU", &model.Country{Name: "Germany", Capital: "Berlin", Code: "DEU"})
+	require.NoError(t, deps.Close(context.Background()))
+
+	deps, err = InitDependencies(cfg)
+	require.NoError(t, err)
+	cached, found := deps.cache.Get("DEU")
+	require.True(t, found)
+	assert.Equal(t, &model.Country{Name: "Germany", Capital: "Berlin", Code: "DEU"}, cached)
+
+	// Restored countries are found by name again without calling upstream.
+	rec := httptest.NewRecorder()
+	deps.CountryHandler.SearchCountry(rec, httptest.NewRequest(http.MethodGet, "/api/countries/search?name=GERMANY", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Berlin")
+
+	// A corrupt snapshot leaves the cache empty rather than failing startup.
+	require.NoError(t, os.WriteFile(cfg.Cache.SnapshotPath, []byte("{not json"), 0o600))
+	deps, err = InitDependencies(cfg)
+	require.NoError(t, err)
+	assert.Zero(t, deps.cache.Len())
+}
+
+func TestDependencies_RunExpirySweep(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Cache.TTL = time.Millisecond
+	cfg.Cache.SweepInterval = 5 * time.Millisecond
+	deps, err := InitDependencies(cfg)
+	require.NoError(t, err)
+	deps.cache.Set("DEU", &model.Country{Name: "Germany"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go deps.RunExpirySweep(ctx)
+
+	assert.Eventually(t, func() bool { return deps.cache.Len() == 0 }, time.Second, 5*time.Millisecond,
+		"expired entries are removed without being read")
+	assert.Equal(t, int64(1), deps.cache.Stats().Expirations)
+}
+
+func TestInitDependencies_ShardedCache(t *testing.T) {
+	deps, err := InitDependencies(DefaultConfig())
+	require.NoError(t, err)
+	assert.IsType(t, &cache.InMemoryCache[string, *model.Country]{}, deps.cache)
+
+	cfg := DefaultConfig()
+	cfg.Cache.Shards = 16
+	cfg.Cache.TTL = time.Hour
+	deps, err = InitDependencies(cfg)
+	require.NoError(t, err)
+	require.IsType(t, &cache.ShardedCache[string, *model.Country]{}, deps.cache)
+	assert.Equal(t, 16, deps.cache.(*cache.ShardedCache[string, *model.Country]).Shards())
+	assert.Equal(t, time.Hour, deps.cache.TTL())
+
+	cfg.Cache.Shards = -1
+	assert.ErrorContains(t, cfg.Validate(), "cache.shards: must not be negative")
+}
+
+func TestInitDependencies_CacheEviction(t *testing.T) {
+	for _, policy := range cache.Policies {
+		cfg := DefaultConfig()
+		cfg.Cache.MaxEntries = 2
+		cfg.Cache.Policy = string(policy)
+		deps, err := InitDependencies(cfg)
+		require.NoError(t, err)
+
+		for _, code := range []string{"DEU", "FRA", "ITA", "ESP"} {
+			deps.cache.Set(code, &model.Country{Code: code})
+		}
+		assert.LessOrEqual(t, deps.cache.Len(), 2, policy)
+		assert.Positive(t, deps.cache.Stats().Evictions, policy)
+	}
+
+	cfg := DefaultConfig()
+	cfg.Cache.MaxEntries = -1
+	cfg.Cache.Policy = "fifo"
+	err := cfg.Validate()
+	assert.ErrorContains(t, err, "cache.max_entries: must not be negative")
+	assert.ErrorContains(t, err, `cache.policy: must be one of lru, lfu, tinylfu; got "fifo"`)
+}
+
+func TestInitDependencies_RemoteCache(t *testing.T) {
+	// Reserve a port and release it so nothing is listening there.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	ln.Close()
+
+	cfg := DefaultConfig()
+	cfg.Cache.Remote.Addr = addr
+	deps, err := InitDependencies(cfg)
+	require.NoError(t, err)
+	require.NotNil(t, deps.tiered)
+
+	// The unreachable remote tier degrades readiness and leaves the local cache working.
+	result := deps.Health.Check(context.Background())
+	assert.Equal(t, "degraded", result.Status)
+	assert.False(t, result.Checks["remote_cache"].Critical)
+
+	deps.tiered.Set("germany", &model.Country{Name: "Germany"})
+	assert.False(t, deps.tiered.RemoteAvailable())
+	cached, found := deps.tiered.Get("germany")
+	require.True(t, found)
+	assert.Equal(t, "Germany", cached.Name)
+
+	require.NoError(t, deps.Close(context.Background()))
+}
+
+func TestInitDependencies_Peers(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Peers.Self = "http://10.0.0.1:8000"
+	cfg.Peers.URLs = []string{"http://10.0.0.1:8000", "http://10.0.0.2:8000/"}
+	cfg.Peers.Secret = "shared"
+	deps, err := InitDependencies(cfg)
+	require.NoError(t, err)
+	require.NotNil(t, deps.peers)
+	assert.Equal(t, []string{"http://10.0.0.1:8000", "http://10.0.0.2:8000"}, deps.peers.Peers())
+	assert.Nil(t, deps.discoverPeers, "static peers need no discovery")
+
+	// The peer route only serves replicas holding the shared secret.
+	h := router.NewRouter(deps.CountryHandler, deps.RouterOptions()...)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, peer.BasePath+"germany", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	req := httptest.NewRequest(http.MethodDelete, peer.BasePath+"germany", nil)
+	req.Header.Set(peer.SecretHeader, "shared")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+	cfg.Peers.URLs = nil
+	cfg.Peers.SRV = "_http._tcp.country-search.invalid"
+	deps, err = InitDependencies(cfg)
+	require.NoError(t, err)
+	assert.NotNil(t, deps.discoverPeers)
+}
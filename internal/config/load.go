@@ -0,0 +1,601 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"github.com/sj1815/golang-country-search/internal/auth"
+	"github.com/sj1815/golang-country-search/internal/cache"
+	"github.com/sj1815/golang-country-search/internal/logging"
+	"github.com/sj1815/golang-country-search/internal/ratelimit"
+	"github.com/sj1815/golang-country-search/internal/tracing"
+)
+
+// EnvPrefix prefixes every environment variable read by Load.
+const EnvPrefix = "COUNTRY_SEARCH_"
+
+// ConfigFileEnv names the environment variable holding the config file path.
+const ConfigFileEnv = EnvPrefix + "CONFIG"
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	secretType   = reflect.TypeOf(Secret(""))
+)
+
+// setting is a single configurable leaf of Config, addressed by its YAML path.
+type setting struct {
+	path  []string
+	value reflect.Value
+}
+
+// key returns the dotted YAML key, used in error messages.
+func (s setting) key() string { return strings.Join(s.path, ".") }
+
+// env returns the environment variable name, e.g. COUNTRY_SEARCH_COMPRESSION_MIN_SIZE.
+func (s setting) env() string { return EnvPrefix + strings.ToUpper(strings.Join(s.path, "_")) }
+
+// flag returns the command-line flag name, e.g. compression-min-size.
+func (s setting) flag() string {
+	return strings.ReplaceAll(strings.Join(s.path, "-"), "_", "-")
+}
+
+// settings lists the leaves of cfg in declaration order. Only those whose type is settable
+// can be set from the environment or flags; the rest can only come from the config file.
+func settings(cfg *Config) []setting {
+	return collectSettings(reflect.ValueOf(cfg).Elem(), nil)
+}
+
+// scalarSettings lists the settings of cfg that can be set from the environment or flags.
+func scalarSettings(cfg *Config) []setting {
+	var out []setting
+	for _, s := range settings(cfg) {
+		if settable(s.value.Type()) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func collectSettings(v reflect.Value, prefix []string) []setting {
+	var out []setting
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if !f.IsExported() || name == "" || name == "-" {
+			continue
+		}
+
+		path := append(append([]string{}, prefix...), name)
+		fv := v.Field(i)
+
+		if fv.Kind() == reflect.Struct && fv.Type() != durationType {
+			out = append(out, collectSettings(fv, path)...)
+		} else {
+			out = append(out, setting{path: path, value: fv})
+		}
+	}
+
+	return out
+}
+
+// settable reports whether values of type t can be parsed from a single string.
+func settable(t reflect.Type) bool {
+	if t == durationType {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool, reflect.Int, reflect.Float64:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.String
+	}
+	return false
+}
+
+// set parses raw into the setting's value.
+func (s setting) set(raw string) error {
+	v := s.value
+	raw = strings.TrimSpace(raw)
+
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", raw)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		v.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		v.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	}
+
+	return nil
+}
+
+// Load builds the configuration by layering, from lowest to highest precedence:
+// DefaultConfig, an optional YAML or TOML config file, COUNTRY_SEARCH_* environment variables and
+// command-line flags. The file is named by the -config flag or COUNTRY_SEARCH_CONFIG.
+//
+// Every problem found — unreadable file, unparsable values, failed validation — is
+// reported together in the returned error. Passing -h returns an error wrapping flag.ErrHelp.
+func Load(args []string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	cfg := DefaultConfig()
+
+	// Parse flags first to find the config file, but apply them last.
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	configPath := fs.String("config", "", "path to a YAML or TOML config file (env "+ConfigFileEnv+")")
+
+	flagValues := make(map[string]string)
+	for _, s := range scalarSettings(cfg) {
+		if s.value.Type() == secretType {
+			continue
+		}
+		name := s.flag()
+		usage := fmt.Sprintf("sets %s (env %s)", s.key(), s.env())
+		record := func(raw string) error {
+			flagValues[name] = raw
+			return nil
+		}
+		if s.value.Kind() == reflect.Bool {
+			fs.BoolFunc(name, usage, record)
+		} else {
+			fs.Func(name, usage, record)
+		}
+	}
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil, fmt.Errorf("Load: %w\n%s", err, Usage(fs))
+		}
+		return nil, fmt.Errorf("Load: %w", err)
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("Load: unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+
+	var errs []error
+
+	path := *configPath
+	if path == "" {
+		path, _ = lookupEnv(ConfigFileEnv)
+	}
+	if path != "" {
+		if err := LoadFile(cfg, path); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for _, s := range scalarSettings(cfg) {
+		if raw, ok := lookupEnv(s.env()); ok {
+			if err := s.set(raw); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", s.env(), err))
+			}
+		}
+	}
+
+	for _, s := range scalarSettings(cfg) {
+		if raw, ok := flagValues[s.flag()]; ok {
+			if err := s.set(raw); err != nil {
+				errs = append(errs, fmt.Errorf("-%s: %w", s.flag(), err))
+			}
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("Load: invalid configuration:\n%w", errors.Join(errs...))
+	}
+
+	return cfg, nil
+}
+
+// LoadFile overlays the config file at path onto cfg. Files ending in .toml are parsed as
+// TOML, anything else as YAML. Keys missing from the file keep their current values;
+// unknown keys are rejected.
+func LoadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("LoadFile: failed to read config file: %w", err)
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		md, err := toml.Decode(string(data), cfg)
+		if err != nil {
+			return fmt.Errorf("LoadFile: failed to parse %s: %w", path, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			keys := make([]string, len(undecoded))
+			for i, key := range undecoded {
+				keys[i] = key.String()
+			}
+			return fmt.Errorf("LoadFile: unknown keys in %s: %s", path, strings.Join(keys, ", "))
+		}
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("LoadFile: failed to parse %s: %w", path, err)
+		}
+	}
+
+	cfg.File = path
+	return nil
+}
+
+// Usage renders the flag help text.
+func Usage(fs *flag.FlagSet) string {
+	var b strings.Builder
+	fs.SetOutput(&b)
+	fs.PrintDefaults()
+	fs.SetOutput(io.Discard)
+	return b.String()
+}
+
+// Validate checks the configuration and returns every problem found, joined.
+func (c *Config) Validate() error {
+	var errs []error
+	fail := func(key, format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+	}
+
+	if err := validateListenAddress(c.ServerPort); err != nil {
+		fail("port", "%v", err)
+	}
+
+	positive := map[string]time.Duration{
+		"http_client_timeout":            c.HTTPClientTimeout,
+		"read_timeout":                   c.ServerReadTimeout,
+		"write_timeout":                  c.ServerWriteTimeout,
+		"shutdown_timeout":               c.ShutdownTimeout,
+		"health.check_timeout":           c.Health.CheckTimeout,
+		"health.upstream_check_interval": c.Health.UpstreamCheckInterval,
+	}
+	for _, key := range slices.Sorted(maps.Keys(positive)) {
+		if positive[key] <= 0 {
+			fail(key, "must be a positive duration, got %s", positive[key])
+		}
+	}
+
+	nonNegative := map[string]time.Duration{
+		"health.drain_delay":      c.Health.DrainDelay,
+		"cache.ttl":               c.Cache.TTL,
+		"cache.sweep_interval":    c.Cache.SweepInterval,
+		"cache.snapshot_interval": c.Cache.SnapshotInterval,
+		"reload.watch_interval":   c.Reload.WatchInterval,
+	}
+	for _, key := range slices.Sorted(maps.Keys(nonNegative)) {
+		if nonNegative[key] < 0 {
+			fail(key, "must not be negative, got %s", nonNegative[key])
+		}
+	}
+
+	if path := c.Cache.SnapshotPath; path != "" {
+		if info, err := os.Stat(filepath.Dir(path)); err != nil || !info.IsDir() {
+			fail("cache.snapshot_path", "directory %s does not exist", filepath.Dir(path))
+		}
+	}
+
+	if c.Cache.Shards < 0 {
+		fail("cache.shards", "must not be negative, got %d", c.Cache.Shards)
+	}
+	if c.Cache.MaxEntries < 0 {
+		fail("cache.max_entries", "must not be negative, got %d", c.Cache.MaxEntries)
+	}
+	if !slices.Contains(cache.Policies, cache.Policy(c.Cache.Policy)) {
+		fail("cache.policy", "must be one of lru, lfu, tinylfu; got %q", c.Cache.Policy)
+	}
+	if remote := c.Cache.Remote; remote.Addr != "" {
+		if _, _, err := net.SplitHostPort(remote.Addr); err != nil {
+			fail("cache.remote.addr", "must be host:port, got %q", remote.Addr)
+		}
+		if remote.DB < 0 {
+			fail("cache.remote.db", "must not be negative, got %d", remote.DB)
+		}
+		if remote.Prefix == "" {
+			fail("cache.remote.prefix", "must not be empty; flushing the cache would delete every key in the database")
+		}
+		if remote.Timeout <= 0 {
+			fail("cache.remote.timeout", "must be a positive duration, got %s", remote.Timeout)
+		}
+		if remote.RetryInterval <= 0 {
+			fail("cache.remote.retry_interval", "must be a positive duration, got %s", remote.RetryInterval)
+		}
+	}
+
+	if peers := c.Peers; peers.Enabled() {
+		if err := validatePeerURL(peers.Self); err != nil {
+			fail("peers.self", "%v", err)
+		}
+		switch {
+		case len(peers.URLs) == 0 && peers.SRV == "":
+			fail("peers.urls", "peers.urls or peers.srv is required when peers.self is set")
+		case len(peers.URLs) > 0 && peers.SRV != "":
+			fail("peers.srv", "cannot be combined with peers.urls")
+		}
+		for _, u := range peers.URLs {
+			if err := validatePeerURL(u); err != nil {
+				fail("peers.urls", "%v", err)
+			}
+		}
+		if peers.SRV != "" && peers.RefreshInterval <= 0 {
+			fail("peers.refresh_interval", "must be a positive duration, got %s", peers.RefreshInterval)
+		}
+		if peers.Timeout <= 0 {
+			fail("peers.timeout", "must be a positive duration, got %s", peers.Timeout)
+		}
+		if peers.Replicas < 1 {
+			fail("peers.replicas", "must be at least 1, got %d", peers.Replicas)
+		}
+		if peers.Secret == "" {
+			fail("peers.secret", "is required when peers.self is set")
+		}
+	}
+
+	if c.Compression.MinSize < 0 {
+		fail("compression.min_size", "must not be negative, got %d", c.Compression.MinSize)
+	}
+
+	if _, err := logging.ParseLevel(c.Logging.Level); err != nil {
+		fail("logging.level", "must be one of debug, info, warn, error; got %q", c.Logging.Level)
+	}
+	if f := strings.ToLower(c.Logging.Format); f != logging.FormatJSON && f != logging.FormatText {
+		fail("logging.format", "must be json or text, got %q", c.Logging.Format)
+	}
+
+	if c.Metrics.Enabled && !strings.HasPrefix(c.Metrics.Path, "/") {
+		fail("metrics.path", "must start with /, got %q", c.Metrics.Path)
+	}
+	if !strings.HasPrefix(c.Health.LivenessPath, "/") {
+		fail("health.liveness_path", "must start with /, got %q", c.Health.LivenessPath)
+	}
+	if !strings.HasPrefix(c.Health.ReadinessPath, "/") {
+		fail("health.readiness_path", "must start with /, got %q", c.Health.ReadinessPath)
+	}
+
+	switch strings.ToLower(c.Tracing.Exporter) {
+	case "", tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLP:
+	case tracing.ExporterFile:
+		if c.Tracing.FilePath == "" {
+			fail("tracing.file_path", "is required when tracing.exporter is file")
+		}
+	default:
+		fail("tracing.exporter", "must be one of none, stdout, file, otlp; got %q", c.Tracing.Exporter)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		fail("tracing.sample_ratio", "must be between 0 and 1, got %g", c.Tracing.SampleRatio)
+	}
+
+	if c.RateLimit.Enabled {
+		validateLimit := func(key string, rate float64, burst int) {
+			if rate <= 0 {
+				fail(key+".rate", "must be positive, got %g", rate)
+			}
+			if burst < 1 {
+				fail(key+".burst", "must be at least 1, got %d", burst)
+			}
+		}
+
+		switch c.RateLimit.KeyBy {
+		case KeyByIP:
+		case KeyByAPIKey:
+			if !c.Auth.Enabled {
+				fail("rate_limit.key_by", "api_key requires auth.enabled; without it every client is keyed by IP")
+			}
+		case KeyByHeader:
+			if strings.TrimSpace(c.RateLimit.Header) == "" {
+				fail("rate_limit.header", "is required when rate_limit.key_by is header")
+			}
+			if len(c.RateLimit.TrustedProxies) == 0 {
+				fail("rate_limit.trusted_proxies", "is required when rate_limit.key_by is header; only trusted proxies may set the header")
+			}
+		default:
+			fail("rate_limit.key_by", "must be one of ip, api_key, header; got %q", c.RateLimit.KeyBy)
+		}
+		if _, err := ratelimit.ParseTrustedProxies(c.RateLimit.TrustedProxies); err != nil {
+			fail("rate_limit.trusted_proxies", "%v", errors.Unwrap(err))
+		}
+		validateLimit("rate_limit", c.RateLimit.Rate, c.RateLimit.Burst)
+		if c.RateLimit.IdleTimeout <= 0 {
+			fail("rate_limit.idle_timeout", "must be a positive duration, got %s", c.RateLimit.IdleTimeout)
+		}
+		for _, pattern := range slices.Sorted(maps.Keys(c.RateLimit.Routes)) {
+			limit := c.RateLimit.Routes[pattern]
+			validateLimit(fmt.Sprintf("rate_limit.routes[%s]", pattern), limit.Rate, limit.Burst)
+		}
+	}
+
+	if c.Auth.Enabled {
+		keys, err := loadAPIKeys(c.Auth)
+		if err == nil {
+			_, err = auth.NewKeyStore(keys)
+		}
+		switch {
+		case err != nil:
+			fail("auth.keys", "%v", err)
+		case len(keys) == 0 && c.Auth.JWT.JWKS == "":
+			fail("auth.keys", "at least one API key or auth.jwt.jwks is required when auth is enabled")
+		}
+		for _, key := range keys {
+			if _, ok := c.Auth.Tiers[key.Tier]; key.Tier != "" && !ok {
+				fail("auth.keys", "key %s: unknown tier %q", key.ID, key.Tier)
+			}
+		}
+
+		for _, tier := range slices.Sorted(maps.Keys(c.Auth.Tiers)) {
+			limit := c.Auth.Tiers[tier]
+			if limit.Rate <= 0 || limit.Burst < 1 {
+				fail(fmt.Sprintf("auth.tiers[%s]", tier), "rate must be positive and burst at least 1, got %g/%d",
+					limit.Rate, limit.Burst)
+			}
+		}
+
+		if jwks := c.Auth.JWT.JWKS; auth.IsURL(jwks) {
+			if u, err := url.Parse(jwks); err != nil || u.Host == "" {
+				fail("auth.jwt.jwks", "invalid URL %q", jwks)
+			}
+		} else if jwks != "" {
+			if err := auth.NewJWKS(jwks, auth.JWKSOptions{}).Refresh(context.Background()); err != nil {
+				fail("auth.jwt.jwks", "%v", err)
+			}
+		}
+		if c.Auth.JWT.JWKS != "" {
+			// Without these, any token signed by the key set would do, including tokens
+			// minted for other services.
+			if strings.TrimSpace(c.Auth.JWT.Issuer) == "" {
+				fail("auth.jwt.issuer", "is required when auth.jwt.jwks is set")
+			}
+			if len(c.Auth.JWT.Audience) == 0 {
+				fail("auth.jwt.audience", "is required when auth.jwt.jwks is set")
+			}
+		}
+		if c.Auth.JWT.ClockSkew < 0 {
+			fail("auth.jwt.clock_skew", "must not be negative, got %s", c.Auth.JWT.ClockSkew)
+		}
+		if c.Auth.JWT.RefreshInterval <= 0 {
+			fail("auth.jwt.refresh_interval", "must be a positive duration, got %s", c.Auth.JWT.RefreshInterval)
+		}
+	}
+
+	if c.Admin.Enabled && !c.Auth.Enabled {
+		fail("admin.enabled", "requires auth.enabled so admin endpoints are never exposed unauthenticated")
+	}
+
+	if c.CORS.Enabled {
+		if len(c.CORS.AllowedOrigins) == 0 {
+			fail("cors.allowed_origins", "at least one origin is required when CORS is enabled")
+		}
+		for _, origin := range c.CORS.AllowedOrigins {
+			if err := validateOrigin(origin); err != nil {
+				fail("cors.allowed_origins", "%v", err)
+			}
+			if origin == "*" && c.CORS.AllowCredentials {
+				fail("cors.allow_credentials", "cannot be combined with the \"*\" origin")
+			}
+		}
+		if c.CORS.MaxAge < 0 {
+			fail("cors.max_age", "must not be negative, got %s", c.CORS.MaxAge)
+		}
+	}
+
+	if c.Upstream.RateLimit < 0 {
+		fail("upstream.rate_limit", "must not be negative, got %g", c.Upstream.RateLimit)
+	}
+	if c.Upstream.RateLimit > 0 && c.Upstream.Burst < 1 {
+		fail("upstream.burst", "must be at least 1 when upstream.rate_limit is set, got %d", c.Upstream.Burst)
+	}
+	if c.Upstream.MaxConcurrent < 0 {
+		fail("upstream.max_concurrent", "must not be negative, got %d", c.Upstream.MaxConcurrent)
+	}
+
+	if c.Warmup.Enabled() {
+		if c.Warmup.Concurrency < 1 {
+			fail("warmup.concurrency", "must be at least 1, got %d", c.Warmup.Concurrency)
+		}
+		if c.Warmup.Timeout <= 0 {
+			fail("warmup.timeout", "must be a positive duration, got %s", c.Warmup.Timeout)
+		}
+		for _, name := range c.Warmup.Countries {
+			if strings.TrimSpace(name) == "" {
+				fail("warmup.countries", "country names must not be empty")
+			}
+		}
+		for _, code := range c.Warmup.Codes {
+			if n := len(code); n < 2 || n > 3 {
+				fail("warmup.codes", "%q is not an ISO 3166-1 alpha-2 or alpha-3 code", code)
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateOrigin checks that origin is "*" or a scheme and host with at most one wildcard.
+func validateOrigin(origin string) error {
+	if origin == "*" {
+		return nil
+	}
+	scheme, host, ok := strings.Cut(origin, "://")
+	if !ok || (scheme != "http" && scheme != "https") || host == "" || strings.Contains(host, "/") {
+		return fmt.Errorf("invalid origin %q, want scheme://host[:port]", origin)
+	}
+	if strings.Count(host, "*") > 1 {
+		return fmt.Errorf("invalid origin %q, at most one wildcard is allowed", origin)
+	}
+	return nil
+}
+
+// validatePeerURL checks that u is an http(s) base URL with a host and no path.
+func validatePeerURL(u string) error {
+	parsed, err := url.Parse(u)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" ||
+		strings.TrimSuffix(parsed.Path, "/") != "" {
+		return fmt.Errorf("invalid peer URL %q, want scheme://host[:port]", u)
+	}
+	return nil
+}
+
+// validateListenAddress checks that addr is a host:port pair with a valid port.
+func validateListenAddress(addr string) error {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid listen address %q", addr)
+	}
+
+	n, err := strconv.Atoi(port)
+	if err != nil || n < 0 || n > 65535 {
+		return fmt.Errorf("invalid port in listen address %q", addr)
+	}
+
+	return nil
+}
@@ -0,0 +1,410 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sj1815/golang-country-search/internal/auth"
+)
+
+func envMap(m map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := m[key]
+		return v, ok
+	}
+}
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	cfg, err := Load(nil, envMap(nil))
+	require.NoError(t, err)
+	assert.Equal(t, DefaultConfig(), cfg)
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	path := writeConfigFile(t, `
+port: ":7000"
+read_timeout: 20s
+compression:
+  min_size: 2048
+logging:
+  level: warn
+`)
+
+	env := envMap(map[string]string{
+		ConfigFileEnv:                  "",
+		"COUNTRY_SEARCH_READ_TIMEOUT":  "30s",
+		"COUNTRY_SEARCH_LOGGING_LEVEL": "error",
+	})
+
+	cfg, err := Load([]string{"-config", path, "-logging-level", "debug"}, env)
+	require.NoError(t, err)
+
+	assert.Equal(t, ":7000", cfg.ServerPort, "file overrides default")
+	assert.Equal(t, 2048, cfg.Compression.MinSize, "file overrides default")
+	assert.Equal(t, 30*time.Second, cfg.ServerReadTimeout, "env overrides file")
+	assert.Equal(t, "debug", cfg.Logging.Level, "flag overrides env")
+	assert.Equal(t, 15*time.Second, cfg.ServerWriteTimeout, "unset keys keep defaults")
+}
+
+func TestLoad_ConfigFileFromEnv(t *testing.T) {
+	path := writeConfigFile(t, "metrics:\n  enabled: false\n")
+
+	cfg, err := Load(nil, envMap(map[string]string{ConfigFileEnv: path}))
+	require.NoError(t, err)
+	assert.False(t, cfg.Metrics.Enabled)
+}
+
+func TestLoad_EnvTypes(t *testing.T) {
+	cfg, err := Load(nil, envMap(map[string]string{
+		"COUNTRY_SEARCH_PORT":                 "127.0.0.1:9000",
+		"COUNTRY_SEARCH_COMPRESSION_ENABLED":  "false",
+		"COUNTRY_SEARCH_TRACING_SAMPLE_RATIO": "0.25",
+		"COUNTRY_SEARCH_HEALTH_DRAIN_DELAY":   "0s",
+	}))
+	require.NoError(t, err)
+
+	assert.Equal(t, "127.0.0.1:9000", cfg.ServerPort)
+	assert.False(t, cfg.Compression.Enabled)
+	assert.Equal(t, 0.25, cfg.Tracing.SampleRatio)
+	assert.Equal(t, time.Duration(0), cfg.Health.DrainDelay)
+}
+
+func TestLoad_BoolFlags(t *testing.T) {
+	cfg, err := Load([]string{"-metrics-enabled=false", "-health-upstream-critical"}, envMap(nil))
+	require.NoError(t, err)
+
+	assert.False(t, cfg.Metrics.Enabled)
+	assert.True(t, cfg.Health.UpstreamCritical)
+}
+
+func TestLoad_ReportsAllErrors(t *testing.T) {
+	_, err := Load([]string{"-write-timeout", "0s", "-port", "localhost:99999"}, envMap(map[string]string{
+		"COUNTRY_SEARCH_READ_TIMEOUT":     "soon",
+		"COUNTRY_SEARCH_LOGGING_FORMAT":   "xml",
+		"COUNTRY_SEARCH_TRACING_EXPORTER": "file",
+	}))
+	require.Error(t, err)
+
+	msg := err.Error()
+	assert.Contains(t, msg, `COUNTRY_SEARCH_READ_TIMEOUT: invalid duration "soon"`)
+	assert.Contains(t, msg, "port: invalid port")
+	assert.Contains(t, msg, "write_timeout: must be a positive duration")
+	assert.Contains(t, msg, "logging.format: must be json or text")
+	assert.Contains(t, msg, "tracing.file_path: is required")
+}
+
+func TestLoad_UnknownFileKey(t *testing.T) {
+	path := writeConfigFile(t, "prot: \":9000\"\n")
+
+	_, err := Load([]string{"-config", path}, envMap(nil))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "field prot not found")
+}
+
+func TestLoad_MissingFile(t *testing.T) {
+	_, err := Load([]string{"-config", filepath.Join(t.TempDir(), "missing.yaml")}, envMap(nil))
+	require.Error(t, err)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestLoad_Flags(t *testing.T) {
+	_, err := Load([]string{"-h"}, envMap(nil))
+	assert.True(t, errors.Is(err, flag.ErrHelp))
+
+	_, err = Load([]string{"-no-such-flag"}, envMap(nil))
+	assert.Error(t, err)
+
+	_, err = Load([]string{"extra"}, envMap(nil))
+	assert.Error(t, err)
+
+	// Secrets would be visible in the process list.
+	_, err = Load([]string{"-cache-remote-password", "secret"}, envMap(nil))
+	assert.ErrorContains(t, err, "flag provided but not defined: -cache-remote-password")
+	cfg, err := Load(nil, envMap(map[string]string{"COUNTRY_SEARCH_CACHE_REMOTE_PASSWORD": "secret"}))
+	require.NoError(t, err)
+	assert.Equal(t, Secret("secret"), cfg.Cache.Remote.Password)
+	assert.Equal(t, "[redacted]", cfg.Cache.Remote.Password.String())
+}
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, DefaultConfig().Validate())
+
+	cfg := DefaultConfig()
+	cfg.ServerPort = "8000"
+	cfg.HTTPClientTimeout = -time.Second
+	cfg.Compression.MinSize = -1
+	cfg.Metrics.Path = "metrics"
+	cfg.Tracing.SampleRatio = 2
+	cfg.RateLimit.Enabled = true
+	cfg.RateLimit.KeyBy = "cookie"
+	cfg.RateLimit.TrustedProxies = []string{"10.0.0.0/99"}
+	cfg.RateLimit.Routes = map[string]RouteLimit{"/healthz": {Rate: 0, Burst: 1}}
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	for _, key := range []string{"port", "http_client_timeout", "compression.min_size", "metrics.path",
+		"tracing.sample_ratio", "rate_limit.key_by", "rate_limit.trusted_proxies", "rate_limit.routes[/healthz].rate"} {
+		assert.Contains(t, err.Error(), key+":")
+	}
+}
+
+func TestConfig_ValidateRateLimitHeader(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.RateLimit.Enabled = true
+	cfg.RateLimit.KeyBy = KeyByHeader
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rate_limit.header:")
+	assert.Contains(t, err.Error(), "rate_limit.trusted_proxies: is required")
+
+	cfg.RateLimit.Header = "X-Client-ID"
+	cfg.RateLimit.TrustedProxies = []string{"10.0.0.0/8"}
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestConfig_ValidateRateLimitAPIKey(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.RateLimit.Enabled = true
+	cfg.RateLimit.KeyBy = KeyByAPIKey
+	assert.ErrorContains(t, cfg.Validate(), "rate_limit.key_by: api_key requires auth.enabled")
+
+	cfg.Auth.Enabled = true
+	cfg.Auth.Keys = []auth.APIKey{{ID: "a", Key: "k1"}}
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestConfig_ValidateAuth(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Auth.Enabled = true
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "at least one API key")
+
+	cfg.Auth.Keys = []auth.APIKey{
+		{ID: "a", Key: "k1", Tier: "gold"},
+		{ID: "a", Key: "k2"},
+	}
+	cfg.Auth.Tiers = map[string]RouteLimit{"free": {Rate: 1}}
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "duplicate id")
+	assert.Contains(t, err.Error(), `unknown tier "gold"`)
+	assert.Contains(t, err.Error(), "auth.tiers[free]:")
+
+	cfg.Auth.Keys = nil
+	cfg.Auth.Tiers = nil
+	cfg.Auth.KeysFile = writeConfigFile(t, "- id: dashboard\n  hash: "+auth.HashKey("secret")+"\n")
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestConfig_ValidateJWT(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Auth.Enabled = true
+	cfg.Auth.JWT.JWKS = "https://issuer.example/.well-known/jwks.json"
+	cfg.Auth.JWT.Issuer = "https://issuer.example"
+	cfg.Auth.JWT.Audience = []string{"country-search"}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Auth.JWT.JWKS = "https://"
+	cfg.Auth.JWT.Issuer = ""
+	cfg.Auth.JWT.Audience = nil
+	cfg.Auth.JWT.ClockSkew = -time.Second
+	cfg.Auth.JWT.RefreshInterval = 0
+	err := cfg.Validate()
+	require.Error(t, err)
+	for _, key := range []string{"auth.jwt.jwks", "auth.jwt.issuer", "auth.jwt.audience", "auth.jwt.clock_skew", "auth.jwt.refresh_interval"} {
+		assert.Contains(t, err.Error(), key+":")
+	}
+
+	cfg = DefaultConfig()
+	cfg.Auth.Enabled = true
+	cfg.Auth.JWT.JWKS = writeConfigFile(t, `{"keys": []}`)
+	cfg.Auth.JWT.Issuer = "https://issuer.example"
+	cfg.Auth.JWT.Audience = []string{"country-search"}
+	assert.ErrorContains(t, cfg.Validate(), "no usable RSA or P-256 signing keys")
+}
+
+func TestConfig_ValidateCORS(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.CORS.Enabled = true
+	assert.ErrorContains(t, cfg.Validate(), "cors.allowed_origins: at least one origin")
+
+	cfg.CORS.AllowedOrigins = []string{"https://*.example.com", "http://localhost:3000"}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.CORS.AllowedOrigins = []string{"*", "example.com", "https://*.*.example.com", "https://example.com/path"}
+	cfg.CORS.AllowCredentials = true
+	cfg.CORS.MaxAge = -time.Second
+	err := cfg.Validate()
+	require.Error(t, err)
+	for _, want := range []string{`"example.com"`, "at most one wildcard", `"https://example.com/path"`,
+		"cors.allow_credentials:", "cors.max_age:"} {
+		assert.Contains(t, err.Error(), want)
+	}
+}
+
+func TestConfig_ValidateAdmin(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Admin.Enabled = true
+	assert.ErrorContains(t, cfg.Validate(), "admin.enabled: requires auth.enabled")
+
+	cfg.Auth.Enabled = true
+	cfg.Auth.Keys = []auth.APIKey{{ID: "ops", Key: "ops-key", Scopes: []string{auth.ScopeAdminCache}}}
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestConfig_ValidateWarmup(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Warmup.Concurrency = 0
+	assert.NoError(t, cfg.Validate(), "warm-up settings are ignored while nothing is configured")
+
+	cfg.Warmup.Countries = []string{"Germany", " "}
+	cfg.Warmup.Codes = []string{"de", "germany"}
+	cfg.Warmup.Timeout = 0
+	err := cfg.Validate()
+	require.Error(t, err)
+	for _, want := range []string{
+		"warmup.concurrency: must be at least 1",
+		"warmup.timeout: must be a positive duration",
+		"warmup.countries: country names must not be empty",
+		`warmup.codes: "germany" is not an ISO 3166-1`,
+	} {
+		assert.Contains(t, err.Error(), want)
+	}
+}
+
+func TestConfig_ValidateSnapshot(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Cache.SnapshotPath = filepath.Join(t.TempDir(), "cache.json")
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Cache.SnapshotPath = filepath.Join(t.TempDir(), "missing", "cache.json")
+	cfg.Cache.SnapshotInterval = -time.Second
+	cfg.Cache.SweepInterval = -time.Second
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cache.snapshot_path: directory")
+	assert.Contains(t, err.Error(), "cache.snapshot_interval: must not be negative")
+	assert.Contains(t, err.Error(), "cache.sweep_interval: must not be negative")
+}
+
+func TestConfig_ValidateRemoteCache(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Cache.Remote.Timeout = 0
+	assert.NoError(t, cfg.Validate(), "remote settings are ignored without an address")
+
+	cfg.Cache.Remote.Addr = "redis"
+	cfg.Cache.Remote.DB = -1
+	cfg.Cache.Remote.RetryInterval = 0
+	cfg.Cache.Remote.Prefix = ""
+	err := cfg.Validate()
+	require.Error(t, err)
+	for _, want := range []string{
+		`cache.remote.addr: must be host:port, got "redis"`,
+		"cache.remote.db: must not be negative",
+		"cache.remote.prefix: must not be empty",
+		"cache.remote.timeout: must be a positive duration",
+		"cache.remote.retry_interval: must be a positive duration",
+	} {
+		assert.Contains(t, err.Error(), want)
+	}
+}
+
+func TestConfig_ValidatePeers(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Peers.Timeout = 0
+	assert.NoError(t, cfg.Validate(), "peer settings are ignored without peers.self")
+
+	cfg.Peers.Self = "10.0.0.1:8000"
+	cfg.Peers.Replicas = 0
+	err := cfg.Validate()
+	require.Error(t, err)
+	for _, want := range []string{
+		`peers.self: invalid peer URL "10.0.0.1:8000"`,
+		"peers.urls: peers.urls or peers.srv is required",
+		"peers.timeout: must be a positive duration",
+		"peers.replicas: must be at least 1",
+		"peers.secret: is required when peers.self is set",
+	} {
+		assert.Contains(t, err.Error(), want)
+	}
+
+	cfg = DefaultConfig()
+	cfg.Peers.Self = "http://10.0.0.1:8000"
+	cfg.Peers.URLs = []string{"http://10.0.0.1:8000", "http://10.0.0.2:8000/api"}
+	cfg.Peers.SRV = "_http._tcp.country-search"
+	cfg.Peers.RefreshInterval = 0
+	err = cfg.Validate()
+	require.Error(t, err)
+	for _, want := range []string{
+		`peers.urls: invalid peer URL "http://10.0.0.2:8000/api"`,
+		"peers.srv: cannot be combined with peers.urls",
+		"peers.refresh_interval: must be a positive duration",
+	} {
+		assert.Contains(t, err.Error(), want)
+	}
+
+	cfg.Peers.URLs = nil
+	cfg.Peers.RefreshInterval = time.Minute
+	cfg.Peers.Secret = "shared"
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestLoad_TOMLFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+port = ":7000"
+read_timeout = "20s"
+
+[cache]
+ttl = "1h"
+`), 0o600))
+
+	cfg, err := Load([]string{"-config", path}, envMap(nil))
+	require.NoError(t, err)
+	assert.Equal(t, ":7000", cfg.ServerPort)
+	assert.Equal(t, 20*time.Second, cfg.ServerReadTimeout)
+	assert.Equal(t, time.Hour, cfg.Cache.TTL)
+	assert.Equal(t, path, cfg.File)
+
+	require.NoError(t, os.WriteFile(path, []byte("prot = \":7000\"\n"), 0o600))
+	_, err = Load([]string{"-config", path}, envMap(nil))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown keys")
+}
+
+func TestLoad_RateLimitRoutesFromFile(t *testing.T) {
+	path := writeConfigFile(t, `
+rate_limit:
+  key_by: header
+  header: X-Client-ID
+  trusted_proxies: [10.0.0.0/8]
+  routes:
+    /healthz:
+      rate: 1
+      burst: 2
+`)
+
+	cfg, err := Load([]string{"-config", path}, envMap(map[string]string{
+		"COUNTRY_SEARCH_RATE_LIMIT_TRUSTED_PROXIES": "10.0.0.0/8, 192.168.1.1",
+	}))
+	require.NoError(t, err)
+
+	assert.Equal(t, KeyByHeader, cfg.RateLimit.KeyBy)
+	assert.Equal(t, []string{"10.0.0.0/8", "192.168.1.1"}, cfg.RateLimit.TrustedProxies)
+	assert.Equal(t, map[string]RouteLimit{"/healthz": {Rate: 1, Burst: 2}}, cfg.RateLimit.Routes)
+}
@@ -0,0 +1,178 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sj1815/golang-country-search/internal/logging"
+	"github.com/sj1815/golang-country-search/internal/ratelimit"
+)
+
+// reloadableKeys lists the settings Dependencies.Apply can change on a running server.
+// Changes to any other setting are logged and take effect after a restart.
+var reloadableKeys = map[string]bool{
+	"logging.level":     true,
+	"cache.ttl":         true,
+	"rate_limit.rate":   true,
+	"rate_limit.burst":  true,
+	"rate_limit.routes": true,
+}
+
+// Change is a single setting that differs between two configurations.
+type Change struct {
+	Key string
+	Old string
+	New string
+}
+
+// Reloadable reports whether the change can be applied without a restart.
+func (c Change) Reloadable() bool {
+	return reloadableKeys[c.Key]
+}
+
+// Diff returns the settings that differ between old and updated, in declaration order.
+// Secrets are reported as changed without revealing either value.
+func Diff(old, updated *Config) []Change {
+	before, after := settings(old), settings(updated)
+
+	var changes []Change
+	for i := range before {
+		o, n := fmt.Sprint(before[i].value.Interface()), fmt.Sprint(after[i].value.Interface())
+		changed := o != n
+		if before[i].value.Type() == secretType {
+			changed = before[i].value.String() != after[i].value.String()
+		}
+		if changed {
+			changes = append(changes, Change{Key: before[i].key(), Old: o, New: n})
+		}
+	}
+	return changes
+}
+
+// Apply updates the live components to match the reloadable settings of cfg. cfg must
+// already be valid.
+func (d *Dependencies) Apply(cfg *Config) {
+	if level, err := logging.ParseLevel(cfg.Logging.Level); err == nil {
+		d.LogLevel.Set(level)
+	}
+	if d.cache != nil {
+		d.cache.SetTTL(cfg.Cache.TTL)
+	}
+	if d.tiered != nil {
+		d.tiered.SetTTL(cfg.Cache.TTL)
+	}
+
+	// Routes are wired into the router at startup, so only their limits can change here.
+	// Routes whose override was removed fall back to the default limit.
+	limits := rateLimits(cfg.RateLimit)
+	for pattern, limiter := range d.rateLimiters {
+		limit, ok := limits[pattern]
+		if !ok {
+			limit = ratelimit.Limit{Rate: cfg.RateLimit.Rate, Burst: cfg.RateLimit.Burst}
+		}
+		limiter.SetLimit(limit)
+	}
+}
+
+// Reloader re-reads the configuration on demand and applies the reloadable subset to the
+// running dependencies. Invalid configurations are rejected and the current one is kept.
+type Reloader struct {
+	mu      sync.Mutex
+	current *Config
+	deps    *Dependencies
+	load    func() (*Config, error)
+}
+
+// NewReloader returns a Reloader starting from current. load produces a fresh, validated
+// configuration, typically by calling Load with the process arguments and environment.
+func NewReloader(current *Config, deps *Dependencies, load func() (*Config, error)) *Reloader {
+	return &Reloader{current: current, deps: deps, load: load}
+}
+
+// Current returns a copy of the configuration in effect.
+func (r *Reloader) Current() Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return *r.current
+}
+
+// Reload loads the configuration again and applies every reloadable change. Each change is
+// logged; changes that need a restart are logged as warnings and not applied. If the new
+// configuration is invalid, nothing is applied and the error is returned.
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	logger := r.deps.Logger
+
+	next, err := r.load()
+	if err != nil {
+		logger.Error("config reload rejected, keeping current configuration", logging.Error(err))
+		return fmt.Errorf("Reload: %w", err)
+	}
+
+	changes := Diff(r.current, next)
+	if len(changes) == 0 {
+		logger.Info("config reloaded, nothing changed")
+		return nil
+	}
+
+	// Start from the running configuration and copy over only what can change live, so
+	// Current keeps describing what the server is actually doing.
+	live := *r.current
+	liveSettings, nextSettings := settings(&live), settings(next)
+
+	for _, change := range changes {
+		attrs := []any{slog.String("key", change.Key), slog.String("old", change.Old), slog.String("new", change.New)}
+		if !change.Reloadable() {
+			logger.Warn("config change requires a restart, ignoring", attrs...)
+			continue
+		}
+
+		for i := range liveSettings {
+			if liveSettings[i].key() == change.Key {
+				liveSettings[i].value.Set(nextSettings[i].value)
+			}
+		}
+		logger.Info("config change applied", attrs...)
+	}
+
+	r.deps.Apply(&live)
+	r.current = &live
+
+	return nil
+}
+
+// Watch polls the file at path every interval and calls Reload when its size or
+// modification time changes. It returns when ctx is cancelled.
+func (r *Reloader) Watch(ctx context.Context, path string, interval time.Duration) {
+	last, _ := os.Stat(path)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			r.deps.Logger.Warn("failed to check config file", slog.String("path", path), logging.Error(err))
+			continue
+		}
+		if last != nil && info.ModTime().Equal(last.ModTime()) && info.Size() == last.Size() {
+			continue
+		}
+		last = info
+
+		r.deps.Logger.Info("config file changed, reloading", slog.String("path", path))
+		_ = r.Reload()
+	}
+}
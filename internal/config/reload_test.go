@@ -0,0 +1,165 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sj1815/golang-country-search/internal/logging"
+	"github.com/sj1815/golang-country-search/internal/ratelimit"
+	"github.com/sj1815/golang-country-search/internal/router"
+)
+
+func newReloadDeps(t *testing.T, cfg *Config) (*Dependencies, *bytes.Buffer) {
+	t.Helper()
+	deps, err := InitDependencies(cfg)
+	require.NoError(t, err)
+
+	var logs bytes.Buffer
+	deps.Logger = logging.New(&logs, logging.FormatJSON, slog.LevelDebug)
+	return deps, &logs
+}
+
+func TestDiff(t *testing.T) {
+	old := DefaultConfig()
+	updated := DefaultConfig()
+	assert.Empty(t, Diff(old, updated))
+
+	updated.Logging.Level = "debug"
+	updated.ServerPort = ":9000"
+
+	changes := Diff(old, updated)
+	assert.Equal(t, []Change{
+		{Key: "port", Old: ":8000", New: ":9000"},
+		{Key: "logging.level", Old: "info", New: "debug"},
+	}, changes)
+	assert.False(t, changes[0].Reloadable())
+	assert.True(t, changes[1].Reloadable())
+
+	old.Cache.Remote.Password = "old-secret"
+	updated = DefaultConfig()
+	updated.Cache.Remote.Password = "new-secret"
+	assert.Equal(t, []Change{{Key: "cache.remote.password", Old: "[redacted]", New: "[redacted]"}}, Diff(old, updated))
+}
+
+func TestReloader_AppliesReloadableChanges(t *testing.T) {
+	cfg := DefaultConfig()
+	deps, logs := newReloadDeps(t, cfg)
+
+	next := DefaultConfig()
+	next.Logging.Level = "debug"
+	next.Cache.TTL = time.Hour
+	next.ServerPort = ":9000"
+
+	r := NewReloader(cfg, deps, func() (*Config, error) { return next, nil })
+	require.NoError(t, r.Reload())
+
+	assert.Equal(t, slog.LevelDebug, deps.LogLevel.Level())
+	assert.Equal(t, time.Hour, deps.cache.TTL())
+
+	current := r.Current()
+	assert.Equal(t, "debug", current.Logging.Level)
+	assert.Equal(t, time.Hour, current.Cache.TTL)
+	assert.Equal(t, ":8000", current.ServerPort, "restart-only settings stay as they are")
+
+	assert.Contains(t, logs.String(), `"msg":"config change applied","key":"logging.level","old":"info","new":"debug"`)
+	assert.Contains(t, logs.String(), `"msg":"config change requires a restart, ignoring","key":"port"`)
+}
+
+func TestReloader_RejectsInvalidConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	deps, logs := newReloadDeps(t, cfg)
+
+	r := NewReloader(cfg, deps, func() (*Config, error) { return nil, errors.New("logging.level: bad") })
+	assert.Error(t, r.Reload())
+
+	assert.Equal(t, slog.LevelInfo, deps.LogLevel.Level())
+	assert.Equal(t, "info", r.Current().Logging.Level)
+	assert.Contains(t, logs.String(), "config reload rejected")
+}
+
+func TestReloader_FromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("cache:\n  ttl: 1m\n"), 0o600))
+
+	load := func() (*Config, error) { return Load([]string{"-config", path}, envMap(nil)) }
+	cfg, err := load()
+	require.NoError(t, err)
+	deps, _ := newReloadDeps(t, cfg)
+	r := NewReloader(cfg, deps, load)
+
+	require.NoError(t, os.WriteFile(path, []byte("cache:\n  ttl: -5m\n"), 0o600))
+	assert.Error(t, r.Reload())
+	assert.Equal(t, time.Minute, deps.cache.TTL())
+
+	require.NoError(t, os.WriteFile(path, []byte("cache:\n  ttl: 5m\n"), 0o600))
+	require.NoError(t, r.Reload())
+	assert.Equal(t, 5*time.Minute, deps.cache.TTL())
+}
+
+func TestReloader_Watch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("logging:\n  level: info\n"), 0o600))
+
+	load := func() (*Config, error) { return Load([]string{"-config", path}, envMap(nil)) }
+	cfg, err := load()
+	require.NoError(t, err)
+	deps, _ := newReloadDeps(t, cfg)
+	r := NewReloader(cfg, deps, load)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Watch(ctx, path, 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond) // let the watcher record the initial file state
+
+	require.NoError(t, os.WriteFile(path, []byte("logging:\n  level: error\n"), 0o600))
+
+	assert.Eventually(t, func() bool {
+		return r.Current().Logging.Level == "error"
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, slog.LevelError, deps.LogLevel.Level())
+}
+
+func TestReloader_RateLimits(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.RateLimit.Enabled = true
+	deps, _ := newReloadDeps(t, cfg)
+
+	next := DefaultConfig()
+	next.RateLimit.Enabled = true
+	next.RateLimit.Rate = 2
+	next.RateLimit.Burst = 4
+	next.RateLimit.Routes = map[string]RouteLimit{"/healthz": {Rate: 1, Burst: 1}}
+
+	r := NewReloader(cfg, deps, func() (*Config, error) { return next, nil })
+	require.NoError(t, r.Reload())
+
+	assert.Equal(t, ratelimit.Limit{Rate: 2, Burst: 4}, deps.rateLimiters[router.CountrySearchRoute].Limit())
+	assert.NotContains(t, deps.rateLimiters, "/healthz", "new routes need a restart")
+}
+
+func TestReloader_RateLimitRouteRemoved(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.RateLimit.Enabled = true
+	cfg.RateLimit.Routes = map[string]RouteLimit{"/healthz": {Rate: 1, Burst: 1}}
+	deps, _ := newReloadDeps(t, cfg)
+	require.Contains(t, deps.rateLimiters, "/healthz")
+
+	next := DefaultConfig()
+	next.RateLimit.Enabled = true
+
+	r := NewReloader(cfg, deps, func() (*Config, error) { return next, nil })
+	require.NoError(t, r.Reload())
+
+	assert.Equal(t, ratelimit.Limit{Rate: next.RateLimit.Rate, Burst: next.RateLimit.Burst},
+		deps.rateLimiters["/healthz"].Limit(), "removed overrides fall back to the default limit")
+	assert.Empty(t, r.Current().RateLimit.Routes)
+}
@@ -0,0 +1,315 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sj1815/golang-country-search/internal/cache"
+	"github.com/sj1815/golang-country-search/internal/logging"
+	"github.com/sj1815/golang-country-search/internal/model"
+	"github.com/sj1815/golang-country-search/internal/service"
+)
+
+// CacheAdminPrefix is the path the cache admin API is served under.
+const CacheAdminPrefix = "/admin/cache/"
+
+// RefreshConcurrency bounds the entries a refresh of the whole cache fetches at once.
+const RefreshConcurrency = 4
+
+// CacheAdminHandler serves the cache admin API:
+//
+//	GET    /admin/cache/entries[?prefix=p]    list entries with age, TTL and hit count
+//	GET    /admin/cache/entries/{key}         describe one entry, including its value
+//	DELETE /admin/cache/entries/{key}         delete one entry
+//	DELETE /admin/cache/entries?prefix=p      delete every entry whose key starts with p
+//	POST   /admin/cache/entries/{key}/refresh fetch one entry again from upstream
+//	POST   /admin/cache/refresh               start fetching every entry again from upstream
+//	POST   /admin/cache/flush                 delete every entry
+//	GET    /admin/cache/stats                 report hits, misses, evictions and size
+//
+// Refreshing the whole cache outlives the request: it answers 202 at once, runs in the
+// background and logs its outcome, and only one such refresh runs at a time. Close stops
+// it.
+//
+// Entries are listed by key. Entry metadata is only available from caches implementing
+// cache.Inspector; other caches list keys alone. Statistics are only available from caches
+// implementing cache.StatsReporter. The handler does no authentication of its own.
+type CacheAdminHandler struct {
+	cache   cache.Cache[string, *model.Country]
+	service service.CountryService
+	logger  *slog.Logger
+	now     func() time.Time
+	mux     *http.ServeMux
+
+	refreshing atomic.Bool
+	// refreshes tracks background refreshes, which end early once stopped is canceled.
+	refreshes sync.WaitGroup
+	stopped   context.Context
+	stop      context.CancelFunc
+}
+
+// NewCacheAdminHandler creates a cache admin handler. Refreshes go through svc so refreshed
+// entries are fetched and stored exactly as searches store them. A nil logger uses slog.Default().
+func NewCacheAdminHandler(c cache.Cache[string, *model.Country], svc service.CountryService, logger *slog.Logger) *CacheAdminHandler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	h := &CacheAdminHandler{
+		cache:   c,
+		service: svc,
+		logger:  logger,
+		now:     time.Now,
+		mux:     http.NewServeMux(),
+	}
+	h.stopped, h.stop = context.WithCancel(context.Background())
+
+	h.mux.HandleFunc("GET "+CacheAdminPrefix+"entries", h.listEntries)
+	h.mux.HandleFunc("DELETE "+CacheAdminPrefix+"entries", h.deletePrefix)
+	h.mux.HandleFunc("GET "+CacheAdminPrefix+"entries/{key}", h.getEntry)
+	h.mux.HandleFunc("DELETE "+CacheAdminPrefix+"entries/{key}", h.deleteEntry)
+	h.mux.HandleFunc("POST "+CacheAdminPrefix+"entries/{key}/refresh", h.refreshEntry)
+	h.mux.HandleFunc("POST "+CacheAdminPrefix+"refresh", h.refreshAll)
+	h.mux.HandleFunc("POST "+CacheAdminPrefix+"flush", h.flush)
+	h.mux.HandleFunc("GET "+CacheAdminPrefix+"stats", h.stats)
+
+	return h
+}
+
+// Close cancels any background refresh and waits until it has stopped writing to the
+// cache, or until ctx ends. Refreshes of the whole cache are refused from then on.
+func (h *CacheAdminHandler) Close(ctx context.Context) error {
+	h.stop()
+	done := make(chan struct{})
+	go func() {
+		h.refreshes.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("Close: %w", ctx.Err())
+	}
+}
+
+// ServeHTTP dispatches admin requests; unknown paths get 404 and wrong methods 405.
+func (h *CacheAdminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *CacheAdminHandler) listEntries(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+
+	entries := make([]model.CacheEntry, 0)
+	if inspector, ok := h.cache.(cache.Inspector[string, *model.Country]); ok {
+		for _, info := range inspector.Entries() {
+			if strings.HasPrefix(info.Key, prefix) {
+				entries = append(entries, h.describe(info, false))
+			}
+		}
+	} else {
+		for _, key := range h.cache.Keys() {
+			if strings.HasPrefix(key, prefix) {
+				entries = append(entries, model.CacheEntry{Key: key})
+			}
+		}
+	}
+
+	slices.SortFunc(entries, func(a, b model.CacheEntry) int { return strings.Compare(a.Key, b.Key) })
+	writeJSON(w, h.logger, http.StatusOK, model.CacheEntriesResponse{Count: len(entries), Entries: entries})
+}
+
+func (h *CacheAdminHandler) getEntry(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+
+	if inspector, ok := h.cache.(cache.Inspector[string, *model.Country]); ok {
+		info, found := inspector.Entry(key)
+		if !found {
+			writeError(w, h.logger, http.StatusNotFound, "no cache entry for "+key)
+			return
+		}
+		writeJSON(w, h.logger, http.StatusOK, h.describe(info, true))
+		return
+	}
+
+	value, found := h.cache.Get(key)
+	if !found {
+		writeError(w, h.logger, http.StatusNotFound, "no cache entry for "+key)
+		return
+	}
+	writeJSON(w, h.logger, http.StatusOK, model.CacheEntry{Key: key, Value: value})
+}
+
+func (h *CacheAdminHandler) deleteEntry(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+	if !h.cache.Delete(key) {
+		writeError(w, h.logger, http.StatusNotFound, "no cache entry for "+key)
+		return
+	}
+
+	h.logger.InfoContext(r.Context(), "cache entry deleted", slog.String("key", key))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *CacheAdminHandler) deletePrefix(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+	if prefix == "" {
+		writeError(w, h.logger, http.StatusBadRequest, "prefix query parameter is required; use flush to delete everything")
+		return
+	}
+
+	deleted := 0
+	for _, key := range h.cache.Keys() {
+		if strings.HasPrefix(key, prefix) && h.cache.Delete(key) {
+			deleted++
+		}
+	}
+
+	h.logger.InfoContext(r.Context(), "cache entries deleted", slog.String("prefix", prefix), slog.Int("deleted", deleted))
+	writeJSON(w, h.logger, http.StatusOK, model.CacheDeleteResponse{Deleted: deleted})
+}
+
+func (h *CacheAdminHandler) flush(w http.ResponseWriter, r *http.Request) {
+	deleted := h.cache.Len()
+	h.cache.Clear()
+
+	h.logger.InfoContext(r.Context(), "cache flushed", slog.Int("deleted", deleted))
+	writeJSON(w, h.logger, http.StatusOK, model.CacheDeleteResponse{Deleted: deleted})
+}
+
+func (h *CacheAdminHandler) stats(w http.ResponseWriter, r *http.Request) {
+	reporter, ok := h.cache.(cache.StatsReporter)
+	if !ok {
+		writeError(w, h.logger, http.StatusNotImplemented, "the cache does not report statistics")
+		return
+	}
+
+	stats := reporter.Stats()
+	writeJSON(w, h.logger, http.StatusOK, model.CacheStats{
+		Hits:        stats.Hits,
+		Misses:      stats.Misses,
+		HitRatio:    stats.HitRatio(),
+		Sets:        stats.Sets,
+		Evictions:   stats.Evictions,
+		Expirations: stats.Expirations,
+		Entries:     stats.Entries,
+		MemoryBytes: stats.Bytes,
+	})
+}
+
+func (h *CacheAdminHandler) refreshEntry(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+
+	country, err := h.service.RefreshCountry(r.Context(), key)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "cache refresh failed", slog.String("key", key), logging.Error(err))
+		writeError(w, h.logger, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	h.logger.InfoContext(r.Context(), "cache entry refreshed", slog.String("key", key))
+	writeJSON(w, h.logger, http.StatusOK, country)
+}
+
+func (h *CacheAdminHandler) refreshAll(w http.ResponseWriter, r *http.Request) {
+	if h.stopped.Err() != nil {
+		writeError(w, h.logger, http.StatusServiceUnavailable, "shutting down")
+		return
+	}
+	if !h.refreshing.CompareAndSwap(false, true) {
+		writeError(w, h.logger, http.StatusConflict, "a cache refresh is already running")
+		return
+	}
+
+	keys := h.cache.Keys()
+	slices.Sort(keys)
+
+	// The refresh keeps the request's values, such as its ID for the logs, but not its
+	// deadline; only Close cancels it.
+	ctx, cancel := context.WithCancel(context.WithoutCancel(r.Context()))
+	stopCanceling := context.AfterFunc(h.stopped, cancel)
+	h.refreshes.Add(1)
+	go func() {
+		defer h.refreshes.Done()
+		defer h.refreshing.Store(false)
+		defer cancel()
+		defer stopCanceling()
+		h.refreshKeys(ctx, keys)
+	}()
+
+	h.logger.InfoContext(r.Context(), "cache refresh started", slog.Int("keys", len(keys)))
+	writeJSON(w, h.logger, http.StatusAccepted, model.CacheRefreshResponse{Keys: len(keys)})
+}
+
+// refreshKeys refreshes keys, RefreshConcurrency at a time, and logs the outcome. Keys not
+// yet started when ctx ends are skipped.
+func (h *CacheAdminHandler) refreshKeys(ctx context.Context, keys []string) {
+	var (
+		mu        sync.Mutex
+		refreshed int
+		failed    []string
+		skipped   int
+	)
+	sem := make(chan struct{}, RefreshConcurrency)
+	var wg sync.WaitGroup
+	for i, key := range keys {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			skipped = len(keys) - i
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+
+			_, err := h.service.RefreshCountry(ctx, key)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				h.logger.WarnContext(ctx, "cache refresh failed", slog.String("key", key), logging.Error(err))
+				failed = append(failed, key)
+				return
+			}
+			refreshed++
+		}()
+	}
+	wg.Wait()
+
+	slices.Sort(failed)
+	if skipped > 0 {
+		h.logger.WarnContext(ctx, "cache refresh canceled", slog.Int("skipped", skipped))
+	}
+	h.logger.InfoContext(ctx, "cache refreshed",
+		slog.Int("refreshed", refreshed), slog.Int("failed", len(failed)), slog.Any("failed_keys", failed))
+}
+
+// describe converts entry metadata to its API form, with the value only if withValue is set.
+func (h *CacheAdminHandler) describe(info cache.EntryInfo[string, *model.Country], withValue bool) model.CacheEntry {
+	now := h.now()
+	entry := model.CacheEntry{
+		Key:        info.Key,
+		StoredAt:   info.StoredAt,
+		AgeSeconds: now.Sub(info.StoredAt).Seconds(),
+		Hits:       info.Hits,
+	}
+	if !info.ExpiresAt.IsZero() {
+		expiresAt := info.ExpiresAt
+		ttl := max(expiresAt.Sub(now).Seconds(), 0)
+		entry.ExpiresAt = &expiresAt
+		entry.TTLSeconds = &ttl
+	}
+	if withValue {
+		entry.Value = info.Value
+	}
+	return entry
+}
@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sj1815/golang-country-search/internal/cache"
+	"github.com/sj1815/golang-country-search/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCacheAdmin(t *testing.T) (*CacheAdminHandler, *cache.InMemoryCache[string, *model.Country], *MockCountryService) {
+	t.Helper()
+	c := cache.NewInMemoryCache[string, *model.Country](cache.WithTTL(time.Hour))
+	c.Set("germany", &model.Country{Name: "Germany"})
+	c.Set("georgia", &model.Country{Name: "Georgia"})
+	c.Set("france", &model.Country{Name: "France"})
+
+	svc := new(MockCountryService)
+	return NewCacheAdminHandler(c, svc, nil), c, svc
+}
+
+// plainCache hides every method of the cache beyond cache.Cache.
+type plainCache struct {
+	cache.Cache[string, *model.Country]
+}
+
+func serveAdmin(h http.Handler, method, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+	return rec
+}
+
+// TestCacheAdminHandler_ListEntries tests listing entries with metadata, optionally by prefix.
+func TestCacheAdminHandler_ListEntries(t *testing.T) {
+	h, c, _ := newTestCacheAdmin(t)
+	c.Get("germany")
+
+	rec := serveAdmin(h, http.MethodGet, "/admin/cache/entries")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp model.CacheEntriesResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	require.Equal(t, 3, resp.Count)
+	assert.Equal(t, "france", resp.Entries[0].Key)
+	assert.Equal(t, int64(1), resp.Entries[2].Hits)
+	assert.NotNil(t, resp.Entries[2].ExpiresAt)
+	assert.InDelta(t, time.Hour.Seconds(), *resp.Entries[2].TTLSeconds, 5)
+	assert.Nil(t, resp.Entries[2].Value, "listings leave values out")
+
+	rec = serveAdmin(h, http.MethodGet, "/admin/cache/entries?prefix=ge")
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	assert.Equal(t, 2, resp.Count)
+}
+
+// TestCacheAdminHandler_GetEntry tests fetching a single entry with its value.
+func TestCacheAdminHandler_GetEntry(t *testing.T) {
+	h, _, _ := newTestCacheAdmin(t)
+
+	rec := serveAdmin(h, http.MethodGet, "/admin/cache/entries/germany")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"name":"Germany"`)
+	assert.Contains(t, rec.Body.String(), `"hits":0`)
+
+	rec = serveAdmin(h, http.MethodGet, "/admin/cache/entries/spain")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+// TestCacheAdminHandler_Delete tests deleting by key, by prefix and flushing everything.
+func TestCacheAdminHandler_Delete(t *testing.T) {
+	h, c, _ := newTestCacheAdmin(t)
+
+	assert.Equal(t, http.StatusNoContent, serveAdmin(h, http.MethodDelete, "/admin/cache/entries/france").Code)
+	assert.Equal(t, http.StatusNotFound, serveAdmin(h, http.MethodDelete, "/admin/cache/entries/france").Code)
+
+	assert.Equal(t, http.StatusBadRequest, serveAdmin(h, http.MethodDelete, "/admin/cache/entries").Code)
+
+	rec := serveAdmin(h, http.MethodDelete, "/admin/cache/entries?prefix=geo")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"deleted":1}`, rec.Body.String())
+	assert.Equal(t, []string{"germany"}, c.Keys())
+
+	rec = serveAdmin(h, http.MethodPost, "/admin/cache/flush")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"deleted":1}`, rec.Body.String())
+	assert.Equal(t, 0, c.Len())
+}
+
+// TestCacheAdminHandler_Refresh tests refreshing one entry and every entry from upstream.
+func TestCacheAdminHandler_Refresh(t *testing.T) {
+	h, _, svc := newTestCacheAdmin(t)
+	svc.On("RefreshCountry", mock.Anything, "germany").Return(&model.Country{Name: "Germany", Population: 84000000}, nil)
+	svc.On("RefreshCountry", mock.Anything, "georgia").Return(&model.Country{Name: "Georgia"}, nil)
+	svc.On("RefreshCountry", mock.Anything, "france").Return(nil, errors.New("upstream unavailable"))
+
+	rec := serveAdmin(h, http.MethodPost, "/admin/cache/entries/germany/refresh")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "84000000")
+
+	rec = serveAdmin(h, http.MethodPost, "/admin/cache/entries/france/refresh")
+	assert.Equal(t, http.StatusBadGateway, rec.Code)
+
+	rec = serveAdmin(h, http.MethodPost, "/admin/cache/refresh")
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.JSONEq(t, `{"keys":3}`, rec.Body.String())
+	h.refreshes.Wait()
+	svc.AssertNumberOfCalls(t, "RefreshCountry", 5)
+	svc.AssertExpectations(t)
+}
+
+// TestCacheAdminHandler_RefreshAllBackground tests that a full refresh outlives its request
+// and that only one runs at a time.
+func TestCacheAdminHandler_RefreshAllBackground(t *testing.T) {
+	h, _, svc := newTestCacheAdmin(t)
+	release := make(chan struct{})
+	svc.On("RefreshCountry", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			<-release
+			assert.NoError(t, args.Get(0).(context.Context).Err(), "the refresh is not cancelled with its request")
+		}).
+		Return(&model.Country{}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/cache/refresh", nil).WithContext(ctx))
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	cancel()
+
+	assert.Equal(t, http.StatusConflict, serveAdmin(h, http.MethodPost, "/admin/cache/refresh").Code)
+
+	close(release)
+	h.refreshes.Wait()
+	svc.AssertNumberOfCalls(t, "RefreshCountry", 3)
+	assert.Equal(t, http.StatusAccepted, serveAdmin(h, http.MethodPost, "/admin/cache/refresh").Code)
+	h.refreshes.Wait()
+}
+
+// TestCacheAdminHandler_Close tests that Close cancels a full refresh, waits for it, and
+// refuses new ones.
+func TestCacheAdminHandler_Close(t *testing.T) {
+	h, c, svc := newTestCacheAdmin(t)
+	for _, name := range []string{"spain", "italy", "austria"} {
+		c.Set(name, &model.Country{Name: name})
+	}
+	started := make(chan struct{}, 6)
+	svc.On("RefreshCountry", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			started <- struct{}{}
+			<-args.Get(0).(context.Context).Done()
+		}).
+		Return(nil, context.Canceled)
+
+	assert.Equal(t, http.StatusAccepted, serveAdmin(h, http.MethodPost, "/admin/cache/refresh").Code)
+	for range RefreshConcurrency {
+		<-started
+	}
+
+	require.NoError(t, h.Close(context.Background()))
+	assert.False(t, h.refreshing.Load())
+	svc.AssertNumberOfCalls(t, "RefreshCountry", RefreshConcurrency)
+	assert.Equal(t, http.StatusServiceUnavailable, serveAdmin(h, http.MethodPost, "/admin/cache/refresh").Code)
+}
+
+// TestCacheAdminHandler_Stats tests reporting the cache statistics.
+func TestCacheAdminHandler_Stats(t *testing.T) {
+	h, c, _ := newTestCacheAdmin(t)
+	c.Get("germany")
+	c.Get("spain")
+
+	rec := serveAdmin(h, http.MethodGet, "/admin/cache/stats")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var stats model.CacheStats
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&stats))
+	assert.Equal(t, int64(1), stats.Hits)
+	assert.Equal(t, int64(1), stats.Misses)
+	assert.Equal(t, 0.5, stats.HitRatio)
+	assert.Equal(t, int64(3), stats.Sets)
+	assert.Equal(t, 3, stats.Entries)
+	assert.Positive(t, stats.MemoryBytes)
+
+	untracked := NewCacheAdminHandler(plainCache{c}, nil, nil)
+	assert.Equal(t, http.StatusNotImplemented, serveAdmin(untracked, http.MethodGet, "/admin/cache/stats").Code)
+}
+
+// TestCacheAdminHandler_UnknownRoutes tests that unknown paths and methods are rejected.
+func TestCacheAdminHandler_UnknownRoutes(t *testing.T) {
+	h, _, _ := newTestCacheAdmin(t)
+
+	assert.Equal(t, http.StatusNotFound, serveAdmin(h, http.MethodGet, "/admin/cache/nope").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, serveAdmin(h, http.MethodGet, "/admin/cache/flush").Code)
+}
@@ -3,23 +3,48 @@ package handler
 
 import (
 	"encoding/json"
-	"log"
+	"errors"
+	"log/slog"
 	"net/http"
 
+	"github.com/sj1815/golang-country-search/internal/client"
+	"github.com/sj1815/golang-country-search/internal/logging"
 	"github.com/sj1815/golang-country-search/internal/model"
 	"github.com/sj1815/golang-country-search/internal/service"
 )
 
+// throttledRetryAfter is the Retry-After, in seconds, sent when the upstream API budget is
+// spent.
+const throttledRetryAfter = "1"
+
 // CountryHandler handles HTTP requests related to countries.
 type CountryHandler struct {
 	service service.CountryService
+	logger  *slog.Logger
+}
+
+// Option configures optional dependencies of the country handler.
+type Option func(*CountryHandler)
+
+// WithLogger sets the logger used by the handler. Defaults to slog.Default().
+func WithLogger(logger *slog.Logger) Option {
+	return func(h *CountryHandler) {
+		h.logger = logger
+	}
 }
 
 // NewCountryHandler creates a new instance of CountryHandler.
-func NewCountryHandler(service service.CountryService) *CountryHandler {
-	return &CountryHandler{
+func NewCountryHandler(service service.CountryService, opts ...Option) *CountryHandler {
+	h := &CountryHandler{
 		service: service,
+		logger:  slog.Default(),
 	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
 }
 
 // SearchCountry handles the search for a country by name.
@@ -40,8 +65,7 @@ func (h *CountryHandler) SearchCountry(w http.ResponseWriter, r *http.Request) {
 
 	country, err := h.service.SearchCountry(r.Context(), countryName)
 	if err != nil {
-		log.Printf("Error searching country: %v", err)
-		h.writeError(w, http.StatusNotFound, err.Error())
+		h.writeSearchError(w, r, countryName, err)
 		return
 	}
 
@@ -52,19 +76,50 @@ func (h *CountryHandler) SearchCountry(w http.ResponseWriter, r *http.Request) {
 	h.writeJSON(w, http.StatusOK, country)
 }
 
+// writeSearchError answers a failed search for name. Only unknown countries are reported
+// as such; upstream failures get a generic message and are logged in full.
+func (h *CountryHandler) writeSearchError(w http.ResponseWriter, r *http.Request, name string, err error) {
+	ctx := r.Context()
+	attrs := []any{slog.String(logging.KeyCountry, name), logging.Error(err)}
+	switch {
+	case errors.Is(err, service.ErrEmptyName):
+		h.writeError(w, http.StatusBadRequest, "name query parameter is required")
+	case errors.Is(err, client.ErrNotFound):
+		h.logger.InfoContext(ctx, "country not found", attrs...)
+		h.writeError(w, http.StatusNotFound, "country not found")
+	case errors.Is(err, client.ErrThrottled):
+		h.logger.WarnContext(ctx, "country search throttled", attrs...)
+		w.Header().Set("Retry-After", throttledRetryAfter)
+		h.writeError(w, http.StatusServiceUnavailable, "upstream busy, retry after "+throttledRetryAfter+"s")
+	default:
+		h.logger.ErrorContext(ctx, "country search failed", attrs...)
+		h.writeError(w, http.StatusBadGateway, "country lookup failed")
+	}
+}
+
 // writeJSON writes the given data as a JSON response with the specified status code.
 func (h *CountryHandler) writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	writeJSON(w, h.logger, status, data)
+}
+
+// writeError writes an error response with the specified status code and message.
+func (h *CountryHandler) writeError(w http.ResponseWriter, status int, message string) {
+	writeError(w, h.logger, status, message)
+}
+
+// writeJSON writes data as a JSON response, logging encoding failures to logger.
+func writeJSON(w http.ResponseWriter, logger *slog.Logger, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 
 	if err := json.NewEncoder(w).Encode(data); err != nil {
-		log.Printf("Error encoding response: %v", err)
+		logger.Error("failed to encode response", logging.Error(err))
 	}
 }
 
-// writeError writes an error response with the specified status code and message.
-func (h *CountryHandler) writeError(w http.ResponseWriter, status int, message string) {
-	h.writeJSON(w, status, model.ErrorResponse{
+// writeError writes an ErrorResponse with the specified status code and message.
+func writeError(w http.ResponseWriter, logger *slog.Logger, status int, message string) {
+	writeJSON(w, logger, status, model.ErrorResponse{
 		Error:   http.StatusText(status),
 		Message: message,
 	})
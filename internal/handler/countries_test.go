@@ -3,11 +3,14 @@ package handler
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/sj1815/golang-country-search/internal/client"
 	"github.com/sj1815/golang-country-search/internal/model"
+	"github.com/sj1815/golang-country-search/internal/service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -25,6 +28,14 @@ func (m *MockCountryService) SearchCountry(ctx context.Context, name string) (*m
 	return args.Get(0).(*model.Country), args.Error(1)
 }
 
+func (m *MockCountryService) RefreshCountry(ctx context.Context, name string) (*model.Country, error) {
+	args := m.Called(ctx, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Country), args.Error(1)
+}
+
 func TestNewCountryHandler(t *testing.T) {
 	mockService := new(MockCountryService)
 	handler := NewCountryHandler(mockService)
@@ -88,7 +99,8 @@ func TestCountryHandler_SearchCountry_NotFound(t *testing.T) {
 	mockService := new(MockCountryService)
 	handler := NewCountryHandler(mockService)
 
-	mockService.On("SearchCountry", mock.Anything, "InvalidCountry").Return(nil, errors.New("country not found"))
+	mockService.On("SearchCountry", mock.Anything, "InvalidCountry").
+		Return(nil, fmt.Errorf("SearchCountry: no country data found for name InvalidCountry: %w", client.ErrNotFound))
 
 	req := httptest.NewRequest(http.MethodGet, "/api/countries/search?name=InvalidCountry", nil)
 	rec := httptest.NewRecorder()
@@ -130,7 +142,41 @@ func TestCountryHandler_SearchCountry_ServiceError(t *testing.T) {
 
 	handler.SearchCountry(rec, req)
 
-	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, http.StatusBadGateway, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "internal error")
+	mockService.AssertExpectations(t)
+}
+
+func TestCountryHandler_SearchCountry_Throttled(t *testing.T) {
+	mockService := new(MockCountryService)
+	handler := NewCountryHandler(mockService)
+
+	mockService.On("SearchCountry", mock.Anything, "Germany").
+		Return(nil, fmt.Errorf("SearchCountry: failed to search country by name: %w", client.ErrThrottled))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/countries/search?name=Germany", nil)
+	rec := httptest.NewRecorder()
+
+	handler.SearchCountry(rec, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, throttledRetryAfter, rec.Header().Get("Retry-After"))
+	assert.NotContains(t, rec.Body.String(), "failed to search")
+	mockService.AssertExpectations(t)
+}
+
+func TestCountryHandler_SearchCountry_EmptyName(t *testing.T) {
+	mockService := new(MockCountryService)
+	handler := NewCountryHandler(mockService)
+
+	mockService.On("SearchCountry", mock.Anything, " ").Return(nil, fmt.Errorf("SearchCountry: %w", service.ErrEmptyName))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/countries/search?name=+", nil)
+	rec := httptest.NewRecorder()
+
+	handler.SearchCountry(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
 	mockService.AssertExpectations(t)
 }
 
@@ -0,0 +1,204 @@
+// Package health implements the liveness and readiness endpoints.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sj1815/golang-country-search/internal/logging"
+	"github.com/sj1815/golang-country-search/internal/model"
+)
+
+// Status values reported by the health endpoints.
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+	StatusFail     = "fail"
+	StatusDraining = "draining"
+)
+
+// DefaultCheckTimeout bounds how long a single readiness check may run.
+const DefaultCheckTimeout = 2 * time.Second
+
+// CheckFunc reports whether a component is healthy.
+type CheckFunc func(ctx context.Context) error
+
+type check struct {
+	name     string
+	critical bool
+	fn       CheckFunc
+}
+
+// Checker runs registered readiness checks and serves the health endpoints.
+type Checker struct {
+	timeout  time.Duration
+	draining atomic.Bool
+
+	mu     sync.RWMutex
+	checks []check
+}
+
+// NewChecker creates a Checker that gives each check at most timeout to complete.
+// A zero timeout uses DefaultCheckTimeout.
+func NewChecker(timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = DefaultCheckTimeout
+	}
+	return &Checker{timeout: timeout}
+}
+
+// Register adds a readiness check. A failing critical check makes the service unready;
+// a failing non-critical check is reported but only degrades the overall status.
+func (c *Checker) Register(name string, critical bool, fn CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, check{name: name, critical: critical, fn: fn})
+}
+
+// SetDraining marks the server as shutting down, making readiness fail immediately.
+func (c *Checker) SetDraining() {
+	c.draining.Store(true)
+}
+
+// Draining reports whether SetDraining has been called.
+func (c *Checker) Draining() bool {
+	return c.draining.Load()
+}
+
+// Check runs every registered check concurrently and returns the aggregated result.
+func (c *Checker) Check(ctx context.Context) model.HealthResponse {
+	c.mu.RLock()
+	checks := append([]check(nil), c.checks...)
+	c.mu.RUnlock()
+
+	results := make([]model.CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, chk := range checks {
+		wg.Add(1)
+		go func(i int, chk check) {
+			defer wg.Done()
+			results[i] = c.run(ctx, chk)
+		}(i, chk)
+	}
+	wg.Wait()
+
+	resp := model.HealthResponse{Status: StatusOK, Checks: make(map[string]model.CheckResult, len(checks))}
+	for i, chk := range checks {
+		result := results[i]
+		resp.Checks[chk.name] = result
+
+		if result.Status == StatusOK {
+			continue
+		}
+		if chk.critical {
+			resp.Status = StatusFail
+		} else if resp.Status == StatusOK {
+			resp.Status = StatusDegraded
+		}
+	}
+
+	return resp
+}
+
+// run executes a single check with the checker's timeout.
+func (c *Checker) run(ctx context.Context, chk check) model.CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- chk.fn(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := model.CheckResult{
+		Status:     StatusOK,
+		Critical:   chk.critical,
+		DurationMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// LivenessHandler serves /healthz. It succeeds as long as the process can serve HTTP.
+func (c *Checker) LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, model.HealthResponse{Status: StatusOK})
+	})
+}
+
+// ReadinessHandler serves /readyz with per-check details. It returns 503 when a critical
+// check fails, and immediately, without running checks, once the server is draining.
+func (c *Checker) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c.Draining() {
+			writeJSON(w, http.StatusServiceUnavailable, model.HealthResponse{Status: StatusDraining})
+			return
+		}
+
+		resp := c.Check(r.Context())
+
+		status := http.StatusOK
+		if resp.Status == StatusFail {
+			status = http.StatusServiceUnavailable
+		}
+		writeJSON(w, status, resp)
+	})
+}
+
+// Cached wraps fn so its result is reused for ttl, keeping expensive checks such as
+// upstream calls off the probe's hot path. Results cut short by the caller's context are
+// returned but not reused, and probes do not wait for each other.
+func Cached(ttl time.Duration, fn CheckFunc) CheckFunc {
+	var (
+		mu      sync.Mutex
+		checked time.Time
+		lastErr error
+	)
+
+	return func(ctx context.Context) error {
+		mu.Lock()
+		if !checked.IsZero() && time.Since(checked) < ttl {
+			err := lastErr
+			mu.Unlock()
+			return err
+		}
+		mu.Unlock()
+
+		err := fn(ctx)
+		if ctx.Err() != nil {
+			return err
+		}
+
+		mu.Lock()
+		lastErr = err
+		checked = time.Now()
+		mu.Unlock()
+		return err
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		slog.Error("failed to encode response", logging.Error(err))
+	}
+}
@@ -0,0 +1,178 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sj1815/golang-country-search/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serve runs h and decodes the JSON health response.
+func serve(t *testing.T, h http.Handler) (int, model.HealthResponse) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	var resp model.HealthResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	return rec.Code, resp
+}
+
+func ok(context.Context) error { return nil }
+
+func failing(context.Context) error { return errors.New("unreachable") }
+
+// TestChecker_Liveness tests that liveness always succeeds, even while draining.
+func TestChecker_Liveness(t *testing.T) {
+	c := NewChecker(0)
+	c.Register("broken", true, failing)
+	c.SetDraining()
+
+	code, resp := serve(t, c.LivenessHandler())
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, StatusOK, resp.Status)
+}
+
+// TestChecker_ReadinessOK tests a fully healthy readiness response.
+func TestChecker_ReadinessOK(t *testing.T) {
+	c := NewChecker(0)
+	c.Register("cache", true, ok)
+	c.Register("upstream", false, ok)
+
+	code, resp := serve(t, c.ReadinessHandler())
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, StatusOK, resp.Status)
+	assert.Equal(t, StatusOK, resp.Checks["cache"].Status)
+	assert.True(t, resp.Checks["cache"].Critical)
+	assert.Equal(t, StatusOK, resp.Checks["upstream"].Status)
+}
+
+// TestChecker_ReadinessDegraded tests that a failing non-critical check keeps the service ready.
+func TestChecker_ReadinessDegraded(t *testing.T) {
+	c := NewChecker(0)
+	c.Register("cache", true, ok)
+	c.Register("upstream", false, failing)
+
+	code, resp := serve(t, c.ReadinessHandler())
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, StatusDegraded, resp.Status)
+	assert.Equal(t, StatusFail, resp.Checks["upstream"].Status)
+	assert.Equal(t, "unreachable", resp.Checks["upstream"].Error)
+}
+
+// TestChecker_ReadinessFail tests that a failing critical check makes the service unready.
+func TestChecker_ReadinessFail(t *testing.T) {
+	c := NewChecker(0)
+	c.Register("cache", true, failing)
+	c.Register("upstream", false, failing)
+
+	code, resp := serve(t, c.ReadinessHandler())
+
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, StatusFail, resp.Status)
+}
+
+// TestChecker_ReadinessTimeout tests that a hanging check is reported as failed.
+func TestChecker_ReadinessTimeout(t *testing.T) {
+	c := NewChecker(20 * time.Millisecond)
+	c.Register("stuck", true, func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	code, resp := serve(t, c.ReadinessHandler())
+
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Contains(t, resp.Checks["stuck"].Error, "deadline exceeded")
+}
+
+// TestChecker_Draining tests that readiness fails immediately once draining starts.
+func TestChecker_Draining(t *testing.T) {
+	c := NewChecker(0)
+	called := false
+	c.Register("cache", true, func(context.Context) error {
+		called = true
+		return nil
+	})
+
+	c.SetDraining()
+	code, resp := serve(t, c.ReadinessHandler())
+
+	assert.True(t, c.Draining())
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, StatusDraining, resp.Status)
+	assert.False(t, called)
+}
+
+// TestCached tests that check results are reused until the TTL expires.
+func TestCached(t *testing.T) {
+	calls := 0
+	check := Cached(50*time.Millisecond, func(context.Context) error {
+		calls++
+		return errors.New("down")
+	})
+
+	assert.Error(t, check(context.Background()))
+	assert.Error(t, check(context.Background()))
+	assert.Equal(t, 1, calls)
+
+	time.Sleep(60 * time.Millisecond)
+	assert.Error(t, check(context.Background()))
+	assert.Equal(t, 2, calls)
+}
+
+// TestCached_ContextErrors tests that results cut short by the caller are not reused and
+// that a slow probe does not block others.
+func TestCached_ContextErrors(t *testing.T) {
+	release := make(chan struct{})
+	var calls atomic.Int32
+	check := Cached(time.Minute, func(ctx context.Context) error {
+		if calls.Add(1) == 1 {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		select {
+		case <-release:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, check(ctx), context.Canceled)
+
+	done := make(chan error, 1)
+	go func() { done <- check(context.Background()) }()
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	blocked := make(chan struct{})
+	go func() {
+		// Runs its own probe rather than waiting for the one in flight.
+		assert.ErrorIs(t, check(ctx), context.DeadlineExceeded)
+		close(blocked)
+	}()
+	select {
+	case <-blocked:
+	case <-time.After(time.Second):
+		t.Fatal("a probe in flight blocks other probes")
+	}
+	close(release)
+	assert.NoError(t, <-done)
+	assert.NoError(t, check(context.Background()), "the completed probe is reused")
+	assert.Equal(t, int32(3), calls.Load())
+}
@@ -0,0 +1,120 @@
+// Package logging builds the structured loggers used across the service.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/sj1815/golang-country-search/internal/requestid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Attribute keys shared by every package so log lines can be queried consistently.
+const (
+	KeyRequestID       = "request_id"
+	KeyCountry         = "country"
+	KeyCacheResult     = "cache_result"
+	KeyUpstreamLatency = "upstream_latency_ms"
+	KeyError           = "error"
+	KeyTraceID         = "trace_id"
+	KeySpanID          = "span_id"
+	KeyClientID        = "client_id"
+)
+
+// Supported output formats.
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// ParseLevel converts a level name such as "debug" or "warn" into a slog.Level.
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return slog.LevelInfo, fmt.Errorf("ParseLevel: invalid log level %q", s)
+	}
+	return level, nil
+}
+
+// New creates a logger writing to w in the given format ("json" or "text") at the given level.
+// Unknown formats fall back to JSON. Every record logged with a context carrying a request
+// ID or an active span gets request_id, trace_id and span_id attributes, as well as any
+// attributes added to the context with WithAttrs.
+func New(w io.Writer, format string, level slog.Leveler) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+
+	var h slog.Handler
+	if strings.EqualFold(format, FormatText) {
+		h = slog.NewTextHandler(w, opts)
+	} else {
+		h = slog.NewJSONHandler(w, opts)
+	}
+
+	return slog.New(NewContextHandler(h))
+}
+
+// Discard returns a logger that drops every record, for use in tests.
+func Discard() *slog.Logger {
+	return slog.New(slog.DiscardHandler)
+}
+
+// Error returns an attribute describing err.
+func Error(err error) slog.Attr {
+	return slog.Any(KeyError, err)
+}
+
+// Latency returns the upstream latency attribute in fractional milliseconds.
+func Latency(d time.Duration) slog.Attr {
+	return slog.Float64(KeyUpstreamLatency, float64(d.Microseconds())/1000)
+}
+
+// attrsKey is the context key for attributes added with WithAttrs.
+type attrsKey struct{}
+
+// WithAttrs returns a copy of ctx whose records, when logged through a ContextHandler, carry
+// attrs in addition to any attributes ctx already carries.
+func WithAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	existing, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	return context.WithValue(ctx, attrsKey{}, append(existing[:len(existing):len(existing)], attrs...))
+}
+
+// ContextHandler decorates a slog.Handler with attributes taken from the record's context.
+type ContextHandler struct {
+	slog.Handler
+}
+
+// NewContextHandler wraps h so records include the request ID, trace context and any
+// attributes added with WithAttrs from their context.
+func NewContextHandler(h slog.Handler) *ContextHandler {
+	return &ContextHandler{Handler: h}
+}
+
+// Handle adds context attributes before passing the record on.
+func (h *ContextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		if id := requestid.FromContext(ctx); id != "" {
+			r.AddAttrs(slog.String(KeyRequestID, id))
+		}
+		if attrs, ok := ctx.Value(attrsKey{}).([]slog.Attr); ok {
+			r.AddAttrs(attrs...)
+		}
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			r.AddAttrs(slog.String(KeyTraceID, sc.TraceID().String()), slog.String(KeySpanID, sc.SpanID().String()))
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs keeps the context decoration on derived handlers.
+func (h *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &ContextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup keeps the context decoration on derived handlers.
+func (h *ContextHandler) WithGroup(name string) slog.Handler {
+	return &ContextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,115 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/sj1815/golang-country-search/internal/requestid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TestParseLevel tests parsing of level names.
+func TestParseLevel(t *testing.T) {
+	tests := map[string]slog.Level{
+		"debug": slog.LevelDebug,
+		"INFO":  slog.LevelInfo,
+		"warn":  slog.LevelWarn,
+		"error": slog.LevelError,
+	}
+
+	for input, expected := range tests {
+		level, err := ParseLevel(input)
+		require.NoError(t, err)
+		assert.Equal(t, expected, level)
+	}
+
+	_, err := ParseLevel("verbose")
+	assert.Error(t, err)
+}
+
+// TestNew_JSON tests that JSON output includes the request ID and shared attributes.
+func TestNew_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, FormatJSON, slog.LevelInfo)
+
+	ctx := requestid.NewContext(context.Background(), "req-1")
+	logger.With(slog.String("component", "test")).InfoContext(ctx, "lookup",
+		slog.String(KeyCountry, "germany"), Latency(1500*time.Microsecond), Error(errors.New("boom")))
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "lookup", entry["msg"])
+	assert.Equal(t, "req-1", entry[KeyRequestID])
+	assert.Equal(t, "germany", entry[KeyCountry])
+	assert.Equal(t, 1.5, entry[KeyUpstreamLatency])
+	assert.Equal(t, "boom", entry[KeyError])
+	assert.Equal(t, "test", entry["component"])
+}
+
+// TestNew_Text tests the text format and level filtering.
+func TestNew_Text(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, FormatText, slog.LevelWarn)
+
+	logger.Info("hidden")
+	logger.Warn("shown")
+
+	assert.NotContains(t, buf.String(), "hidden")
+	assert.Contains(t, buf.String(), "msg=shown")
+	assert.NotContains(t, buf.String(), KeyRequestID)
+}
+
+// TestNew_LevelVar tests that the level can be changed after the logger is built.
+func TestNew_LevelVar(t *testing.T) {
+	var buf bytes.Buffer
+	level := new(slog.LevelVar)
+	logger := New(&buf, FormatJSON, level)
+
+	logger.Debug("before")
+	level.Set(slog.LevelDebug)
+	logger.Debug("after")
+
+	assert.NotContains(t, buf.String(), "before")
+	assert.Contains(t, buf.String(), "after")
+}
+
+// TestNew_TraceContext tests that trace and span IDs from the context are logged.
+func TestNew_TraceContext(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, FormatJSON, slog.LevelInfo)
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+
+	logger.InfoContext(ctx, "traced")
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", entry[KeyTraceID])
+	assert.Equal(t, "00f067aa0ba902b7", entry[KeySpanID])
+}
+
+// TestNew_ContextAttrs tests that attributes added to the context are logged.
+func TestNew_ContextAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, FormatJSON, slog.LevelInfo)
+
+	ctx := WithAttrs(context.Background(), slog.String(KeyClientID, "dashboard"))
+	logger.InfoContext(WithAttrs(ctx, slog.String("tier", "gold")), "authenticated")
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "dashboard", entry[KeyClientID])
+	assert.Equal(t, "gold", entry["tier"])
+}
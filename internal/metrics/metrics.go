@@ -0,0 +1,234 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sj1815/golang-country-search/internal/cache"
+)
+
+// Namespace prefixes every metric exported by the service.
+const Namespace = "country_search"
+
+// Upstream call outcomes used as the "outcome" label.
+const (
+	OutcomeSuccess     = "success"
+	OutcomeNotFound    = "not_found"
+	OutcomeHTTPError   = "http_error"
+	OutcomeDecodeError = "decode_error"
+	OutcomeNetwork     = "network_error"
+	OutcomeCanceled    = "canceled"
+	OutcomeThrottled   = "throttled"
+)
+
+// UnmatchedRoute is the route label used for requests that matched no route.
+const UnmatchedRoute = "unmatched"
+
+// Metrics holds every metric exported by the service. All methods are safe to call on a
+// nil *Metrics, which makes instrumentation optional for callers and tests.
+type Metrics struct {
+	registry *Registry
+
+	requests         *CounterVec
+	requestDuration  *HistogramVec
+	requestsInFlight *Gauge
+	panics           *CounterVec
+	rateLimited      *CounterVec
+	authRequests     *CounterVec
+
+	cacheHits   *Counter
+	cacheMisses *Counter
+
+	upstreamRequests *CounterVec
+	upstreamDuration *HistogramVec
+	upstreamQueued   *Gauge
+	upstreamWait     *Histogram
+}
+
+// New creates the service metrics in a fresh registry.
+func New() *Metrics {
+	r := NewRegistry()
+
+	return &Metrics{
+		registry: r,
+
+		requests: r.NewCounterVec(Namespace+"_http_requests_total",
+			"Total HTTP requests by route, method and status code.", "route", "method", "status"),
+		requestDuration: r.NewHistogramVec(Namespace+"_http_request_duration_seconds",
+			"HTTP request latency by route, method and status code.", nil, "route", "method", "status"),
+		requestsInFlight: r.NewGauge(Namespace+"_http_requests_in_flight",
+			"HTTP requests currently being served."),
+		panics: r.NewCounterVec(Namespace+"_http_panics_total",
+			"Panics recovered while serving HTTP requests, by route.", "route"),
+		rateLimited: r.NewCounterVec(Namespace+"_http_rate_limited_total",
+			"HTTP requests rejected by the rate limiter, by route.", "route"),
+		authRequests: r.NewCounterVec(Namespace+"_auth_requests_total",
+			"Authentication attempts by client and result.", "client", "result"),
+
+		cacheHits: r.NewCounter(Namespace+"_cache_hits_total",
+			"Country lookups served from the cache."),
+		cacheMisses: r.NewCounter(Namespace+"_cache_misses_total",
+			"Country lookups not found in the cache."),
+
+		upstreamRequests: r.NewCounterVec(Namespace+"_upstream_requests_total",
+			"Calls to the REST Countries API by outcome.", "outcome"),
+		upstreamDuration: r.NewHistogramVec(Namespace+"_upstream_request_duration_seconds",
+			"Latency of calls to the REST Countries API by outcome.", nil, "outcome"),
+		upstreamQueued: r.NewGauge(Namespace+"_upstream_queue_depth",
+			"Callers waiting for the outbound rate limit or a free connection slot."),
+		upstreamWait: r.NewHistogram(Namespace+"_upstream_queue_wait_seconds",
+			"Time callers spent waiting for the outbound rate limit and a free connection slot.", nil),
+	}
+}
+
+// Registry returns the underlying registry so callers can add their own metrics.
+func (m *Metrics) Registry() *Registry {
+	if m == nil {
+		return nil
+	}
+	return m.registry
+}
+
+// Handler serves all metrics in the Prometheus text exposition format.
+func (m *Metrics) Handler() http.Handler {
+	if m == nil {
+		return http.NotFoundHandler()
+	}
+	return m.registry.Handler()
+}
+
+// RequestStarted increments the in-flight gauge; call RequestFinished when the request ends.
+func (m *Metrics) RequestStarted() {
+	if m == nil {
+		return
+	}
+	m.requestsInFlight.Inc()
+}
+
+// RequestFinished records a completed HTTP request and decrements the in-flight gauge.
+func (m *Metrics) RequestFinished(route, method string, status int, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.requestsInFlight.Dec()
+
+	if route == "" {
+		route = UnmatchedRoute
+	}
+	code := strconv.Itoa(status)
+	method = normalizeMethod(method)
+
+	m.requests.WithLabelValues(route, method, code).Inc()
+	m.requestDuration.WithLabelValues(route, method, code).Observe(d.Seconds())
+}
+
+// PanicRecovered counts a panic recovered on route.
+func (m *Metrics) PanicRecovered(route string) {
+	if m == nil {
+		return
+	}
+	m.panics.WithLabelValues(route).Inc()
+}
+
+// RateLimited counts a request on route rejected by the rate limiter.
+func (m *Metrics) RateLimited(route string) {
+	if m == nil {
+		return
+	}
+	if route == "" {
+		route = UnmatchedRoute
+	}
+	m.rateLimited.WithLabelValues(route).Inc()
+}
+
+// AuthResult counts an authentication attempt by client, which must come from a bounded set
+// such as the configured API key IDs; an empty client is reported as "anonymous".
+func (m *Metrics) AuthResult(client, result string) {
+	if m == nil {
+		return
+	}
+	if client == "" {
+		client = "anonymous"
+	}
+	m.authRequests.WithLabelValues(client, result).Inc()
+}
+
+// CacheHit counts a lookup served from the cache.
+func (m *Metrics) CacheHit() {
+	if m == nil {
+		return
+	}
+	m.cacheHits.Inc()
+}
+
+// CacheMiss counts a lookup that missed the cache.
+func (m *Metrics) CacheMiss() {
+	if m == nil {
+		return
+	}
+	m.cacheMisses.Inc()
+}
+
+// ObserveCacheStats registers metrics reporting the entry count, approximate memory size,
+// sets, evictions and expirations of the cache via stats. Hits and misses are counted by
+// CacheHit and CacheMiss, which see every tier of the cache.
+func (m *Metrics) ObserveCacheStats(stats func() cache.Stats) {
+	if m == nil {
+		return
+	}
+	m.registry.NewGaugeFunc(Namespace+"_cache_entries", "Entries currently held in the cache.", func() float64 {
+		return float64(stats().Entries)
+	})
+	m.registry.NewGaugeFunc(Namespace+"_cache_memory_bytes",
+		"Approximate memory held by the cache entries.", func() float64 {
+			return float64(stats().Bytes)
+		})
+	m.registry.NewCounterFunc(Namespace+"_cache_sets_total", "Values stored in the cache.", func() float64 {
+		return float64(stats().Sets)
+	})
+	m.registry.NewCounterFunc(Namespace+"_cache_evictions_total",
+		"Entries removed from the cache to make room for others.", func() float64 {
+			return float64(stats().Evictions)
+		})
+	m.registry.NewCounterFunc(Namespace+"_cache_expirations_total",
+		"Entries removed from the cache because they expired.", func() float64 {
+			return float64(stats().Expirations)
+		})
+}
+
+// UpstreamRequest records a call to the REST Countries API.
+func (m *Metrics) UpstreamRequest(outcome string, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.upstreamRequests.WithLabelValues(outcome).Inc()
+	m.upstreamDuration.WithLabelValues(outcome).Observe(d.Seconds())
+}
+
+// UpstreamQueued increments the upstream queue depth; call UpstreamDequeued when the wait ends.
+func (m *Metrics) UpstreamQueued() {
+	if m == nil {
+		return
+	}
+	m.upstreamQueued.Inc()
+}
+
+// UpstreamDequeued decrements the upstream queue depth and records how long the caller waited.
+func (m *Metrics) UpstreamDequeued(wait time.Duration) {
+	if m == nil {
+		return
+	}
+	m.upstreamQueued.Dec()
+	m.upstreamWait.Observe(wait.Seconds())
+}
+
+// normalizeMethod bounds the method label to the standard HTTP methods.
+func normalizeMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions, http.MethodConnect, http.MethodTrace:
+		return method
+	}
+	return "OTHER"
+}
@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sj1815/golang-country-search/internal/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMetrics_NilSafe tests that a nil *Metrics can be used without checks.
+func TestMetrics_NilSafe(t *testing.T) {
+	var m *Metrics
+
+	assert.NotPanics(t, func() {
+		m.RequestStarted()
+		m.RequestFinished("/", http.MethodGet, http.StatusOK, time.Millisecond)
+		m.PanicRecovered("/")
+		m.RateLimited("/")
+		m.AuthResult("", "missing")
+		m.CacheHit()
+		m.CacheMiss()
+		m.ObserveCacheStats(func() cache.Stats { return cache.Stats{} })
+		m.UpstreamRequest(OutcomeSuccess, time.Millisecond)
+		m.UpstreamQueued()
+		m.UpstreamDequeued(time.Millisecond)
+	})
+	assert.Nil(t, m.Registry())
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+// TestMetrics_Exposition tests that service metrics appear in the scrape output.
+func TestMetrics_Exposition(t *testing.T) {
+	m := New()
+
+	m.RequestStarted()
+	m.RequestStarted()
+	m.RequestFinished("/api/countries/search", http.MethodGet, http.StatusOK, 20*time.Millisecond)
+	m.RequestFinished("", "BREW", http.StatusNotFound, time.Millisecond)
+	m.PanicRecovered("/api/countries/search")
+	m.RateLimited("/api/countries/search")
+	m.AuthResult("dashboard", "ok")
+	m.AuthResult("", "invalid")
+	m.CacheHit()
+	m.CacheHit()
+	m.CacheMiss()
+	m.ObserveCacheStats(func() cache.Stats {
+		return cache.Stats{Sets: 9, Evictions: 3, Expirations: 2, Entries: 7, Bytes: 4096}
+	})
+	m.UpstreamRequest(OutcomeNotFound, 300*time.Millisecond)
+	m.UpstreamQueued()
+	m.UpstreamQueued()
+	m.UpstreamDequeued(50 * time.Millisecond)
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+
+	assert.Contains(t, body, `country_search_http_requests_total{route="/api/countries/search",method="GET",status="200"} 1`)
+	assert.Contains(t, body, `country_search_http_requests_total{route="unmatched",method="OTHER",status="404"} 1`)
+	assert.Contains(t, body, `country_search_http_request_duration_seconds_bucket{route="/api/countries/search",method="GET",status="200",le="0.025"} 1`)
+	assert.Contains(t, body, "country_search_http_requests_in_flight 0")
+	assert.Contains(t, body, `country_search_http_panics_total{route="/api/countries/search"} 1`)
+	assert.Contains(t, body, `country_search_http_rate_limited_total{route="/api/countries/search"} 1`)
+	assert.Contains(t, body, `country_search_auth_requests_total{client="dashboard",result="ok"} 1`)
+	assert.Contains(t, body, `country_search_auth_requests_total{client="anonymous",result="invalid"} 1`)
+	assert.Contains(t, body, "country_search_cache_hits_total 2")
+	assert.Contains(t, body, "country_search_cache_misses_total 1")
+	assert.Contains(t, body, "country_search_cache_evictions_total 3")
+	assert.Contains(t, body, "country_search_cache_entries 7")
+	assert.Contains(t, body, "country_search_cache_memory_bytes 4096")
+	assert.Contains(t, body, "country_search_cache_sets_total 9")
+	assert.Contains(t, body, "country_search_cache_expirations_total 2")
+	assert.Contains(t, body, `country_search_upstream_requests_total{outcome="not_found"} 1`)
+	assert.Contains(t, body, `country_search_upstream_request_duration_seconds_count{outcome="not_found"} 1`)
+	assert.Contains(t, body, "country_search_upstream_queue_depth 1")
+	assert.Contains(t, body, "country_search_upstream_queue_wait_seconds_count 1")
+}
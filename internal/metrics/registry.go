@@ -0,0 +1,434 @@
+// Package metrics implements a small Prometheus-compatible metrics registry and the
+// metrics exported by the service.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ContentType is the content type of the Prometheus text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are latency histogram buckets in seconds.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// collector is a metric family that can write itself in the text exposition format.
+type collector interface {
+	write(w *bufio.Writer)
+}
+
+// Registry holds metric families and renders them in the Prometheus text format.
+type Registry struct {
+	mu         sync.Mutex
+	names      map[string]bool
+	collectors []collector
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+// register adds a collector, panicking on duplicate names as they indicate a programming error.
+func (r *Registry) register(name string, c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.names[name] {
+		panic(fmt.Sprintf("metrics: duplicate metric %q", name))
+	}
+	r.names[name] = true
+	r.collectors = append(r.collectors, c)
+}
+
+// WriteTo writes every registered metric to w in registration order.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	collectors := append([]collector(nil), r.collectors...)
+	r.mu.Unlock()
+
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, c := range collectors {
+		c.write(bw)
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// Handler serves the registry in the Prometheus text exposition format.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", ContentType)
+		_, _ = r.WriteTo(w)
+	})
+}
+
+// countingWriter counts bytes written to the wrapped writer.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// atomicFloat is a float64 updated with compare-and-swap.
+type atomicFloat struct {
+	bits atomic.Uint64
+}
+
+func (f *atomicFloat) Add(v float64) {
+	for {
+		old := f.bits.Load()
+		if f.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+func (f *atomicFloat) Set(v float64) {
+	f.bits.Store(math.Float64bits(v))
+}
+
+func (f *atomicFloat) Load() float64 {
+	return math.Float64frombits(f.bits.Load())
+}
+
+// family is the shared bookkeeping of a labelled metric family.
+type family[T any] struct {
+	name   string
+	help   string
+	typ    string
+	labels []string
+	newFn  func() *T
+
+	mu     sync.RWMutex
+	series map[string]*T
+	values map[string][]string
+}
+
+func newFamily[T any](name, help, typ string, labels []string, newFn func() *T) *family[T] {
+	return &family[T]{
+		name:   name,
+		help:   help,
+		typ:    typ,
+		labels: labels,
+		newFn:  newFn,
+		series: make(map[string]*T),
+		values: make(map[string][]string),
+	}
+}
+
+// with returns the series for the given label values, creating it on first use.
+func (f *family[T]) with(values []string) *T {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+
+	key := strings.Join(values, "\xff")
+
+	f.mu.RLock()
+	s, ok := f.series[key]
+	f.mu.RUnlock()
+	if ok {
+		return s
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if s, ok := f.series[key]; ok {
+		return s
+	}
+	s = f.newFn()
+	f.series[key] = s
+	f.values[key] = append([]string(nil), values...)
+	return s
+}
+
+// each calls fn for every series in a stable order.
+func (f *family[T]) each(fn func(labels string, s *T)) {
+	f.mu.RLock()
+	keys := make([]string, 0, len(f.series))
+	for k := range f.series {
+		keys = append(keys, k)
+	}
+	f.mu.RUnlock()
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		f.mu.RLock()
+		s, values := f.series[k], f.values[k]
+		f.mu.RUnlock()
+		fn(formatLabels(f.labels, values), s)
+	}
+}
+
+func (f *family[T]) writeHeader(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.typ)
+}
+
+// Counter is a monotonically increasing value.
+type Counter struct {
+	v atomicFloat
+}
+
+// Inc adds one to the counter.
+func (c *Counter) Inc() { c.v.Add(1) }
+
+// Add adds v, which must not be negative, to the counter.
+func (c *Counter) Add(v float64) {
+	if v < 0 {
+		return
+	}
+	c.v.Add(v)
+}
+
+// Value returns the current counter value.
+func (c *Counter) Value() float64 { return c.v.Load() }
+
+// CounterVec is a family of counters partitioned by label values.
+type CounterVec struct {
+	f *family[Counter]
+}
+
+// NewCounterVec registers a counter family with the given label names.
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	cv := &CounterVec{f: newFamily(name, help, "counter", labels, func() *Counter { return &Counter{} })}
+	r.register(name, cv)
+	return cv
+}
+
+// NewCounter registers a counter without labels.
+func (r *Registry) NewCounter(name, help string) *Counter {
+	return r.NewCounterVec(name, help).WithLabelValues()
+}
+
+// WithLabelValues returns the counter for the given label values.
+func (cv *CounterVec) WithLabelValues(values ...string) *Counter {
+	return cv.f.with(values)
+}
+
+func (cv *CounterVec) write(w *bufio.Writer) {
+	cv.f.writeHeader(w)
+	cv.f.each(func(labels string, c *Counter) {
+		writeSample(w, cv.f.name, labels, c.Value())
+	})
+}
+
+// Gauge is a value that can go up and down.
+type Gauge struct {
+	v atomicFloat
+}
+
+// Set sets the gauge to v.
+func (g *Gauge) Set(v float64) { g.v.Set(v) }
+
+// Inc adds one to the gauge.
+func (g *Gauge) Inc() { g.v.Add(1) }
+
+// Dec subtracts one from the gauge.
+func (g *Gauge) Dec() { g.v.Add(-1) }
+
+// Add adds v to the gauge.
+func (g *Gauge) Add(v float64) { g.v.Add(v) }
+
+// Value returns the current gauge value.
+func (g *Gauge) Value() float64 { return g.v.Load() }
+
+// GaugeVec is a family of gauges partitioned by label values.
+type GaugeVec struct {
+	f *family[Gauge]
+}
+
+// NewGaugeVec registers a gauge family with the given label names.
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	gv := &GaugeVec{f: newFamily(name, help, "gauge", labels, func() *Gauge { return &Gauge{} })}
+	r.register(name, gv)
+	return gv
+}
+
+// NewGauge registers a gauge without labels.
+func (r *Registry) NewGauge(name, help string) *Gauge {
+	return r.NewGaugeVec(name, help).WithLabelValues()
+}
+
+// WithLabelValues returns the gauge for the given label values.
+func (gv *GaugeVec) WithLabelValues(values ...string) *Gauge {
+	return gv.f.with(values)
+}
+
+func (gv *GaugeVec) write(w *bufio.Writer) {
+	gv.f.writeHeader(w)
+	gv.f.each(func(labels string, g *Gauge) {
+		writeSample(w, gv.f.name, labels, g.Value())
+	})
+}
+
+// funcMetric reports a value computed at scrape time.
+type funcMetric struct {
+	name string
+	help string
+	typ  string
+	fn   func() float64
+}
+
+// NewGaugeFunc registers a gauge whose value is read from fn on every scrape.
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	r.register(name, &funcMetric{name: name, help: help, typ: "gauge", fn: fn})
+}
+
+// NewCounterFunc registers a counter whose value is read from fn on every scrape.
+func (r *Registry) NewCounterFunc(name, help string, fn func() float64) {
+	r.register(name, &funcMetric{name: name, help: help, typ: "counter", fn: fn})
+}
+
+func (m *funcMetric) write(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", m.name, escapeHelp(m.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", m.name, m.typ)
+	writeSample(w, m.name, "", m.fn())
+}
+
+// Histogram counts observations into cumulative buckets.
+type Histogram struct {
+	upperBounds []float64
+	counts      []atomic.Uint64
+	count       atomic.Uint64
+	sum         atomicFloat
+}
+
+// Observe records a single observation.
+func (h *Histogram) Observe(v float64) {
+	i := sort.SearchFloat64s(h.upperBounds, v)
+	if i < len(h.counts) {
+		h.counts[i].Add(1)
+	}
+	h.count.Add(1)
+	h.sum.Add(v)
+}
+
+// Count returns the number of observations.
+func (h *Histogram) Count() uint64 { return h.count.Load() }
+
+// Sum returns the sum of all observations.
+func (h *Histogram) Sum() float64 { return h.sum.Load() }
+
+// HistogramVec is a family of histograms partitioned by label values.
+type HistogramVec struct {
+	f *family[Histogram]
+}
+
+// NewHistogramVec registers a histogram family. Nil buckets fall back to DefaultBuckets.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	bounds := append([]float64(nil), buckets...)
+	sort.Float64s(bounds)
+
+	hv := &HistogramVec{f: newFamily(name, help, "histogram", labels, func() *Histogram {
+		return &Histogram{upperBounds: bounds, counts: make([]atomic.Uint64, len(bounds))}
+	})}
+	r.register(name, hv)
+	return hv
+}
+
+// NewHistogram registers a histogram without labels.
+func (r *Registry) NewHistogram(name, help string, buckets []float64) *Histogram {
+	return r.NewHistogramVec(name, help, buckets).WithLabelValues()
+}
+
+// WithLabelValues returns the histogram for the given label values.
+func (hv *HistogramVec) WithLabelValues(values ...string) *Histogram {
+	return hv.f.with(values)
+}
+
+func (hv *HistogramVec) write(w *bufio.Writer) {
+	hv.f.writeHeader(w)
+	hv.f.each(func(labels string, h *Histogram) {
+		var cumulative uint64
+		for i, bound := range h.upperBounds {
+			cumulative += h.counts[i].Load()
+			writeSample(w, hv.f.name+"_bucket", appendLabel(labels, "le", formatFloat(bound)), float64(cumulative))
+		}
+		count := h.count.Load()
+		writeSample(w, hv.f.name+"_bucket", appendLabel(labels, "le", "+Inf"), float64(count))
+		writeSample(w, hv.f.name+"_sum", labels, h.sum.Load())
+		writeSample(w, hv.f.name+"_count", labels, float64(count))
+	})
+}
+
+// formatLabels renders label pairs as the inside of a {...} label set.
+func formatLabels(names, values []string) string {
+	var b strings.Builder
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(name)
+		b.WriteString(`="`)
+		b.WriteString(escapeLabelValue(values[i]))
+		b.WriteByte('"')
+	}
+	return b.String()
+}
+
+func appendLabel(labels, name, value string) string {
+	pair := name + `="` + escapeLabelValue(value) + `"`
+	if labels == "" {
+		return pair
+	}
+	return labels + "," + pair
+}
+
+func writeSample(w *bufio.Writer, name, labels string, value float64) {
+	w.WriteString(name)
+	if labels != "" {
+		w.WriteByte('{')
+		w.WriteString(labels)
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabelValue(v string) string { return labelEscaper.Replace(v) }
+
+func escapeHelp(v string) string { return helpEscaper.Replace(v) }
@@ -0,0 +1,151 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// render returns the text exposition of the registry.
+func render(t *testing.T, r *Registry) string {
+	t.Helper()
+
+	var b strings.Builder
+	_, err := r.WriteTo(&b)
+	require.NoError(t, err)
+	return b.String()
+}
+
+// TestRegistry_Counter tests counter exposition with labels in a stable order.
+func TestRegistry_Counter(t *testing.T) {
+	r := NewRegistry()
+	cv := r.NewCounterVec("requests_total", "Total requests.", "route", "status")
+
+	cv.WithLabelValues("/b", "200").Inc()
+	cv.WithLabelValues("/a", "404").Add(2)
+	cv.WithLabelValues("/a", "404").Add(-1)
+
+	expected := `# HELP requests_total Total requests.
+# TYPE requests_total counter
+requests_total{route="/a",status="404"} 2
+requests_total{route="/b",status="200"} 1
+`
+	assert.Equal(t, expected, render(t, r))
+}
+
+// TestRegistry_Gauge tests gauges and gauge functions.
+func TestRegistry_Gauge(t *testing.T) {
+	r := NewRegistry()
+	g := r.NewGauge("in_flight", "In flight.")
+	g.Inc()
+	g.Inc()
+	g.Dec()
+	r.NewGaugeFunc("entries", "Entries.", func() float64 { return 42 })
+
+	expected := `# HELP in_flight In flight.
+# TYPE in_flight gauge
+in_flight 1
+# HELP entries Entries.
+# TYPE entries gauge
+entries 42
+`
+	assert.Equal(t, expected, render(t, r))
+}
+
+// TestRegistry_Histogram tests cumulative bucket output.
+func TestRegistry_Histogram(t *testing.T) {
+	r := NewRegistry()
+	h := r.NewHistogramVec("latency_seconds", "Latency.", []float64{1, 0.1}, "outcome")
+
+	h.WithLabelValues("ok").Observe(0.05)
+	h.WithLabelValues("ok").Observe(0.1)
+	h.WithLabelValues("ok").Observe(0.5)
+	h.WithLabelValues("ok").Observe(3)
+
+	expected := `# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{outcome="ok",le="0.1"} 2
+latency_seconds_bucket{outcome="ok",le="1"} 3
+latency_seconds_bucket{outcome="ok",le="+Inf"} 4
+latency_seconds_sum{outcome="ok"} 3.65
+latency_seconds_count{outcome="ok"} 4
+`
+	assert.Equal(t, expected, render(t, r))
+}
+
+// TestRegistry_Escaping tests escaping of label values and help text.
+func TestRegistry_Escaping(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("c", "Line one\nline two \\", "v").WithLabelValues("a\"b\\c\nd").Inc()
+
+	out := render(t, r)
+	assert.Contains(t, out, `# HELP c Line one\nline two \\`)
+	assert.Contains(t, out, `c{v="a\"b\\c\nd"} 1`)
+}
+
+// TestRegistry_DuplicateName tests that registering the same name twice panics.
+func TestRegistry_DuplicateName(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("dup", "Duplicate.")
+
+	assert.Panics(t, func() { r.NewGauge("dup", "Duplicate.") })
+}
+
+// TestRegistry_WrongLabelCount tests that mismatched label values panic.
+func TestRegistry_WrongLabelCount(t *testing.T) {
+	r := NewRegistry()
+	cv := r.NewCounterVec("c", "C.", "a", "b")
+
+	assert.Panics(t, func() { cv.WithLabelValues("only-one") })
+}
+
+// TestRegistry_Handler tests the HTTP exposition endpoint.
+func TestRegistry_Handler(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("hits_total", "Hits.").Inc()
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, ContentType, rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), "hits_total 1")
+
+	rec = httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/metrics", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+// TestRegistry_Concurrent tests concurrent updates and scrapes.
+func TestRegistry_Concurrent(t *testing.T) {
+	r := NewRegistry()
+	cv := r.NewCounterVec("c", "C.", "k")
+	h := r.NewHistogram("h", "H.", nil)
+	var wg sync.WaitGroup
+
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			cv.WithLabelValues(string(rune('a' + i%5))).Inc()
+			h.Observe(float64(i) / 100)
+		}(i)
+		go func() {
+			defer wg.Done()
+			render(t, r)
+		}()
+	}
+	wg.Wait()
+
+	var total float64
+	for i := 0; i < 5; i++ {
+		total += cv.WithLabelValues(string(rune('a' + i))).Value()
+	}
+	assert.Equal(t, float64(50), total)
+	assert.Equal(t, uint64(50), h.Count())
+}
@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/sj1815/golang-country-search/internal/auth"
+	"github.com/sj1815/golang-country-search/internal/logging"
+)
+
+// Authentication outcomes passed to AuthOptions.OnResult.
+const (
+	AuthOK        = "ok"
+	AuthMissing   = "missing"
+	AuthInvalid   = "invalid"
+	AuthForbidden = "forbidden"
+)
+
+// AuthRealm is reported in WWW-Authenticate challenges.
+const AuthRealm = "country-search"
+
+// AuthOptions configures the Authenticate middleware.
+type AuthOptions struct {
+	// Authenticator identifies the client.
+	Authenticator auth.Authenticator
+	// Scopes lists the scopes a client needs, all of them, to reach the wrapped handler.
+	Scopes []string
+	// Logger receives an entry for every rejected request. Defaults to slog.Default().
+	Logger *slog.Logger
+	// OnResult, if set, is called with the client's metric label and the outcome. The label
+	// is the key ID for API keys, which the config bounds, the authentication method for
+	// other clients, whose IDs are unbounded, and empty when the client is unknown.
+	OnResult func(client, result string)
+}
+
+// Authenticate returns a middleware that requires an authenticated client holding every
+// scope in opts.Scopes. The client's identity is stored in the request context, where
+// auth.FromContext and the log handler pick it up. A request already authenticated by an
+// outer middleware is not authenticated again; only its scopes are checked.
+//
+// Missing or invalid credentials get a 401 problem response with a WWW-Authenticate
+// challenge; missing scopes get a 403.
+func Authenticate(opts AuthOptions) func(http.Handler) http.Handler {
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	report := func(client, result string) {
+		if opts.OnResult != nil {
+			opts.OnResult(client, result)
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := auth.FromContext(r.Context())
+			if id == nil {
+				var err error
+				id, err = opts.Authenticator.Authenticate(r)
+				if err != nil {
+					result, challenge := AuthInvalid, fmt.Sprintf(`Bearer realm=%q, error="invalid_token"`, AuthRealm)
+					if errors.Is(err, auth.ErrNoCredentials) {
+						result, challenge = AuthMissing, fmt.Sprintf("Bearer realm=%q", AuthRealm)
+					}
+					report("", result)
+					logger.InfoContext(r.Context(), "request not authenticated",
+						slog.String("path", r.URL.Path), slog.String("result", result), logging.Error(err))
+
+					w.Header().Set("WWW-Authenticate", challenge)
+					writeProblem(w, r, http.StatusUnauthorized, "valid credentials are required")
+					return
+				}
+				ctx := logging.WithAttrs(auth.NewContext(r.Context(), id), slog.String(logging.KeyClientID, id.ID))
+				r = r.WithContext(ctx)
+			}
+
+			for _, scope := range opts.Scopes {
+				if !id.HasScope(scope) {
+					report(metricClient(id), AuthForbidden)
+					logger.InfoContext(r.Context(), "request lacks required scope",
+						slog.String("path", r.URL.Path), slog.String("scope", scope))
+
+					w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm=%q, error="insufficient_scope", scope=%q`,
+						AuthRealm, strings.Join(opts.Scopes, " ")))
+					writeProblem(w, r, http.StatusForbidden, "missing required scope "+scope)
+					return
+				}
+			}
+
+			report(metricClient(id), AuthOK)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// metricClient returns the label AuthOptions.OnResult reports id under.
+func metricClient(id *auth.Identity) string {
+	if id.Method == auth.MethodAPIKey {
+		return id.ID
+	}
+	if id.Method == "" {
+		return "other"
+	}
+	return id.Method
+}
@@ -0,0 +1,161 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sj1815/golang-country-search/internal/auth"
+	"github.com/sj1815/golang-country-search/internal/logging"
+	"github.com/sj1815/golang-country-search/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKeyStore(t *testing.T) *auth.KeyStore {
+	t.Helper()
+	store, err := auth.NewKeyStore([]auth.APIKey{
+		{ID: "reader", Key: "reader-key", Scopes: []string{auth.ScopeCountriesRead}, Tier: "pro"},
+		{ID: "nobody", Key: "nobody-key"},
+	})
+	require.NoError(t, err)
+	return store
+}
+
+// TestAuthenticate tests the responses for missing, invalid, under-scoped and valid credentials.
+func TestAuthenticate(t *testing.T) {
+	type result struct{ client, result string }
+	var results []result
+
+	var seen *auth.Identity
+	h := Authenticate(AuthOptions{
+		Authenticator: newTestKeyStore(t),
+		Scopes:        []string{auth.ScopeCountriesRead},
+		Logger:        logging.Discard(),
+		OnResult:      func(client, r string) { results = append(results, result{client, r}) },
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = auth.FromContext(r.Context())
+	}))
+
+	tests := []struct {
+		name      string
+		key       string
+		status    int
+		challenge string
+	}{
+		{"missing", "", http.StatusUnauthorized, `Bearer realm="country-search"`},
+		{"invalid", "wrong", http.StatusUnauthorized, `Bearer realm="country-search", error="invalid_token"`},
+		{"missing scope", "nobody-key", http.StatusForbidden, `Bearer realm="country-search", error="insufficient_scope", scope="countries:read"`},
+		{"valid", "reader-key", http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/countries/search", nil)
+			if tt.key != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.key)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.status, rec.Code)
+			assert.Equal(t, tt.challenge, rec.Header().Get("WWW-Authenticate"))
+			if tt.status != http.StatusOK {
+				assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+			}
+		})
+	}
+
+	require.NotNil(t, seen)
+	assert.Equal(t, "reader", seen.ID)
+	assert.Equal(t, []result{
+		{"", AuthMissing}, {"", AuthInvalid}, {"nobody", AuthForbidden}, {"reader", AuthOK},
+	}, results)
+}
+
+// TestAuthenticate_AlreadyAuthenticated tests that an identity set by an outer middleware is reused.
+func TestAuthenticate_AlreadyAuthenticated(t *testing.T) {
+	outer := Authenticate(AuthOptions{Authenticator: newTestKeyStore(t), Logger: logging.Discard()})
+	inner := Authenticate(AuthOptions{
+		Authenticator: failingAuthenticator{},
+		Scopes:        []string{auth.ScopeCountriesRead},
+		Logger:        logging.Discard(),
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(auth.APIKeyHeader, "reader-key")
+	rec := httptest.NewRecorder()
+	outer(inner(okHandler())).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+// TestRateLimit_Tiers tests that authenticated clients are limited by ID using their tier.
+func TestRateLimit_Tiers(t *testing.T) {
+	h := Authenticate(AuthOptions{Authenticator: newTestKeyStore(t), Logger: logging.Discard()})(
+		RateLimit(RateLimitOptions{
+			Limiter: ratelimit.New(ratelimit.Limit{Rate: 1, Burst: 1}),
+			Tiers:   map[string]*ratelimit.Limiter{"pro": ratelimit.New(ratelimit.Limit{Rate: 1, Burst: 100})},
+			Logger:  logging.Discard(),
+		})(okHandler()))
+
+	send := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(auth.APIKeyHeader, key)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, "100", send("reader-key").Header().Get(HeaderRateLimitLimit))
+	assert.Equal(t, http.StatusOK, send("nobody-key").Code)
+	assert.Equal(t, http.StatusTooManyRequests, send("nobody-key").Code)
+}
+
+// TestAuthenticate_MetricClient tests that only API key IDs are reported as metric labels.
+func TestAuthenticate_MetricClient(t *testing.T) {
+	var clients []string
+	h := Authenticate(AuthOptions{
+		Authenticator: identityAuthenticator{ID: "user-1234", Method: auth.MethodJWT},
+		Logger:        logging.Discard(),
+		OnResult:      func(client, _ string) { clients = append(clients, client) },
+	})(okHandler())
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, []string{auth.MethodJWT}, clients)
+}
+
+type identityAuthenticator auth.Identity
+
+func (a identityAuthenticator) Authenticate(*http.Request) (*auth.Identity, error) {
+	id := auth.Identity(a)
+	return &id, nil
+}
+
+type failingAuthenticator struct{}
+
+func (failingAuthenticator) Authenticate(*http.Request) (*auth.Identity, error) {
+	return nil, auth.ErrInvalidCredentials
+}
+
+// TestAuthenticate_LogsClientID tests that records logged while serving an authenticated
+// request carry the client ID.
+func TestAuthenticate_LogsClientID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logging.New(&buf, logging.FormatJSON, slog.LevelInfo)
+	h := Authenticate(AuthOptions{Authenticator: newTestKeyStore(t), Logger: logging.Discard()})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			logger.InfoContext(r.Context(), "served")
+		}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/countries/search", nil)
+	req.Header.Set("Authorization", "Bearer reader-key")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "reader", entry[logging.KeyClientID])
+}
@@ -0,0 +1,353 @@
+// Package middleware provides HTTP middleware shared by the API routes.
+package middleware
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// DefaultCompressMinSize is the response size below which bodies are sent uncompressed.
+const DefaultCompressMinSize = 1024
+
+const (
+	encodingGzip    = "gzip"
+	encodingDeflate = "deflate"
+	encodingZstd    = "zstd"
+)
+
+// supportedEncodings lists the encodings the server can produce, in order of preference
+// when the client weighs several of them equally.
+var supportedEncodings = []string{encodingZstd, encodingGzip, encodingDeflate}
+
+// encoder is the common interface of the pooled compression writers.
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+var encoderPools = map[string]*sync.Pool{
+	encodingGzip: {New: func() interface{} {
+		return gzip.NewWriter(io.Discard)
+	}},
+	encodingDeflate: {New: func() interface{} {
+		w, _ := flate.NewWriter(io.Discard, flate.DefaultCompression)
+		return w
+	}},
+	encodingZstd: {New: func() interface{} {
+		w, _ := zstd.NewWriter(io.Discard, zstd.WithEncoderConcurrency(1))
+		return w
+	}},
+}
+
+// incompressibleTypes lists content types whose payloads are already compressed.
+var incompressibleTypes = map[string]bool{
+	"application/gzip":             true,
+	"application/x-gzip":           true,
+	"application/zip":              true,
+	"application/zstd":             true,
+	"application/x-bzip2":          true,
+	"application/x-xz":             true,
+	"application/x-7z-compressed":  true,
+	"application/x-rar-compressed": true,
+	"application/pdf":              true,
+	"font/woff":                    true,
+	"font/woff2":                   true,
+}
+
+// Compress returns a middleware that compresses response bodies with the best encoding
+// accepted by the client. Bodies smaller than minSize are sent as-is; a non-positive
+// minSize falls back to DefaultCompressMinSize.
+func Compress(minSize int) func(http.Handler) http.Handler {
+	if minSize <= 0 {
+		minSize = DefaultCompressMinSize
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			addVary(w.Header(), "Accept-Encoding")
+
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+			if encoding == "" || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{
+				ResponseWriter: w,
+				encoding:       encoding,
+				minSize:        minSize,
+			}
+			defer cw.close()
+
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// negotiateEncoding picks the supported encoding with the highest quality value in the
+// Accept-Encoding header. It returns an empty string when the response should not be encoded.
+func negotiateEncoding(header string) string {
+	if header == "" {
+		return ""
+	}
+
+	qualities := make(map[string]float64)
+	wildcard := -1.0
+
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		q := 1.0
+		if key, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(key) == "q" {
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+
+		if name == "*" {
+			wildcard = q
+			continue
+		}
+		qualities[name] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, encoding := range supportedEncodings {
+		q, ok := qualities[encoding]
+		if !ok {
+			q = wildcard
+		}
+		if q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+
+	return best
+}
+
+// addVary appends value to the Vary header unless it is already listed.
+func addVary(h http.Header, value string) {
+	for _, existing := range h.Values("Vary") {
+		for _, v := range strings.Split(existing, ",") {
+			v = strings.TrimSpace(v)
+			if v == "*" || strings.EqualFold(v, value) {
+				return
+			}
+		}
+	}
+	h.Add("Vary", value)
+}
+
+// compressWriter buffers the start of a response until it knows whether the body is worth
+// compressing, then streams the rest through a pooled encoder.
+type compressWriter struct {
+	http.ResponseWriter
+
+	encoding string
+	minSize  int
+
+	status      int
+	buf         []byte
+	decided     bool
+	wroteHeader bool
+	enc         encoder
+}
+
+// WriteHeader records the status code; the header is sent once the encoding is decided.
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.wroteHeader || cw.status != 0 {
+		return
+	}
+
+	// Informational responses are passed straight through.
+	if status >= 100 && status < 200 {
+		cw.ResponseWriter.WriteHeader(status)
+		return
+	}
+
+	cw.status = status
+	if !bodyAllowed(status) {
+		cw.decide(false)
+	}
+}
+
+// Write buffers data until minSize bytes are seen, then switches to compressed output.
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+
+	if !cw.decided {
+		cw.buf = append(cw.buf, p...)
+		if len(cw.buf) < cw.minSize {
+			return len(p), nil
+		}
+
+		if err := cw.start(); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+
+	if cw.enc != nil {
+		return cw.enc.Write(p)
+	}
+	return cw.ResponseWriter.Write(p)
+}
+
+// Flush sends any buffered data to the client, deciding the encoding early if needed.
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		if cw.status == 0 {
+			cw.status = http.StatusOK
+		}
+		if err := cw.start(); err != nil {
+			return
+		}
+	}
+
+	if cw.enc != nil {
+		_ = cw.enc.Flush()
+	}
+
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack lets protocol upgrades bypass compression entirely.
+func (cw *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := cw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	cw.decided = true
+	cw.wroteHeader = true
+	return hj.Hijack()
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// start decides the encoding based on what has been buffered and writes it out.
+func (cw *compressWriter) start() error {
+	cw.decide(len(cw.buf) >= cw.minSize && cw.compressible())
+
+	if len(cw.buf) == 0 {
+		return nil
+	}
+
+	buf := cw.buf
+	cw.buf = nil
+
+	var err error
+	if cw.enc != nil {
+		_, err = cw.enc.Write(buf)
+	} else {
+		_, err = cw.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// decide fixes the response headers, acquiring an encoder when compress is true.
+func (cw *compressWriter) decide(compress bool) {
+	if cw.decided {
+		return
+	}
+	cw.decided = true
+
+	h := cw.ResponseWriter.Header()
+	if compress {
+		h.Set("Content-Encoding", cw.encoding)
+		h.Del("Content-Length")
+
+		cw.enc = encoderPools[cw.encoding].Get().(encoder)
+		cw.enc.Reset(cw.ResponseWriter)
+	}
+
+	cw.wroteHeader = true
+	cw.ResponseWriter.WriteHeader(cw.status)
+}
+
+// compressible reports whether the buffered response is eligible for compression.
+func (cw *compressWriter) compressible() bool {
+	if !bodyAllowed(cw.status) {
+		return false
+	}
+
+	h := cw.ResponseWriter.Header()
+	if h.Get("Content-Encoding") != "" || h.Get("Content-Range") != "" {
+		return false
+	}
+
+	contentType := h.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(cw.buf)
+		h.Set("Content-Type", contentType)
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	switch {
+	case incompressibleTypes[mediaType]:
+		return false
+	case mediaType == "image/svg+xml":
+		return true
+	case strings.HasPrefix(mediaType, "image/"),
+		strings.HasPrefix(mediaType, "video/"),
+		strings.HasPrefix(mediaType, "audio/"):
+		return false
+	}
+
+	return true
+}
+
+// close flushes short responses uncompressed and returns the encoder to its pool.
+func (cw *compressWriter) close() {
+	if !cw.decided {
+		if cw.status == 0 {
+			cw.status = http.StatusOK
+		}
+
+		// The whole body fit below the threshold, so send it as-is.
+		cw.decide(false)
+		if len(cw.buf) > 0 {
+			_, _ = cw.ResponseWriter.Write(cw.buf)
+			cw.buf = nil
+		}
+	}
+
+	if cw.enc != nil {
+		_ = cw.enc.Close()
+		cw.enc.Reset(io.Discard)
+		encoderPools[cw.encoding].Put(cw.enc)
+		cw.enc = nil
+	}
+}
+
+// bodyAllowed reports whether a response with the given status may carry a body.
+func bodyAllowed(status int) bool {
+	return status != http.StatusNoContent && status != http.StatusNotModified
+}
@@ -0,0 +1,215 @@
+package middleware
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// largeBody is a compressible JSON payload comfortably above the default threshold.
+var largeBody = "[" + strings.Repeat(`{"name":"Germany","capital":"Berlin","currency":"€","population":83240525},`, 50) + "{}]"
+
+// serveCompressed runs handler behind the Compress middleware and returns the recorded response.
+func serveCompressed(t *testing.T, minSize int, acceptEncoding string, handler http.HandlerFunc) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/countries/search?name=Germany", nil)
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	rec := httptest.NewRecorder()
+
+	Compress(minSize)(handler).ServeHTTP(rec, req)
+
+	return rec
+}
+
+// jsonHandler writes body as a JSON response.
+func jsonHandler(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(body))
+	}
+}
+
+// decode decompresses a recorded body according to its Content-Encoding header.
+func decode(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+
+	var reader io.Reader
+	switch rec.Header().Get("Content-Encoding") {
+	case "gzip":
+		gz, err := gzip.NewReader(rec.Body)
+		require.NoError(t, err)
+		reader = gz
+	case "deflate":
+		reader = flate.NewReader(rec.Body)
+	case "zstd":
+		zr, err := zstd.NewReader(rec.Body)
+		require.NoError(t, err)
+		defer zr.Close()
+		reader = zr
+	default:
+		reader = rec.Body
+	}
+
+	data, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	return string(data)
+}
+
+// TestCompress_Encodings tests that each supported encoding round-trips the response body.
+func TestCompress_Encodings(t *testing.T) {
+	for _, encoding := range []string{"gzip", "deflate", "zstd"} {
+		t.Run(encoding, func(t *testing.T) {
+			rec := serveCompressed(t, 0, encoding, jsonHandler(largeBody))
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, encoding, rec.Header().Get("Content-Encoding"))
+			assert.Equal(t, "Accept-Encoding", rec.Header().Get("Vary"))
+			assert.Empty(t, rec.Header().Get("Content-Length"))
+			assert.Less(t, rec.Body.Len(), len(largeBody))
+			assert.Equal(t, largeBody, decode(t, rec))
+		})
+	}
+}
+
+// TestCompress_SmallBody tests that bodies below the minimum size are sent uncompressed.
+func TestCompress_SmallBody(t *testing.T) {
+	rec := serveCompressed(t, 0, "gzip", jsonHandler(`{"name":"India"}`))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", rec.Header().Get("Vary"))
+	assert.Equal(t, `{"name":"India"}`, rec.Body.String())
+}
+
+// TestCompress_CustomMinSize tests that the configured minimum size is honoured.
+func TestCompress_CustomMinSize(t *testing.T) {
+	rec := serveCompressed(t, 8, "gzip", jsonHandler(`{"name":"India"}`))
+
+	assert.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+	assert.Equal(t, `{"name":"India"}`, decode(t, rec))
+}
+
+// TestCompress_NoAcceptEncoding tests that clients without Accept-Encoding get identity responses.
+func TestCompress_NoAcceptEncoding(t *testing.T) {
+	rec := serveCompressed(t, 0, "", jsonHandler(largeBody))
+
+	assert.Empty(t, rec.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", rec.Header().Get("Vary"))
+	assert.Equal(t, largeBody, rec.Body.String())
+}
+
+// TestCompress_AlreadyCompressedContentType tests that compressed media types are passed through.
+func TestCompress_AlreadyCompressedContentType(t *testing.T) {
+	for _, contentType := range []string{"image/png", "application/zip", "video/mp4"} {
+		t.Run(contentType, func(t *testing.T) {
+			rec := serveCompressed(t, 0, "gzip", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", contentType)
+				w.Write([]byte(largeBody))
+			})
+
+			assert.Empty(t, rec.Header().Get("Content-Encoding"))
+			assert.Equal(t, largeBody, rec.Body.String())
+		})
+	}
+}
+
+// TestCompress_ExistingContentEncoding tests that handlers which encode their own body are left alone.
+func TestCompress_ExistingContentEncoding(t *testing.T) {
+	rec := serveCompressed(t, 0, "gzip", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "br")
+		w.Write([]byte(largeBody))
+	})
+
+	assert.Equal(t, "br", rec.Header().Get("Content-Encoding"))
+	assert.Equal(t, largeBody, rec.Body.String())
+}
+
+// TestCompress_NoContent tests that bodiless statuses are written unchanged.
+func TestCompress_NoContent(t *testing.T) {
+	rec := serveCompressed(t, 0, "gzip", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Empty(t, rec.Header().Get("Content-Encoding"))
+	assert.Zero(t, rec.Body.Len())
+}
+
+// TestCompress_PreservesStatus tests that error statuses survive buffering.
+func TestCompress_PreservesStatus(t *testing.T) {
+	rec := serveCompressed(t, 0, "gzip", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(largeBody))
+	})
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+	assert.Equal(t, largeBody, decode(t, rec))
+}
+
+// TestCompress_Flush tests that flushing a streaming response still produces a valid stream.
+func TestCompress_Flush(t *testing.T) {
+	rec := serveCompressed(t, 0, "gzip", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(largeBody))
+		w.(http.Flusher).Flush()
+		w.Write([]byte("tail"))
+	})
+
+	assert.True(t, rec.Flushed)
+	assert.Equal(t, largeBody+"tail", decode(t, rec))
+}
+
+// TestCompress_VaryNotDuplicated tests that an existing Vary entry is not repeated.
+func TestCompress_VaryNotDuplicated(t *testing.T) {
+	handler := jsonHandler(largeBody)
+	wrapped := Compress(0)(Compress(0)(handler))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	wrapped.ServeHTTP(rec, req)
+
+	assert.Equal(t, []string{"Accept-Encoding"}, rec.Header().Values("Vary"))
+}
+
+// TestNegotiateEncoding tests Accept-Encoding parsing and preference ordering.
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected string
+	}{
+		{"", ""},
+		{"identity", ""},
+		{"gzip", "gzip"},
+		{"deflate", "deflate"},
+		{"gzip, deflate, zstd", "zstd"},
+		{"gzip, deflate", "gzip"},
+		{"gzip;q=0.5, deflate;q=0.8", "deflate"},
+		{"zstd;q=0, gzip", "gzip"},
+		{"GZIP", "gzip"},
+		{"*", "zstd"},
+		{"*;q=0.1, gzip;q=0.5", "gzip"},
+		{"br", ""},
+		{"gzip;q=0", ""},
+		{"gzip;q=abc", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			assert.Equal(t, tt.expected, negotiateEncoding(tt.header))
+		})
+	}
+}
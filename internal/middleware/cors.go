@@ -0,0 +1,154 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSOptions configures the CORS middleware.
+type CORSOptions struct {
+	// AllowedOrigins lists the origins allowed to call the API, such as
+	// "https://dashboard.example.com". A "*" in an origin matches one or more characters of
+	// the host, so "https://*.example.com" allows every subdomain; "*" alone allows any origin.
+	AllowedOrigins []string
+	// AllowedMethods lists the methods allowed in cross-origin requests. Defaults to GET and HEAD.
+	AllowedMethods []string
+	// AllowedHeaders lists the request headers allowed in cross-origin requests, or "*" for any.
+	AllowedHeaders []string
+	// ExposedHeaders lists the response headers scripts may read beyond the safelisted ones.
+	ExposedHeaders []string
+	// AllowCredentials lets browsers send cookies and Authorization headers.
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight response. Zero leaves it to the browser.
+	MaxAge time.Duration
+}
+
+// originPattern matches an Origin header against a configured origin.
+type originPattern struct {
+	prefix, suffix string
+	wildcard       bool
+}
+
+func (p originPattern) match(origin string) bool {
+	if !p.wildcard {
+		return origin == p.prefix
+	}
+	if len(origin) <= len(p.prefix)+len(p.suffix) ||
+		!strings.HasPrefix(origin, p.prefix) || !strings.HasSuffix(origin, p.suffix) {
+		return false
+	}
+	// The wildcard stands for part of the host, never a path or another scheme.
+	return !strings.ContainsAny(origin[len(p.prefix):len(origin)-len(p.suffix)], "/:")
+}
+
+// CORS returns a middleware implementing Cross-Origin Resource Sharing. Preflight requests
+// are answered with 204 No Content without reaching the routes, so it must run before
+// authentication and rate limiting; only preflights from allowed origins for allowed methods
+// and headers get CORS headers. Other requests from allowed origins get the
+// Access-Control-Allow-Origin header; requests from other origins are passed through
+// untouched and left for the browser to block.
+func CORS(opts CORSOptions) func(http.Handler) http.Handler {
+	var patterns []originPattern
+	anyOrigin := false
+	for _, origin := range opts.AllowedOrigins {
+		origin = strings.ToLower(strings.TrimSpace(origin))
+		if origin == "*" {
+			anyOrigin = true
+			continue
+		}
+		prefix, suffix, wildcard := strings.Cut(origin, "*")
+		patterns = append(patterns, originPattern{prefix: prefix, suffix: suffix, wildcard: wildcard})
+	}
+
+	methods := opts.AllowedMethods
+	if len(methods) == 0 {
+		methods = []string{http.MethodGet, http.MethodHead}
+	}
+	allowedMethods := strings.Join(methods, ", ")
+
+	anyHeader := slices.Contains(opts.AllowedHeaders, "*")
+	allowedHeaders := make(map[string]bool, len(opts.AllowedHeaders))
+	for _, h := range opts.AllowedHeaders {
+		allowedHeaders[http.CanonicalHeaderKey(strings.TrimSpace(h))] = true
+	}
+
+	exposedHeaders := strings.Join(opts.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(opts.MaxAge.Seconds()))
+
+	allowed := func(origin string) bool {
+		if anyOrigin {
+			return true
+		}
+		origin = strings.ToLower(origin)
+		return slices.ContainsFunc(patterns, func(p originPattern) bool { return p.match(origin) })
+	}
+
+	headersAllowed := func(requested string) bool {
+		if anyHeader {
+			return true
+		}
+		for _, h := range strings.Split(requested, ",") {
+			if h = strings.TrimSpace(h); h != "" && !allowedHeaders[http.CanonicalHeaderKey(h)] {
+				return false
+			}
+		}
+		return true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			addVary(h, "Origin")
+
+			origin := r.Header.Get("Origin")
+			preflight := origin != "" && r.Method == http.MethodOptions &&
+				r.Header.Get("Access-Control-Request-Method") != ""
+			if !preflight && (origin == "" || !allowed(origin)) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// A credentialed response must name the origin; "*" is only valid without credentials.
+			allowOrigin := origin
+			if anyOrigin && !opts.AllowCredentials {
+				allowOrigin = "*"
+			}
+
+			if !preflight {
+				h.Set("Access-Control-Allow-Origin", allowOrigin)
+				if opts.AllowCredentials {
+					h.Set("Access-Control-Allow-Credentials", "true")
+				}
+				if exposedHeaders != "" {
+					h.Set("Access-Control-Expose-Headers", exposedHeaders)
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			addVary(h, "Access-Control-Request-Method")
+			addVary(h, "Access-Control-Request-Headers")
+
+			// A rejected preflight gets no CORS headers, which makes the browser block the request.
+			requestedHeaders := r.Header.Get("Access-Control-Request-Headers")
+			if allowed(origin) && slices.Contains(methods, r.Header.Get("Access-Control-Request-Method")) &&
+				headersAllowed(requestedHeaders) {
+				h.Set("Access-Control-Allow-Origin", allowOrigin)
+				h.Set("Access-Control-Allow-Methods", allowedMethods)
+				if requestedHeaders != "" {
+					h.Set("Access-Control-Allow-Headers", requestedHeaders)
+				}
+				if opts.AllowCredentials {
+					h.Set("Access-Control-Allow-Credentials", "true")
+				}
+				if opts.MaxAge > 0 {
+					h.Set("Access-Control-Max-Age", maxAge)
+				}
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
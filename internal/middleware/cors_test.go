@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newCORSHandler(opts CORSOptions) http.Handler {
+	return CORS(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func preflight(origin, method, headers string) *http.Request {
+	req := httptest.NewRequest(http.MethodOptions, "/api/countries/search", nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", method)
+	if headers != "" {
+		req.Header.Set("Access-Control-Request-Headers", headers)
+	}
+	return req
+}
+
+// TestCORS_Origins tests exact and wildcard origin matching on simple requests.
+func TestCORS_Origins(t *testing.T) {
+	h := newCORSHandler(CORSOptions{
+		AllowedOrigins: []string{"https://dashboard.example.com", "https://*.example.org"},
+		ExposedHeaders: []string{HeaderRateLimitRemaining},
+	})
+
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"https://dashboard.example.com", true},
+		{"https://DASHBOARD.example.com", true},
+		{"https://app.example.org", true},
+		{"https://a.b.example.org", true},
+		{"https://example.org", false},
+		{"https://evil.com/.example.org", false},
+		{"http://dashboard.example.com", false},
+		{"https://dashboard.example.com.evil.com", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/countries/search", nil)
+			req.Header.Set("Origin", tt.origin)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Contains(t, rec.Header().Values("Vary"), "Origin")
+			if tt.allowed {
+				assert.Equal(t, tt.origin, rec.Header().Get("Access-Control-Allow-Origin"))
+				assert.Equal(t, HeaderRateLimitRemaining, rec.Header().Get("Access-Control-Expose-Headers"))
+			} else {
+				assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+			}
+		})
+	}
+}
+
+// TestCORS_Preflight tests that preflights are answered without reaching the wrapped handler.
+func TestCORS_Preflight(t *testing.T) {
+	h := newCORSHandler(CORSOptions{
+		AllowedOrigins:   []string{"https://dashboard.example.com"},
+		AllowedHeaders:   []string{"Authorization", "X-API-Key"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	})
+
+	t.Run("allowed", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, preflight("https://dashboard.example.com", http.MethodGet, "authorization, x-api-key"))
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Equal(t, "https://dashboard.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "GET, HEAD", rec.Header().Get("Access-Control-Allow-Methods"))
+		assert.Equal(t, "authorization, x-api-key", rec.Header().Get("Access-Control-Allow-Headers"))
+		assert.Equal(t, "true", rec.Header().Get("Access-Control-Allow-Credentials"))
+		assert.Equal(t, "600", rec.Header().Get("Access-Control-Max-Age"))
+	})
+
+	for name, req := range map[string]*http.Request{
+		"disallowed origin": preflight("https://evil.example.com", http.MethodGet, ""),
+		"disallowed method": preflight("https://dashboard.example.com", http.MethodDelete, ""),
+		"disallowed header": preflight("https://dashboard.example.com", http.MethodGet, "X-Secret"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusNoContent, rec.Code)
+			assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+			assert.Empty(t, rec.Header().Get("Access-Control-Allow-Methods"))
+		})
+	}
+
+	t.Run("plain OPTIONS passes through", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodOptions, "/api/countries/search", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+}
+
+// TestCORS_AnyOrigin tests that "*" is sent as-is without credentials and echoed with them.
+func TestCORS_AnyOrigin(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Origin", "https://anywhere.example")
+
+	rec := httptest.NewRecorder()
+	newCORSHandler(CORSOptions{AllowedOrigins: []string{"*"}}).ServeHTTP(rec, req)
+	assert.Equal(t, "*", rec.Header().Get("Access-Control-Allow-Origin"))
+
+	rec = httptest.NewRecorder()
+	newCORSHandler(CORSOptions{AllowedOrigins: []string{"*"}, AllowCredentials: true}).ServeHTTP(rec, req)
+	assert.Equal(t, "https://anywhere.example", rec.Header().Get("Access-Control-Allow-Origin"))
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/sj1815/golang-country-search/internal/metrics"
+	"github.com/sj1815/golang-country-search/internal/router"
+)
+
+// Metrics returns a middleware that records request counts, latency and in-flight requests
+// per matched route and status code.
+func Metrics(m *metrics.Metrics) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := newResponseRecorder(w)
+
+			m.RequestStarted()
+			defer func() {
+				m.RequestFinished(router.Route(r), r.Method, rec.Status(), time.Since(start))
+			}()
+
+			next.ServeHTTP(rec, r)
+		})
+	}
+}
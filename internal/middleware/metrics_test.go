@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sj1815/golang-country-search/internal/metrics"
+	"github.com/sj1815/golang-country-search/internal/router"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMetrics_RecordsRoutesAndStatus tests that requests are counted by route pattern and status.
+func TestMetrics_RecordsRoutesAndStatus(t *testing.T) {
+	m := metrics.New()
+
+	handler := router.NewRouter(nil,
+		router.WithMiddleware(Metrics(m), Recover(RecoverOptions{OnPanic: m.PanicRecovered})),
+		router.WithRoute("GET /api/items/{id}", jsonHandler(`{}`)),
+		router.WithRoute("/api/panic", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		})),
+	)
+
+	for _, path := range []string{"/api/items/1", "/api/items/2", "/api/unknown", "/api/panic"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+
+	assert.Contains(t, body, `country_search_http_requests_total{route="GET /api/items/{id}",method="GET",status="200"} 2`)
+	assert.Contains(t, body, `country_search_http_requests_total{route="unmatched",method="GET",status="404"} 1`)
+	assert.Contains(t, body, `country_search_http_requests_total{route="/api/panic",method="GET",status="500"} 1`)
+	assert.Contains(t, body, `country_search_http_panics_total{route="/api/panic"} 1`)
+	assert.Contains(t, body, "country_search_http_requests_in_flight 0")
+}
@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sj1815/golang-country-search/internal/auth"
+	"github.com/sj1815/golang-country-search/internal/ratelimit"
+	"github.com/sj1815/golang-country-search/internal/router"
+)
+
+// Rate limit response headers, following the IETF RateLimit header fields draft.
+const (
+	HeaderRateLimitLimit     = "RateLimit-Limit"
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	HeaderRateLimitReset     = "RateLimit-Reset"
+)
+
+// RateLimitOptions configures the RateLimit middleware.
+type RateLimitOptions struct {
+	// Limiter holds the per-client token buckets.
+	Limiter *ratelimit.Limiter
+	// Tiers holds the limiters used instead of Limiter for authenticated clients in a tier.
+	Tiers map[string]*ratelimit.Limiter
+	// Key identifies unauthenticated clients. Defaults to the direct peer's IP address.
+	// Authenticated clients are always identified by their client ID.
+	Key ratelimit.KeyFunc
+	// Logger receives a debug entry for every rejected request. Defaults to slog.Default().
+	Logger *slog.Logger
+	// OnLimited, if set, is called with the matched route for every rejected request.
+	OnLimited func(route string)
+}
+
+// RateLimit returns a middleware that allows each client a token-bucket budget of requests.
+// Every response carries RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers;
+// requests over budget get a 429 problem response with Retry-After.
+//
+// Authenticated clients are limited by client ID, with their tier's limiter if they have
+// one, so RateLimit should run after Authenticate.
+func RateLimit(opts RateLimitOptions) func(http.Handler) http.Handler {
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	key := opts.Key
+	if key == nil {
+		key = ratelimit.KeyByIP(nil)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limiter := opts.Limiter
+			var client string
+			if id := auth.FromContext(r.Context()); id != nil {
+				client = "client:" + id.ID
+				if tier, ok := opts.Tiers[id.Tier]; ok {
+					limiter = tier
+				}
+			} else {
+				client = key(r)
+			}
+			res := limiter.Allow(client)
+
+			h := w.Header()
+			h.Set(HeaderRateLimitLimit, strconv.Itoa(res.Limit))
+			h.Set(HeaderRateLimitRemaining, strconv.Itoa(res.Remaining))
+			h.Set(HeaderRateLimitReset, strconv.Itoa(ceilSeconds(res.Reset)))
+
+			if res.Allowed {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			route := router.Route(r)
+			logger.DebugContext(r.Context(), "rate limit exceeded",
+				slog.String("route", route),
+				slog.Duration("retry_after", res.RetryAfter))
+
+			if opts.OnLimited != nil {
+				opts.OnLimited(route)
+			}
+
+			h.Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+			writeProblem(w, r, http.StatusTooManyRequests, "rate limit exceeded, retry after "+h.Get("Retry-After")+"s")
+		})
+	}
+}
+
+// ceilSeconds rounds d up to whole seconds, capped to keep header values sane.
+func ceilSeconds(d time.Duration) int {
+	const maxSeconds = 24 * 60 * 60
+	s := math.Ceil(d.Seconds())
+	if s > maxSeconds {
+		return maxSeconds
+	}
+	return int(s)
+}
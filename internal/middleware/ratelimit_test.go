@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/sj1815/golang-country-search/internal/logging"
+	"github.com/sj1815/golang-country-search/internal/model"
+	"github.com/sj1815/golang-country-search/internal/ratelimit"
+	"github.com/sj1815/golang-country-search/internal/router"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRateLimit_Headers tests that allowed responses carry the rate limit headers.
+func TestRateLimit_Headers(t *testing.T) {
+	limiter := ratelimit.New(ratelimit.Limit{Rate: 1, Burst: 5})
+	h := RateLimit(RateLimitOptions{Limiter: limiter, Logger: logging.Discard()})(okHandler())
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "5", rec.Header().Get(HeaderRateLimitLimit))
+	assert.Equal(t, "4", rec.Header().Get(HeaderRateLimitRemaining))
+	assert.Equal(t, "1", rec.Header().Get(HeaderRateLimitReset))
+	assert.Empty(t, rec.Header().Get("Retry-After"))
+}
+
+// TestRateLimit_TooManyRequests tests the 429 problem response once a client is over budget.
+func TestRateLimit_TooManyRequests(t *testing.T) {
+	var limitedRoutes []string
+	limiter := ratelimit.New(ratelimit.Limit{Rate: 0.5, Burst: 1})
+
+	handler := router.NewRouter(nil, router.WithRoute("/limited", okHandler(),
+		RateLimit(RateLimitOptions{
+			Limiter:   limiter,
+			Logger:    logging.Discard(),
+			OnLimited: func(route string) { limitedRoutes = append(limitedRoutes, route) },
+		})))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/limited", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/limited", nil))
+
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+	assert.Equal(t, "0", rec.Header().Get(HeaderRateLimitRemaining))
+	assert.Equal(t, []string{"/limited"}, limitedRoutes)
+
+	var problem model.ProblemDetails
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+	assert.Equal(t, http.StatusTooManyRequests, problem.Status)
+	assert.Equal(t, "Too Many Requests", problem.Title)
+	assert.Equal(t, "/limited", problem.Instance)
+}
+
+// TestRateLimit_KeyFunc tests that clients with different keys are limited separately.
+func TestRateLimit_KeyFunc(t *testing.T) {
+	limiter := ratelimit.New(ratelimit.Limit{Rate: 1, Burst: 1})
+	h := RateLimit(RateLimitOptions{
+		Limiter: limiter,
+		Key:     ratelimit.KeyByHeader("X-Client-ID", []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")}, ratelimit.KeyByIP(nil)),
+		Logger:  logging.Discard(),
+	})(okHandler())
+
+	send := func(client string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Client-ID", client)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, send("alpha"))
+	assert.Equal(t, http.StatusTooManyRequests, send("alpha"))
+	assert.Equal(t, http.StatusOK, send("beta"))
+}
+
+func okHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/sj1815/golang-country-search/internal/router"
+)
+
+// RecoverOptions configures the Recover middleware.
+type RecoverOptions struct {
+	// Logger receives the panic value and stack trace. Defaults to slog.Default().
+	Logger *slog.Logger
+	// OnPanic, if set, is called with the matched route after every recovered panic.
+	OnPanic func(route string)
+}
+
+// Recover returns a middleware that turns panics in downstream handlers into 500 problem
+// responses.
+//
+// A panic with http.ErrAbortHandler is re-raised untouched so net/http can abort the
+// connection quietly. If the response was already partially written, the panic is logged
+// and the connection is aborted, since the client can no longer be sent a clean error.
+func Recover(opts RecoverOptions) func(http.Handler) http.Handler {
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rec := newResponseRecorder(w)
+
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+
+				if err, ok := v.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+					panic(v)
+				}
+
+				route := router.Route(r)
+				if route == "" {
+					route = r.URL.Path
+				}
+
+				logger.ErrorContext(r.Context(), "panic recovered",
+					slog.String("method", r.Method),
+					slog.String("path", r.URL.Path),
+					slog.String("route", route),
+					slog.String("panic", fmt.Sprint(v)),
+					slog.String("stack", string(debug.Stack())))
+
+				if opts.OnPanic != nil {
+					opts.OnPanic(route)
+				}
+
+				if rec.wroteHeader {
+					panic(http.ErrAbortHandler)
+				}
+
+				writeProblem(rec, r, http.StatusInternalServerError, "internal server error")
+			}()
+
+			next.ServeHTTP(rec, r)
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sj1815/golang-country-search/internal/logging"
+	"github.com/sj1815/golang-country-search/internal/model"
+	"github.com/sj1815/golang-country-search/internal/router"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRecover_Panic tests that a panicking handler produces a 500 problem response and a logged stack trace.
+func TestRecover_Panic(t *testing.T) {
+	var logs bytes.Buffer
+	var panicRoutes []string
+
+	recoverer := Recover(RecoverOptions{
+		Logger:  logging.New(&logs, logging.FormatText, slog.LevelInfo),
+		OnPanic: func(route string) { panicRoutes = append(panicRoutes, route) },
+	})
+
+	panicking := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var country *struct{ Name string }
+		_ = country.Name
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/countries/search?name=Germany", nil)
+	req.Header.Set("X-Request-ID", "req-123")
+	rec := httptest.NewRecorder()
+
+	RequestID()(recoverer(panicking)).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+	var problem model.ProblemDetails
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+	assert.Equal(t, model.ProblemDetails{
+		Type:     "about:blank",
+		Title:    "Internal Server Error",
+		Status:   http.StatusInternalServerError,
+		Detail:   "internal server error",
+		Instance: "/api/countries/search",
+	}, problem)
+	assert.NotContains(t, rec.Body.String(), "nil pointer")
+
+	assert.Contains(t, logs.String(), "nil pointer dereference")
+	assert.Contains(t, logs.String(), "request_id=req-123")
+	assert.Contains(t, logs.String(), "route=/api/countries/search")
+	assert.Contains(t, logs.String(), "goroutine")
+	assert.Equal(t, []string{"/api/countries/search"}, panicRoutes)
+}
+
+// TestRecover_RoutePattern tests that the matched router pattern is reported.
+func TestRecover_RoutePattern(t *testing.T) {
+	var panicRoutes []string
+	recoverer := Recover(RecoverOptions{
+		Logger:  logging.Discard(),
+		OnPanic: func(route string) { panicRoutes = append(panicRoutes, route) },
+	})
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	rec := httptest.NewRecorder()
+	handler := router.NewRouter(nil, router.WithMiddleware(recoverer), router.WithRoute("GET /api/items/{id}", h))
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/items/42", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, []string{"GET /api/items/{id}"}, panicRoutes)
+}
+
+// TestRecover_NoPanic tests that normal responses pass through untouched.
+func TestRecover_NoPanic(t *testing.T) {
+	recoverer := Recover(RecoverOptions{})
+
+	rec := httptest.NewRecorder()
+	recoverer(jsonHandler(`{"name":"India"}`)).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `{"name":"India"}`, rec.Body.String())
+}
+
+// TestRecover_ErrAbortHandler tests that http.ErrAbortHandler is re-raised without logging.
+func TestRecover_ErrAbortHandler(t *testing.T) {
+	var logs bytes.Buffer
+	called := false
+	recoverer := Recover(RecoverOptions{
+		Logger:  logging.New(&logs, logging.FormatText, slog.LevelInfo),
+		OnPanic: func(string) { called = true },
+	})
+
+	aborting := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		recoverer(aborting).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+	assert.Empty(t, logs.String())
+	assert.False(t, called)
+}
+
+// TestRecover_AfterHeadersWritten tests that a panic mid-response aborts the connection.
+func TestRecover_AfterHeadersWritten(t *testing.T) {
+	var logs bytes.Buffer
+	recoverer := Recover(RecoverOptions{Logger: logging.New(&logs, logging.FormatText, slog.LevelInfo)})
+
+	partial := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"name":`))
+		panic("boom")
+	})
+
+	rec := httptest.NewRecorder()
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		recoverer(partial).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, logs.String(), "boom")
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/sj1815/golang-country-search/internal/requestid"
+)
+
+// RequestID returns a middleware that assigns every request an ID. A valid incoming
+// X-Request-ID header is reused, otherwise a new ID is generated. The ID is stored in the
+// request context and echoed in the response header.
+func RequestID() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(requestid.Header)
+			if !requestid.Valid(id) {
+				id = requestid.New()
+			}
+
+			w.Header().Set(requestid.Header, id)
+			next.ServeHTTP(w, r.WithContext(requestid.NewContext(r.Context(), id)))
+		})
+	}
+}
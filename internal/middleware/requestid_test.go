@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sj1815/golang-country-search/internal/requestid"
+	"github.com/stretchr/testify/assert"
+)
+
+// serveRequestID runs a request with the given X-Request-ID through the middleware and
+// returns the ID seen by the handler and the recorded response.
+func serveRequestID(incoming string) (string, *httptest.ResponseRecorder) {
+	var seen string
+	h := RequestID()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = requestid.FromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if incoming != "" {
+		req.Header.Set(requestid.Header, incoming)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	return seen, rec
+}
+
+// TestRequestID_AcceptsIncoming tests that a valid incoming ID is propagated and echoed.
+func TestRequestID_AcceptsIncoming(t *testing.T) {
+	seen, rec := serveRequestID("gateway-42")
+
+	assert.Equal(t, "gateway-42", seen)
+	assert.Equal(t, "gateway-42", rec.Header().Get(requestid.Header))
+}
+
+// TestRequestID_Generates tests that a missing ID is generated.
+func TestRequestID_Generates(t *testing.T) {
+	seen, rec := serveRequestID("")
+
+	assert.True(t, requestid.Valid(seen))
+	assert.Equal(t, seen, rec.Header().Get(requestid.Header))
+}
+
+// TestRequestID_ReplacesInvalid tests that unsafe incoming IDs are replaced.
+func TestRequestID_ReplacesInvalid(t *testing.T) {
+	seen, rec := serveRequestID("bad id\r\ninjected")
+
+	assert.NotContains(t, seen, "injected")
+	assert.True(t, requestid.Valid(seen))
+	assert.Equal(t, seen, rec.Header().Get(requestid.Header))
+}
@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"bufio"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"net/http"
+
+	"github.com/sj1815/golang-country-search/internal/logging"
+	"github.com/sj1815/golang-country-search/internal/model"
+)
+
+// responseRecorder wraps an http.ResponseWriter to remember the status code and body size.
+type responseRecorder struct {
+	http.ResponseWriter
+
+	status      int
+	written     int64
+	wroteHeader bool
+}
+
+// newResponseRecorder wraps w, reusing it if it is already a responseRecorder.
+func newResponseRecorder(w http.ResponseWriter) *responseRecorder {
+	if rec, ok := w.(*responseRecorder); ok {
+		return rec
+	}
+	return &responseRecorder{ResponseWriter: w}
+}
+
+// WriteHeader records the status code before forwarding it.
+func (rr *responseRecorder) WriteHeader(status int) {
+	if !rr.wroteHeader && status >= 200 {
+		rr.status = status
+		rr.wroteHeader = true
+	}
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+// Write records an implicit 200 status and the number of bytes written.
+func (rr *responseRecorder) Write(p []byte) (int, error) {
+	if !rr.wroteHeader {
+		rr.status = http.StatusOK
+		rr.wroteHeader = true
+	}
+	n, err := rr.ResponseWriter.Write(p)
+	rr.written += int64(n)
+	return n, err
+}
+
+// Status returns the response status code, defaulting to 200 when nothing was written.
+func (rr *responseRecorder) Status() int {
+	if rr.status == 0 {
+		return http.StatusOK
+	}
+	return rr.status
+}
+
+// Flush forwards to the underlying writer if it supports flushing.
+func (rr *responseRecorder) Flush() {
+	if f, ok := rr.ResponseWriter.(http.Flusher); ok {
+		if !rr.wroteHeader {
+			rr.status = http.StatusOK
+			rr.wroteHeader = true
+		}
+		f.Flush()
+	}
+}
+
+// Hijack forwards to the underlying writer if it supports hijacking.
+func (rr *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := rr.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	rr.wroteHeader = true
+	return hj.Hijack()
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (rr *responseRecorder) Unwrap() http.ResponseWriter {
+	return rr.ResponseWriter
+}
+
+// writeProblem writes an RFC 9457 problem response.
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(model.ProblemDetails{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.Path,
+	}); err != nil {
+		slog.Error("failed to encode response", logging.Error(err))
+	}
+}
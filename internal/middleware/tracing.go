@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/sj1815/golang-country-search/internal/requestid"
+	"github.com/sj1815/golang-country-search/internal/router"
+	"github.com/sj1815/golang-country-search/internal/tracing"
+)
+
+// Tracing returns a middleware that starts a server span for every request, continuing
+// the trace from an inbound W3C traceparent header when one is present.
+func Tracing(tp trace.TracerProvider) func(http.Handler) http.Handler {
+	tracer := tracing.Tracer(tp)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := tracing.Propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx, span := tracer.Start(ctx, "HTTP "+r.Method,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					attribute.String("http.request.method", r.Method),
+					attribute.String("url.path", r.URL.Path),
+				),
+			)
+			defer span.End()
+
+			if id := requestid.FromContext(ctx); id != "" {
+				span.SetAttributes(attribute.String("request_id", id))
+			}
+
+			rec := newResponseRecorder(w)
+			next.ServeHTTP(rec, r.WithContext(ctx))
+
+			if route := router.Route(r); route != "" {
+				span.SetName(spanName(r.Method, route))
+				span.SetAttributes(attribute.String("http.route", route))
+			}
+
+			status := rec.Status()
+			span.SetAttributes(attribute.Int("http.response.status_code", status))
+			if status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+		})
+	}
+}
+
+// spanName names a server span after its route, avoiding a duplicated method when the
+// route pattern already includes one.
+func spanName(method, route string) string {
+	if strings.Contains(route, " ") {
+		return route
+	}
+	return method + " " + route
+}
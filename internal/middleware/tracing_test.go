@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sj1815/golang-country-search/internal/router"
+	"github.com/sj1815/golang-country-search/internal/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TestTracing_ContinuesInboundTrace tests that a traceparent header becomes the server span's parent.
+func TestTracing_ContinuesInboundTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := tracing.NewProvider(recorder, tracing.Options{SampleRatio: 0})
+
+	var inner trace.SpanContext
+	handler := router.NewRouter(nil,
+		router.WithMiddleware(RequestID(), Tracing(tp)),
+		router.WithRoute("GET /api/items/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			inner = trace.SpanContextFromContext(r.Context())
+		})),
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/items/7", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1, "sampled parents are followed even with a zero ratio")
+
+	span := spans[0]
+	assert.Equal(t, "GET /api/items/{id}", span.Name())
+	assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+	assert.Equal(t, span.SpanContext().SpanID(), inner.SpanID())
+}
+
+// TestTracing_ServerError tests that 5xx responses mark the span as failed.
+func TestTracing_ServerError(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := tracing.NewProvider(recorder, tracing.Options{SampleRatio: 1})
+
+	handler := router.NewRouter(nil,
+		router.WithMiddleware(Tracing(tp), Recover(RecoverOptions{})),
+		router.WithRoute("/boom", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		})),
+	)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/boom", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	assert.Equal(t, "POST /boom", spans[0].Name())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "HTTP GET", spans[1].Name())
+	assert.Equal(t, codes.Unset, spans[1].Status().Code)
+}
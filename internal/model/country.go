@@ -1,19 +1,26 @@
 package model
 
+import "time"
+
 // Country represents the country information returned by the API.
 type Country struct {
 	Name       string `json:"name"`
 	Capital    string `json:"capital"`
 	Currency   string `json:"currency"`
 	Population int    `json:"population"`
+	// Code is the ISO 3166-1 alpha-3 code, the country's cache key.
+	Code string `json:"code,omitempty"`
 }
 
 // RESTCountryResponse represents the response structure from the REST Countries API.
 type RESTCountryResponse struct {
-	Name       CountryName             `json:"name"`
-	Capital    []string                `json:"capital"`
-	Currencies map[string]CurrencyInfo `json:"currencies"`
-	Population int                     `json:"population"`
+	Name         CountryName             `json:"name"`
+	CCA2         string                  `json:"cca2"`
+	CCA3         string                  `json:"cca3"`
+	AltSpellings []string                `json:"altSpellings"`
+	Capital      []string                `json:"capital"`
+	Currencies   map[string]CurrencyInfo `json:"currencies"`
+	Population   int                     `json:"population"`
 }
 
 // CountryName represents the name structure in the REST Countries API response.
@@ -33,3 +40,67 @@ type ErrorResponse struct {
 	Error   string `json:"error"`
 	Message string `json:"message,omitempty"`
 }
+
+// HealthResponse represents the body of the liveness and readiness endpoints.
+type HealthResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// CheckResult represents the outcome of a single readiness check.
+type CheckResult struct {
+	Status     string  `json:"status"`
+	Critical   bool    `json:"critical"`
+	Error      string  `json:"error,omitempty"`
+	DurationMS float64 `json:"duration_ms"`
+}
+
+// ProblemDetails represents an RFC 9457 problem response.
+type ProblemDetails struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// CacheEntry describes a cached value in the admin API.
+type CacheEntry struct {
+	Key        string    `json:"key"`
+	StoredAt   time.Time `json:"stored_at"`
+	AgeSeconds float64   `json:"age_seconds"`
+	// ExpiresAt and TTLSeconds are omitted when entries never expire.
+	ExpiresAt  *time.Time  `json:"expires_at,omitempty"`
+	TTLSeconds *float64    `json:"ttl_seconds,omitempty"`
+	Hits       int64       `json:"hits"`
+	Value      interface{} `json:"value,omitempty"`
+}
+
+// CacheEntriesResponse represents a listing of cache entries.
+type CacheEntriesResponse struct {
+	Count   int          `json:"count"`
+	Entries []CacheEntry `json:"entries"`
+}
+
+// CacheDeleteResponse reports how many entries an admin request removed.
+type CacheDeleteResponse struct {
+	Deleted int `json:"deleted"`
+}
+
+// CacheRefreshResponse reports a refresh of the whole cache that has started in the background.
+type CacheRefreshResponse struct {
+	// Keys is the number of entries being refreshed.
+	Keys int `json:"keys"`
+}
+
+// CacheStats reports the activity and size of the cache since the service started.
+type CacheStats struct {
+	Hits        int64   `json:"hits"`
+	Misses      int64   `json:"misses"`
+	HitRatio    float64 `json:"hit_ratio"`
+	Sets        int64   `json:"sets"`
+	Evictions   int64   `json:"evictions"`
+	Expirations int64   `json:"expirations"`
+	Entries     int     `json:"entries"`
+	MemoryBytes int64   `json:"memory_bytes"`
+}
@@ -0,0 +1,58 @@
+package peer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sj1815/golang-country-search/internal/logging"
+)
+
+// Resolver looks up DNS SRV records. *net.Resolver implements it.
+type Resolver interface {
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+// LookupSRV returns the base URLs of the peers listed in the SRV records of name, e.g.
+// "_http._tcp.country-search.default.svc.cluster.local", as "scheme://target:port".
+func LookupSRV(ctx context.Context, resolver Resolver, scheme, name string) ([]string, error) {
+	_, records, err := resolver.LookupSRV(ctx, "", "", name)
+	if err != nil {
+		return nil, fmt.Errorf("LookupSRV: %w", err)
+	}
+
+	peers := make([]string, 0, len(records))
+	for _, srv := range records {
+		host := strings.TrimSuffix(srv.Target, ".")
+		peers = append(peers, scheme+"://"+net.JoinHostPort(host, strconv.Itoa(int(srv.Port))))
+	}
+	return peers, nil
+}
+
+// Discover sets the pool's peers from lookup now and then every interval until ctx is
+// done. A failed or empty lookup keeps the previous peers, so a DNS outage does not
+// split the ring.
+func (p *Pool) Discover(ctx context.Context, interval time.Duration, lookup func(ctx context.Context) ([]string, error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		peers, err := lookup(ctx)
+		switch {
+		case err != nil:
+			p.logger.Warn("cache peer discovery failed", logging.Error(err))
+		case len(peers) == 0:
+			p.logger.Warn("cache peer discovery found no peers")
+		default:
+			p.Set(peers...)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package peer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeResolver returns fixed SRV records, or err.
+type fakeResolver struct {
+	records []*net.SRV
+	err     error
+}
+
+func (f *fakeResolver) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	return name, f.records, f.err
+}
+
+// TestLookupSRV tests converting SRV records to peer URLs.
+func TestLookupSRV(t *testing.T) {
+	resolver := &fakeResolver{records: []*net.SRV{
+		{Target: "pod-0.country-search.svc.", Port: 8000},
+		{Target: "pod-1.country-search.svc.", Port: 8000},
+	}}
+	peers, err := LookupSRV(context.Background(), resolver, "http", "_http._tcp.country-search.svc")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"http://pod-0.country-search.svc:8000", "http://pod-1.country-search.svc:8000"}, peers)
+
+	resolver.err = errors.New("no such host")
+	_, err = LookupSRV(context.Background(), resolver, "http", "_http._tcp.country-search.svc")
+	assert.ErrorContains(t, err, "no such host")
+}
+
+// TestPool_Discover tests that discovery sets the peers and keeps them when lookups fail
+// or find nothing.
+func TestPool_Discover(t *testing.T) {
+	pool := NewPool(PoolOptions{Self: "http://a:8000", Logger: slog.New(slog.NewTextHandler(io.Discard, nil))})
+
+	var lookups atomic.Int32
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go pool.Discover(ctx, time.Millisecond, func(ctx context.Context) ([]string, error) {
+		switch lookups.Add(1) {
+		case 1:
+			return []string{"http://a:8000", "http://b:8000"}, nil
+		case 2:
+			return nil, nil
+		default:
+			return nil, errors.New("lookup failed")
+		}
+	})
+
+	assert.Eventually(t, func() bool { return lookups.Load() > 3 }, time.Second, time.Millisecond)
+	assert.Equal(t, []string{"http://a:8000", "http://b:8000"}, pool.Peers())
+}
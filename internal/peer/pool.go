@@ -0,0 +1,257 @@
+package peer
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sj1815/golang-country-search/internal/client"
+	"github.com/sj1815/golang-country-search/internal/logging"
+	"github.com/sj1815/golang-country-search/internal/model"
+	"github.com/sj1815/golang-country-search/internal/requestid"
+	"github.com/sj1815/golang-country-search/internal/service"
+	"github.com/sj1815/golang-country-search/internal/tracing"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// BasePath is the route under which a Pool serves countries to its peers, followed by
+// the path-escaped cache key. GET looks the country up and POST refreshes it.
+const BasePath = "/_peer/countries/"
+
+// DefaultTimeout bounds each request to a peer by default.
+const DefaultTimeout = 2 * time.Second
+
+// SecretHeader carries the secret shared by the replicas on every peer request.
+const SecretHeader = "X-Peer-Secret"
+
+// Getter looks a country up on this replica, from its cache or upstream.
+type Getter func(ctx context.Context, key string) (*model.Country, error)
+
+// PoolOptions configures a Pool.
+type PoolOptions struct {
+	// Self is this replica's base URL exactly as the other replicas list it, e.g.
+	// "http://10.0.0.1:8000". It is always on the ring.
+	Self string
+	// Getter serves the keys this replica owns to its peers.
+	Getter Getter
+	// Refresher fetches the keys this replica owns from upstream again when a peer asks.
+	// Without it, peers cannot refresh through this replica.
+	Refresher Getter
+	// Secret is shared by every replica and sent with each peer request. Requests without
+	// it are rejected, so a Pool with no Secret serves nothing.
+	Secret string
+	// Replicas is the number of ring points per peer. Defaults to DefaultReplicas.
+	Replicas int
+	// Client sends requests to peers. Defaults to a client with a DefaultTimeout timeout.
+	Client *http.Client
+	// Logger reports peer list changes. Defaults to slog.Default().
+	Logger *slog.Logger
+}
+
+// Pool is the set of replicas sharing the cache. It picks the owner of each key for the
+// country service and, as an http.Handler mounted at BasePath, serves the keys this
+// replica owns to the others. It is safe for concurrent use.
+type Pool struct {
+	self      string
+	getter    Getter
+	refresher Getter
+	secret    string
+	replicas  int
+	client    *http.Client
+	logger    *slog.Logger
+
+	mu    sync.RWMutex
+	ring  *Ring
+	peers map[string]*httpPeer
+}
+
+var _ service.PeerPicker = (*Pool)(nil)
+
+// NewPool creates a pool containing only opts.Self; use Set to add the other replicas.
+func NewPool(opts PoolOptions) *Pool {
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: DefaultTimeout}
+	}
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+
+	p := &Pool{
+		self:      strings.TrimSuffix(opts.Self, "/"),
+		getter:    opts.Getter,
+		refresher: opts.Refresher,
+		secret:    opts.Secret,
+		replicas:  opts.Replicas,
+		client:    opts.Client,
+		logger:    opts.Logger,
+	}
+	p.Set()
+	return p
+}
+
+// Set replaces the replicas sharing the cache with peers, given as base URLs. This
+// replica is always included, whether or not it is listed.
+func (p *Pool) Set(peers ...string) {
+	ring := NewRing(p.replicas)
+	ring.Add(p.self)
+	httpPeers := make(map[string]*httpPeer, len(peers))
+	for _, peer := range peers {
+		peer = strings.TrimSuffix(peer, "/")
+		ring.Add(peer)
+		if peer != p.self {
+			httpPeers[peer] = &httpPeer{baseURL: peer, secret: p.secret, client: p.client}
+		}
+	}
+
+	p.mu.Lock()
+	changed := p.ring == nil || !slices.Equal(p.ring.Peers(), ring.Peers())
+	p.ring, p.peers = ring, httpPeers
+	p.mu.Unlock()
+
+	if changed && len(peers) > 0 {
+		p.logger.Info("cache peers updated", slog.Any("peers", ring.Peers()))
+	}
+}
+
+// Peers returns the base URLs of every replica sharing the cache, including this one, sorted.
+func (p *Pool) Peers() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.ring.Peers()
+}
+
+// PickPeer returns the peer owning key, or false when this replica owns it.
+func (p *Pool) PickPeer(key string) (service.Peer, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	owner := p.ring.Get(key)
+	if owner == p.self {
+		return nil, false
+	}
+	peer, ok := p.peers[owner]
+	return peer, ok
+}
+
+// ServeHTTP answers a peer's request for the country under the key following BasePath,
+// refreshing it first for POST requests. The lookup never leaves this replica, even if it
+// does not own the key by its own ring.
+// Requests must carry the shared secret in SecretHeader. Failed lookups are reported
+// without their cause, which is logged instead.
+func (p *Pool) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	secret := r.Header.Get(SecretHeader)
+	if p.secret == "" || subtle.ConstantTimeCompare([]byte(secret), []byte(p.secret)) != 1 {
+		writeError(w, http.StatusUnauthorized, "peer secret required")
+		return
+	}
+	get := p.getter
+	if r.Method == http.MethodPost {
+		get = p.refresher
+	}
+	if (r.Method != http.MethodGet && r.Method != http.MethodPost) || get == nil {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	key, ok := strings.CutPrefix(r.URL.Path, BasePath)
+	if !ok || key == "" {
+		writeError(w, http.StatusBadRequest, "key is required")
+		return
+	}
+
+	country, err := get(service.LocalOnly(r.Context()), key)
+	if err != nil {
+		p.logger.DebugContext(r.Context(), "peer lookup failed", slog.String("key", key), logging.Error(err))
+		switch {
+		case errors.Is(err, client.ErrNotFound):
+			writeError(w, http.StatusNotFound, "country not found")
+		case errors.Is(err, client.ErrThrottled):
+			writeError(w, http.StatusServiceUnavailable, "upstream busy")
+		default:
+			writeError(w, http.StatusBadGateway, "lookup failed")
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(country); err != nil {
+		p.logger.Error("failed to encode peer response", logging.Error(err))
+	}
+}
+
+// writeError writes an ErrorResponse with the specified status code and message.
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(model.ErrorResponse{Error: http.StatusText(status), Message: message})
+}
+
+// httpPeer fetches countries from another replica's Pool.
+type httpPeer struct {
+	baseURL string
+	secret  string
+	client  *http.Client
+}
+
+// Fetch implements service.Peer. Lookups that failed on the owner wrap
+// service.ErrPeerLookup, and client.ErrNotFound or client.ErrThrottled if that was why.
+func (h *httpPeer) Fetch(ctx context.Context, key string) (*model.Country, error) {
+	country, err := h.do(ctx, http.MethodGet, key)
+	if err != nil {
+		return nil, fmt.Errorf("Fetch: %w", err)
+	}
+	return country, nil
+}
+
+// Refresh implements service.Peer. Its errors wrap the same errors as Fetch's.
+func (h *httpPeer) Refresh(ctx context.Context, key string) (*model.Country, error) {
+	country, err := h.do(ctx, http.MethodPost, key)
+	if err != nil {
+		return nil, fmt.Errorf("Refresh: %w", err)
+	}
+	return country, nil
+}
+
+// do sends a peer request for key and decodes the country in the response.
+func (h *httpPeer) do(ctx context.Context, method, key string) (*model.Country, error) {
+	req, err := http.NewRequestWithContext(ctx, method, h.baseURL+BasePath+url.PathEscape(key), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(SecretHeader, h.secret)
+	if id := requestid.FromContext(ctx); id != "" {
+		req.Header.Set(requestid.Header, id)
+	}
+	tracing.Propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var country model.Country
+		if err := json.NewDecoder(resp.Body).Decode(&country); err != nil {
+			return nil, fmt.Errorf("failed to decode response from %s: %w", h.baseURL, err)
+		}
+		return &country, nil
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %w", service.ErrPeerLookup, client.ErrNotFound)
+	case http.StatusServiceUnavailable:
+		return nil, fmt.Errorf("%w: %w", service.ErrPeerLookup, client.ErrThrottled)
+	case http.StatusBadGateway:
+		var body model.ErrorResponse
+		_ = json.NewDecoder(resp.Body).Decode(&body)
+		return nil, fmt.Errorf("%w: %s", service.ErrPeerLookup, body.Message)
+	default:
+		return nil, fmt.Errorf("unexpected status %d from %s", resp.StatusCode, h.baseURL)
+	}
+}
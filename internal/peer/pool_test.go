@@ -0,0 +1,243 @@
+package peer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sj1815/golang-country-search/internal/cache"
+	"github.com/sj1815/golang-country-search/internal/client"
+	"github.com/sj1815/golang-country-search/internal/model"
+	"github.com/sj1815/golang-country-search/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeUpstream stands in for restcountries.com, counting the lookups of each name.
+type fakeUpstream struct {
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (f *fakeUpstream) SearchCountryByName(ctx context.Context, name string) ([]model.RESTCountryResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls[name]++
+	if name == "atlantis" {
+		return nil, nil
+	}
+	resp := model.RESTCountryResponse{Population: 1}
+	resp.Name.Common = strings.ToUpper(name[:1]) + name[1:]
+	return []model.RESTCountryResponse{resp}, nil
+}
+
+func (f *fakeUpstream) ListCountriesByCode(ctx context.Context, codes []string) ([]model.RESTCountryResponse, error) {
+	return nil, nil
+}
+
+func (f *fakeUpstream) ListAllCountries(ctx context.Context) ([]model.RESTCountryResponse, error) {
+	return nil, nil
+}
+
+func (f *fakeUpstream) Calls(name string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[name]
+}
+
+// replica is one in-process server with its own cache, upstream and pool.
+type replica struct {
+	server   *httptest.Server
+	pool     *Pool
+	service  service.CountryService
+	upstream *fakeUpstream
+}
+
+// startReplicas starts n replicas sharing their cache with each other.
+func startReplicas(t *testing.T, n int) []*replica {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	replicas := make([]*replica, n)
+	var urls []string
+	for i := range replicas {
+		r := &replica{upstream: &fakeUpstream{calls: make(map[string]int)}}
+		mux := http.NewServeMux()
+		r.server = httptest.NewServer(mux)
+		t.Cleanup(r.server.Close)
+
+		r.pool = NewPool(PoolOptions{
+			Self:   r.server.URL,
+			Secret: "shared",
+			Getter: func(ctx context.Context, key string) (*model.Country, error) {
+				return r.service.SearchCountry(ctx, key)
+			},
+			Refresher: func(ctx context.Context, key string) (*model.Country, error) {
+				return r.service.RefreshCountry(ctx, key)
+			},
+			Logger: logger,
+		})
+		r.service = service.NewCountryService(r.upstream, cache.NewInMemoryCache[string, *model.Country](),
+			service.WithLogger(logger), service.WithPeers(r.pool))
+		mux.Handle(BasePath, r.pool)
+
+		replicas[i] = r
+		urls = append(urls, r.server.URL)
+	}
+	for _, r := range replicas {
+		r.pool.Set(urls...)
+	}
+	return replicas
+}
+
+// owner returns the replica owning key.
+func owner(replicas []*replica, key string) *replica {
+	for _, r := range replicas {
+		if _, remote := r.pool.PickPeer(key); !remote {
+			return r
+		}
+	}
+	return nil
+}
+
+// TestPool tests that only the owner of a country calls upstream, whichever replica is asked.
+func TestPool(t *testing.T) {
+	replicas := startReplicas(t, 3)
+	ctx := context.Background()
+
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("country%d", i)
+		for _, r := range replicas {
+			country, err := r.service.SearchCountry(ctx, name)
+			require.NoError(t, err)
+			assert.Equal(t, "Country"+name[7:], country.Name)
+		}
+
+		owner := owner(replicas, name)
+		require.NotNil(t, owner)
+		for _, r := range replicas {
+			if r == owner {
+				assert.Equal(t, 1, r.upstream.Calls(name), "the owner calls upstream once")
+			} else {
+				assert.Zero(t, r.upstream.Calls(name), "non-owners never call upstream")
+			}
+		}
+	}
+}
+
+// TestPool_Refresh tests that refreshing a country on any replica makes only its owner call upstream.
+func TestPool_Refresh(t *testing.T) {
+	replicas := startReplicas(t, 2)
+	name := "country0"
+	for i := 1; owner(replicas, name) != replicas[1]; i++ {
+		name = fmt.Sprintf("country%d", i)
+	}
+
+	_, err := replicas[0].service.SearchCountry(context.Background(), name)
+	require.NoError(t, err)
+	country, err := replicas[0].service.RefreshCountry(context.Background(), name)
+	require.NoError(t, err)
+	assert.Equal(t, "Country"+name[7:], country.Name)
+
+	assert.Zero(t, replicas[0].upstream.Calls(name))
+	assert.Equal(t, 2, replicas[1].upstream.Calls(name), "the owner refreshed from upstream")
+}
+
+// TestPool_LookupFailure tests that a failed lookup on the owner is reported, not retried upstream.
+func TestPool_LookupFailure(t *testing.T) {
+	replicas := startReplicas(t, 2)
+	var asker *replica
+	for _, r := range replicas {
+		if _, remote := r.pool.PickPeer("atlantis"); remote {
+			asker = r
+		}
+	}
+	require.NotNil(t, asker)
+
+	_, err := asker.service.SearchCountry(context.Background(), "atlantis")
+	require.ErrorIs(t, err, service.ErrPeerLookup)
+	assert.ErrorIs(t, err, client.ErrNotFound)
+	assert.NotContains(t, err.Error(), "atlantis", "the owner does not reveal why the lookup failed")
+	assert.Zero(t, asker.upstream.Calls("atlantis"))
+}
+
+// TestPool_PeerDown tests that countries owned by an unreachable peer are looked up locally.
+func TestPool_PeerDown(t *testing.T) {
+	replicas := startReplicas(t, 2)
+	asker := replicas[0]
+	replicas[1].server.Close()
+
+	name := "country0"
+	for i := 1; owner(replicas, name) == asker; i++ {
+		name = fmt.Sprintf("country%d", i)
+	}
+
+	country, err := asker.service.SearchCountry(context.Background(), name)
+	require.NoError(t, err)
+	assert.Equal(t, "Country"+name[7:], country.Name)
+	assert.Equal(t, 1, asker.upstream.Calls(name))
+}
+
+// TestPool_ServeHTTP tests the peer endpoint and that the lookups it makes stay local.
+func TestPool_ServeHTTP(t *testing.T) {
+	var gotCtx context.Context
+	pool := NewPool(PoolOptions{
+		Self:   "http://self:8000",
+		Secret: "shared",
+		Getter: func(ctx context.Context, key string) (*model.Country, error) {
+			gotCtx = ctx
+			return &model.Country{Name: key}, nil
+		},
+	})
+	request := func(method, target, secret string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		if secret != "" {
+			req.Header.Set(SecretHeader, secret)
+		}
+		rec := httptest.NewRecorder()
+		pool.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, request(http.MethodGet, BasePath+"germany", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, request(http.MethodGet, BasePath+"germany", "wrong").Code)
+	assert.Nil(t, gotCtx, "unauthenticated requests never reach the getter")
+
+	rec := request(http.MethodGet, BasePath+"united%20kingdom", "shared")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"name":"united kingdom","capital":"","currency":"","population":0}`, rec.Body.String())
+
+	// The owner never forwards a peer's request, even if its own ring disagrees.
+	pool.Set("http://self:8000", "http://other:8000")
+	picker := &countingPicker{Pool: pool}
+	svc := service.NewCountryService(&fakeUpstream{calls: make(map[string]int)},
+		cache.NewInMemoryCache[string, *model.Country](), service.WithPeers(picker))
+	_, _ = svc.SearchCountry(gotCtx, "germany")
+	assert.Zero(t, picker.picks)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, request(http.MethodPost, BasePath+"germany", "shared").Code,
+		"a pool without a refresher serves no refreshes")
+	assert.Equal(t, http.StatusMethodNotAllowed, request(http.MethodDelete, BasePath+"germany", "shared").Code)
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodGet, BasePath, "shared").Code)
+
+	open := NewPool(PoolOptions{Self: "http://self:8000", Getter: pool.getter})
+	rec = httptest.NewRecorder()
+	open.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, BasePath+"germany", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code, "a pool without a secret serves nothing")
+}
+
+type countingPicker struct {
+	*Pool
+	picks int
+}
+
+func (p *countingPicker) PickPeer(key string) (service.Peer, bool) {
+	p.picks++
+	return p.Pool.PickPeer(key)
+}
@@ -0,0 +1,73 @@
+// Package peer shares the country cache among replicas: a consistent hash ring assigns
+// every key an owning replica, and the others fetch it from the owner over HTTP.
+package peer
+
+import (
+	"hash/crc32"
+	"slices"
+	"sort"
+	"strconv"
+)
+
+// DefaultReplicas is the number of points each peer gets on a Ring by default.
+const DefaultReplicas = 50
+
+// Ring is a consistent hash ring. Each peer is hashed onto it at several points, and a key
+// belongs to the peer at the first point after the key's hash, so adding or removing a
+// peer only moves the keys next to its points. Rings built from the same peers agree on
+// every owner regardless of the order the peers are given in. A Ring is not safe for
+// concurrent use; Pool replaces its ring rather than changing it.
+type Ring struct {
+	replicas int
+	hashes   []uint32
+	owners   map[uint32]string
+	peers    []string
+}
+
+// NewRing creates an empty ring placing each peer at replicas points. Zero or less uses
+// DefaultReplicas.
+func NewRing(replicas int) *Ring {
+	if replicas <= 0 {
+		replicas = DefaultReplicas
+	}
+	return &Ring{replicas: replicas, owners: make(map[uint32]string)}
+}
+
+// Add places peers on the ring. Peers already on it are ignored.
+func (r *Ring) Add(peers ...string) {
+	for _, peer := range peers {
+		if slices.Contains(r.peers, peer) {
+			continue
+		}
+		r.peers = append(r.peers, peer)
+		for i := 0; i < r.replicas; i++ {
+			h := crc32.ChecksumIEEE([]byte(strconv.Itoa(i) + peer))
+			// On a collision the lower peer wins, so every ring resolves it the same way.
+			if owner, ok := r.owners[h]; ok {
+				r.owners[h] = min(owner, peer)
+				continue
+			}
+			r.owners[h] = peer
+			r.hashes = append(r.hashes, h)
+		}
+	}
+	slices.Sort(r.hashes)
+}
+
+// Get returns the peer owning key, or "" when the ring is empty.
+func (r *Ring) Get(key string) string {
+	if len(r.hashes) == 0 {
+		return ""
+	}
+	h := crc32.ChecksumIEEE([]byte(key))
+	i := sort.Search(len(r.hashes), func(i int) bool { return r.hashes[i] >= h })
+	if i == len(r.hashes) {
+		i = 0
+	}
+	return r.owners[r.hashes[i]]
+}
+
+// Peers returns the peers on the ring, sorted.
+func (r *Ring) Peers() []string {
+	return slices.Sorted(slices.Values(r.peers))
+}
@@ -0,0 +1,50 @@
+package peer
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRing tests that every key has one owner, agreed on regardless of peer order.
+func TestRing(t *testing.T) {
+	assert.Equal(t, "", NewRing(0).Get("germany"), "an empty ring has no owners")
+
+	peers := []string{"http://a:8000", "http://b:8000", "http://c:8000"}
+	r1 := NewRing(0)
+	r1.Add(peers...)
+	r2 := NewRing(0)
+	r2.Add(peers[2], peers[0], peers[1], peers[0])
+	assert.Equal(t, peers, r2.Peers())
+
+	owned := make(map[string]int)
+	for i := 0; i < 3000; i++ {
+		key := fmt.Sprintf("country-%d", i)
+		owner := r1.Get(key)
+		assert.Equal(t, owner, r2.Get(key))
+		owned[owner]++
+	}
+	for _, peer := range peers {
+		assert.Greater(t, owned[peer], 500, "keys are spread over every peer")
+	}
+}
+
+// TestRing_AddPeer tests that a new peer only takes keys over, never moving them between
+// existing peers.
+func TestRing_AddPeer(t *testing.T) {
+	before := NewRing(0)
+	before.Add("http://a:8000", "http://b:8000", "http://c:8000")
+	after := NewRing(0)
+	after.Add("http://a:8000", "http://b:8000", "http://c:8000", "http://d:8000")
+
+	moved := 0
+	for i := 0; i < 3000; i++ {
+		key := fmt.Sprintf("country-%d", i)
+		if owner := after.Get(key); owner != before.Get(key) {
+			assert.Equal(t, "http://d:8000", owner)
+			moved++
+		}
+	}
+	assert.InDelta(t, 750, moved, 300, "about a quarter of the keys move")
+}
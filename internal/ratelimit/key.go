@@ -0,0 +1,118 @@
+package ratelimit
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// KeyFunc extracts the rate limiting key identifying the client that sent r.
+type KeyFunc func(r *http.Request) string
+
+// ParseTrustedProxies parses CIDR ranges or single IP addresses of proxies whose
+// X-Forwarded-For header is trusted.
+func ParseTrustedProxies(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if strings.Contains(v, "/") {
+			p, err := netip.ParsePrefix(v)
+			if err != nil {
+				return nil, fmt.Errorf("ParseTrustedProxies: invalid CIDR %q: %w", v, err)
+			}
+			prefixes = append(prefixes, p.Masked())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(v)
+		if err != nil {
+			return nil, fmt.Errorf("ParseTrustedProxies: invalid IP address %q: %w", v, err)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// ClientIP returns the address of the client that sent r. X-Forwarded-For is only
+// consulted when the direct peer is a trusted proxy; it is then read right to left and the
+// first address that is not a trusted proxy is the client.
+func ClientIP(r *http.Request, trusted []netip.Prefix) string {
+	host, peer, ok := directPeer(r, trusted)
+	if !ok {
+		return host
+	}
+
+	hops := forwardedFor(r)
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(hops[i])
+		if err != nil {
+			// A malformed hop cannot be attributed; stop at the last address we trust.
+			break
+		}
+		if !isTrusted(addr, trusted) {
+			return addr.Unmap().String()
+		}
+		peer = addr
+	}
+
+	// Every hop was a trusted proxy: the request originated inside the trusted network.
+	return peer.Unmap().String()
+}
+
+// KeyByIP keys requests by client IP, honouring X-Forwarded-For from trusted proxies.
+func KeyByIP(trusted []netip.Prefix) KeyFunc {
+	return func(r *http.Request) string {
+		return "ip:" + ClientIP(r, trusted)
+	}
+}
+
+// KeyByHeader keys requests by the value of header, falling back to fallback for requests
+// that do not send it. Clients could pick a new value for every request, so the header is
+// only believed when the direct peer is a trusted proxy, which is expected to set it.
+func KeyByHeader(header string, trusted []netip.Prefix, fallback KeyFunc) KeyFunc {
+	return func(r *http.Request) string {
+		if _, _, ok := directPeer(r, trusted); ok {
+			if v := strings.TrimSpace(r.Header.Get(header)); v != "" {
+				return "header:" + v
+			}
+		}
+		return fallback(r)
+	}
+}
+
+// directPeer returns the host and address of the peer that sent r and whether it is a
+// trusted proxy.
+func directPeer(r *http.Request, trusted []netip.Prefix) (string, netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	peer, err := netip.ParseAddr(host)
+	return host, peer, err == nil && isTrusted(peer, trusted)
+}
+
+// forwardedFor returns every address listed in the X-Forwarded-For headers, in order.
+func forwardedFor(r *http.Request) []string {
+	var hops []string
+	for _, line := range r.Header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(line, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	return hops
+}
+
+func isTrusted(addr netip.Addr, trusted []netip.Prefix) bool {
+	addr = addr.Unmap()
+	for _, p := range trusted {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
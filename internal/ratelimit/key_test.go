@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseTrustedProxies tests parsing CIDR ranges and single addresses.
+func TestParseTrustedProxies(t *testing.T) {
+	prefixes, err := ParseTrustedProxies([]string{"10.0.0.0/8", " 192.168.1.7 ", "::1"})
+	require.NoError(t, err)
+	require.Len(t, prefixes, 3)
+	assert.Equal(t, "10.0.0.0/8", prefixes[0].String())
+	assert.Equal(t, "192.168.1.7/32", prefixes[1].String())
+	assert.Equal(t, "::1/128", prefixes[2].String())
+
+	_, err = ParseTrustedProxies([]string{"10.0.0.0/40"})
+	assert.Error(t, err)
+	_, err = ParseTrustedProxies([]string{"proxy.internal"})
+	assert.Error(t, err)
+}
+
+// TestClientIP tests resolving the client address with and without trusted proxies.
+func TestClientIP(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		xff        []string
+		want       string
+	}{
+		{"direct client", "203.0.113.5:1234", nil, "203.0.113.5"},
+		{"untrusted peer cannot spoof", "203.0.113.5:1234", []string{"1.2.3.4"}, "203.0.113.5"},
+		{"trusted proxy", "10.0.0.1:80", []string{"198.51.100.9"}, "198.51.100.9"},
+		{"spoofed hop left of real client", "10.0.0.1:80", []string{"1.2.3.4, 198.51.100.9"}, "198.51.100.9"},
+		{"chain of trusted proxies", "10.0.0.1:80", []string{"198.51.100.9, 10.1.1.1", "10.2.2.2"}, "198.51.100.9"},
+		{"all hops trusted", "10.0.0.1:80", []string{"10.3.3.3"}, "10.3.3.3"},
+		{"malformed hop", "10.0.0.1:80", []string{"198.51.100.9, garbage"}, "10.0.0.1"},
+		{"ipv4-mapped ipv6", "[::ffff:10.0.0.1]:80", []string{"198.51.100.9"}, "198.51.100.9"},
+		{"no port", "203.0.113.5", nil, "203.0.113.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, v := range tt.xff {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			assert.Equal(t, tt.want, ClientIP(r, trusted))
+		})
+	}
+}
+
+// TestKeyByHeader tests keying by a header set by trusted proxies with a fallback to the client IP.
+func TestKeyByHeader(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+	key := KeyByHeader("X-Client-ID", trusted, KeyByIP(trusted))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Set("X-Forwarded-For", "203.0.113.5")
+	assert.Equal(t, "ip:203.0.113.5", key(r))
+
+	r.Header.Set("X-Client-ID", "service-a")
+	assert.Equal(t, "header:service-a", key(r))
+
+	r.RemoteAddr = "203.0.113.5:1234"
+	assert.Equal(t, "ip:203.0.113.5", key(r), "clients cannot choose their own key")
+}
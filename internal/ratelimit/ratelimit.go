@@ -0,0 +1,216 @@
+// Package ratelimit implements token-bucket rate limiting keyed by client.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+// DefaultIdleTimeout is how long a full, unused bucket is kept before it is dropped.
+const DefaultIdleTimeout = 10 * time.Minute
+
+// ErrWouldExceedDeadline is returned by Wait when the caller's deadline passes before its
+// turn would come.
+var ErrWouldExceedDeadline = errors.New("ratelimit: wait would exceed context deadline")
+
+// Limit is a token bucket that holds up to Burst tokens and refills at Rate tokens per second.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Result describes the outcome of taking a token from a bucket.
+type Result struct {
+	// Allowed reports whether a token was available.
+	Allowed bool
+	// Limit is the bucket capacity.
+	Limit int
+	// Remaining is the number of whole tokens left after this request.
+	Remaining int
+	// RetryAfter is how long until a token becomes available; zero when Allowed.
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+}
+
+// bucket is the state of a single client's token bucket.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// refill adds the tokens earned since the last update, capped at the burst size.
+func (b *bucket) refill(limit Limit, now time.Time) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*limit.Rate)
+	}
+	b.last = now
+}
+
+// Limiter keeps a token bucket per key. It is safe for concurrent use.
+type Limiter struct {
+	mu        sync.Mutex
+	limit     Limit
+	buckets   map[string]*bucket
+	idle      time.Duration
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// Option customises a Limiter.
+type Option func(*Limiter)
+
+// WithIdleTimeout sets how long a full bucket may go unused before it is dropped.
+func WithIdleTimeout(d time.Duration) Option {
+	return func(l *Limiter) {
+		if d > 0 {
+			l.idle = d
+		}
+	}
+}
+
+// New creates a Limiter applying limit to every key.
+func New(limit Limit, opts ...Option) *Limiter {
+	l := &Limiter{
+		limit:   limit,
+		buckets: make(map[string]*bucket),
+		idle:    DefaultIdleTimeout,
+		now:     time.Now,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	l.lastSweep = l.now()
+	return l
+}
+
+// Allow takes a token from key's bucket if one is available.
+func (l *Limiter) Allow(key string) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b := l.bucket(key, now)
+
+	res := Result{Limit: l.limit.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = l.until(1 - b.tokens)
+	}
+	res.Remaining = max(int(b.tokens), 0)
+	res.Reset = l.until(float64(l.limit.Burst) - b.tokens)
+
+	return res
+}
+
+// Wait blocks until key may proceed, taking its turn in line with other waiters. It returns
+// ErrWouldExceedDeadline straight away if ctx's deadline would pass first, or ctx's error if
+// ctx is done while waiting; in both cases the turn is given back.
+func (l *Limiter) Wait(ctx context.Context, key string) error {
+	l.mu.Lock()
+	now := l.now()
+	l.sweep(now)
+
+	// Reserve a token even if it pushes the bucket below zero; the debt is the queue ahead.
+	b := l.bucket(key, now)
+	b.tokens--
+	wait := l.until(-b.tokens)
+
+	if deadline, ok := ctx.Deadline(); ok && wait > 0 && now.Add(wait).After(deadline) {
+		b.tokens++
+		l.mu.Unlock()
+		return ErrWouldExceedDeadline
+	}
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.bucket(key, l.now()).tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// Limit returns the limit currently applied.
+func (l *Limiter) Limit() Limit {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit
+}
+
+// SetLimit changes the limit for every key, including buckets that already exist. Buckets
+// holding more tokens than the new burst size are trimmed.
+func (l *Limiter) SetLimit(limit Limit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	for _, b := range l.buckets {
+		b.refill(l.limit, now)
+		b.tokens = math.Min(b.tokens, float64(limit.Burst))
+	}
+	l.limit = limit
+}
+
+// Len returns the number of buckets being tracked.
+func (l *Limiter) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.buckets)
+}
+
+// bucket returns key's bucket refilled up to now, creating a full one if needed.
+func (l *Limiter) bucket(key string, now time.Time) *bucket {
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.limit.Burst), last: now}
+		l.buckets[key] = b
+	}
+	b.refill(l.limit, now)
+	return b
+}
+
+// until returns how long it takes to earn the given number of tokens.
+func (l *Limiter) until(tokens float64) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+	if l.limit.Rate <= 0 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(tokens / l.limit.Rate * float64(time.Second))
+}
+
+// sweep drops buckets that have been unused for the idle timeout and have refilled. Dropping
+// a full bucket loses nothing, since a new one starts full. It runs at most once per idle timeout.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.idle {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		idle := now.Sub(b.last)
+		b.refill(l.limit, now)
+		if idle >= l.idle && b.tokens >= float64(l.limit.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLimiter(limit Limit, opts ...Option) (*Limiter, *time.Time) {
+	now := time.Unix(1_700_000_000, 0)
+	l := New(limit, opts...)
+	l.now = func() time.Time { return now }
+	l.lastSweep = now
+	return l, &now
+}
+
+// TestLimiter_Burst tests that a client may spend its whole burst at once and is then limited.
+func TestLimiter_Burst(t *testing.T) {
+	l, _ := newTestLimiter(Limit{Rate: 1, Burst: 3})
+
+	for i := 2; i >= 0; i-- {
+		res := l.Allow("a")
+		assert.True(t, res.Allowed)
+		assert.Equal(t, 3, res.Limit)
+		assert.Equal(t, i, res.Remaining)
+	}
+
+	res := l.Allow("a")
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+	assert.Equal(t, time.Second, res.RetryAfter)
+	assert.Equal(t, 3*time.Second, res.Reset)
+}
+
+// TestLimiter_Refill tests that tokens are earned back at the configured rate.
+func TestLimiter_Refill(t *testing.T) {
+	l, now := newTestLimiter(Limit{Rate: 2, Burst: 2})
+
+	assert.True(t, l.Allow("a").Allowed)
+	assert.True(t, l.Allow("a").Allowed)
+	assert.False(t, l.Allow("a").Allowed)
+
+	*now = now.Add(500 * time.Millisecond)
+	assert.True(t, l.Allow("a").Allowed)
+	assert.False(t, l.Allow("a").Allowed)
+
+	*now = now.Add(time.Hour)
+	res := l.Allow("a")
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 1, res.Remaining, "tokens are capped at the burst size")
+}
+
+// TestLimiter_KeysAreIndependent tests that each key has its own bucket.
+func TestLimiter_KeysAreIndependent(t *testing.T) {
+	l, _ := newTestLimiter(Limit{Rate: 1, Burst: 1})
+
+	assert.True(t, l.Allow("a").Allowed)
+	assert.False(t, l.Allow("a").Allowed)
+	assert.True(t, l.Allow("b").Allowed)
+	assert.Equal(t, 2, l.Len())
+}
+
+// TestLimiter_SetLimit tests changing the limit of a running limiter.
+func TestLimiter_SetLimit(t *testing.T) {
+	l, _ := newTestLimiter(Limit{Rate: 1, Burst: 10})
+	assert.True(t, l.Allow("a").Allowed)
+
+	l.SetLimit(Limit{Rate: 1, Burst: 2})
+	assert.Equal(t, Limit{Rate: 1, Burst: 2}, l.Limit())
+
+	res := l.Allow("a")
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 2, res.Limit)
+	assert.Equal(t, 1, res.Remaining, "existing buckets are trimmed to the new burst")
+}
+
+// TestLimiter_IdleCleanup tests that full, unused buckets are dropped after the idle timeout.
+func TestLimiter_IdleCleanup(t *testing.T) {
+	l, now := newTestLimiter(Limit{Rate: 1, Burst: 1}, WithIdleTimeout(time.Minute))
+
+	l.Allow("a")
+	l.Allow("b")
+	assert.Equal(t, 2, l.Len())
+
+	*now = now.Add(30 * time.Second)
+	l.Allow("b")
+	assert.Equal(t, 2, l.Len())
+
+	*now = now.Add(45 * time.Second)
+	l.Allow("c")
+	assert.Equal(t, 2, l.Len(), "a was idle for the timeout, b was not")
+}
+
+// TestLimiter_ConcurrentAccess tests that concurrent callers never exceed the burst.
+func TestLimiter_ConcurrentAccess(t *testing.T) {
+	l, _ := newTestLimiter(Limit{Rate: 0.001, Burst: 50})
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		allowed int
+	)
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if l.Allow("shared").Allowed {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 50, allowed)
+}
+
+// TestLimiter_Wait tests that waiters are released at the configured rate.
+func TestLimiter_Wait(t *testing.T) {
+	l := New(Limit{Rate: 50, Burst: 1})
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, l.Wait(ctx, ""))
+	}
+
+	// The first call uses the burst; the next two wait 20ms each.
+	assert.GreaterOrEqual(t, time.Since(start), 35*time.Millisecond)
+}
+
+// TestLimiter_WaitDeadline tests that a caller whose deadline would pass is turned away at once.
+func TestLimiter_WaitDeadline(t *testing.T) {
+	l := New(Limit{Rate: 1, Burst: 1})
+	require.NoError(t, l.Wait(context.Background(), ""))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	assert.ErrorIs(t, l.Wait(ctx, ""), ErrWouldExceedDeadline)
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+}
+
+// TestLimiter_WaitCanceled tests that a canceled waiter gives its turn back.
+func TestLimiter_WaitCanceled(t *testing.T) {
+	l, now := newTestLimiter(Limit{Rate: 1, Burst: 1})
+	require.NoError(t, l.Wait(context.Background(), ""))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	assert.ErrorIs(t, l.Wait(ctx, ""), context.Canceled)
+
+	*now = now.Add(time.Second)
+	assert.True(t, l.Allow("").Allowed, "the canceled reservation no longer holds a token")
+}
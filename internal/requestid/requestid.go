@@ -0,0 +1,54 @@
+// Package requestid carries per-request correlation IDs through contexts and HTTP headers.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// Header is the HTTP header used to receive, echo and forward request IDs.
+const Header = "X-Request-ID"
+
+// MaxLength is the longest client-supplied request ID that is accepted.
+const MaxLength = 128
+
+type contextKey struct{}
+
+// New generates a random 128-bit request ID encoded as hex.
+func New() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// Valid reports whether id is safe to accept from a client: non-empty, at most MaxLength
+// characters and made only of letters, digits and the punctuation ".-_:".
+func Valid(id string) bool {
+	if id == "" || len(id) > MaxLength {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '.', c == '-', c == '_', c == ':':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// NewContext returns a copy of ctx carrying the request ID.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID stored in ctx, or an empty string if there is none.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
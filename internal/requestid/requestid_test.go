@@ -0,0 +1,49 @@
+package requestid
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNew tests that generated IDs are valid and unique.
+func TestNew(t *testing.T) {
+	a, b := New(), New()
+
+	assert.Len(t, a, 32)
+	assert.True(t, Valid(a))
+	assert.NotEqual(t, a, b)
+}
+
+// TestValid tests which client-supplied IDs are accepted.
+func TestValid(t *testing.T) {
+	tests := []struct {
+		id       string
+		expected bool
+	}{
+		{"abc-123", true},
+		{"0f8fad5b-d9cb-469f-a165-70867728950e", true},
+		{"gateway:req_1.2", true},
+		{"", false},
+		{"has space", false},
+		{"line\nbreak", false},
+		{"quote\"", false},
+		{strings.Repeat("a", MaxLength), true},
+		{strings.Repeat("a", MaxLength+1), false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, Valid(tt.id), tt.id)
+	}
+}
+
+// TestContext tests storing and retrieving IDs from a context.
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+	assert.Empty(t, FromContext(ctx))
+
+	ctx = NewContext(ctx, "req-1")
+	assert.Equal(t, "req-1", FromContext(ctx))
+}
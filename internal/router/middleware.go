@@ -0,0 +1,57 @@
+package router
+
+import (
+	"context"
+	"net/http"
+)
+
+// Middleware wraps an http.Handler with cross-cutting behaviour such as logging or auth.
+type Middleware func(http.Handler) http.Handler
+
+// Chain wraps h with the given middleware. The first middleware listed is the outermost,
+// so it sees the request first and the response last.
+func Chain(h http.Handler, middleware ...Middleware) http.Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		if middleware[i] != nil {
+			h = middleware[i](h)
+		}
+	}
+	return h
+}
+
+type routeKey struct{}
+
+// routeInfo is shared by every copy of a request so the matched pattern recorded deep in
+// the stack is visible to the middleware wrapping it.
+type routeInfo struct {
+	pattern string
+}
+
+// Route returns the pattern of the route that matched the request, or an empty string if
+// no route matched yet. Global middleware can call it once the wrapped handler has returned.
+func Route(r *http.Request) string {
+	if info, ok := r.Context().Value(routeKey{}).(*routeInfo); ok {
+		return info.pattern
+	}
+	return ""
+}
+
+// trackRoutes installs the shared route holder before any global middleware runs.
+func trackRoutes(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Value(routeKey{}).(*routeInfo); !ok {
+			r = r.WithContext(context.WithValue(r.Context(), routeKey{}, &routeInfo{}))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// recordRoute marks the request as matched by pattern.
+func recordRoute(pattern string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if info, ok := r.Context().Value(routeKey{}).(*routeInfo); ok {
+			info.pattern = pattern
+		}
+		next.ServeHTTP(w, r)
+	})
+}
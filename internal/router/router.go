@@ -6,12 +6,72 @@ import (
 	"github.com/sj1815/golang-country-search/internal/handler"
 )
 
+// CountrySearchRoute is the pattern of the country search endpoint.
+const CountrySearchRoute = "/api/countries/search"
+
+// route is a single registered endpoint with its own middleware.
+type route struct {
+	pattern    string
+	handler    http.Handler
+	middleware []Middleware
+}
+
+// options collects the settings applied by Option values.
+type options struct {
+	middleware      []Middleware
+	routes          []route
+	routeMiddleware map[string][]Middleware
+}
+
+// Option customises the router built by NewRouter.
+type Option func(*options)
+
+// WithMiddleware appends global middleware that wraps every request, including requests
+// that match no route. Middleware runs in the order it is added.
+func WithMiddleware(middleware ...Middleware) Option {
+	return func(o *options) {
+		o.middleware = append(o.middleware, middleware...)
+	}
+}
+
+// WithRoute registers an additional route with optional per-route middleware.
+func WithRoute(pattern string, h http.Handler, middleware ...Middleware) Option {
+	return func(o *options) {
+		o.routes = append(o.routes, route{pattern: pattern, handler: h, middleware: middleware})
+	}
+}
+
+// WithRouteMiddleware appends middleware to the route registered under pattern. It applies
+// to built-in routes as well as those added with WithRoute, and runs inside the global
+// middleware and after the route's own middleware.
+func WithRouteMiddleware(pattern string, middleware ...Middleware) Option {
+	return func(o *options) {
+		if o.routeMiddleware == nil {
+			o.routeMiddleware = make(map[string][]Middleware)
+		}
+		o.routeMiddleware[pattern] = append(o.routeMiddleware[pattern], middleware...)
+	}
+}
+
 // NewRouter sets up the HTTP routes for the application.
-func NewRouter(countryHandler *handler.CountryHandler) *http.ServeMux {
-	mux := http.NewServeMux()
+//
+// Requests pass through the global middleware first, in the order it was added, then
+// through the matched route's middleware, then reach the route handler.
+func NewRouter(countryHandler *handler.CountryHandler, opts ...Option) http.Handler {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	routes := append([]route{
+		{pattern: CountrySearchRoute, handler: http.HandlerFunc(countryHandler.SearchCountry)},
+	}, o.routes...)
 
-	mux.HandleFunc("/api/countries/search", countryHandler.SearchCountry)
-	// Additional routes can be added here
+	mux := http.NewServeMux()
+	for _, rt := range routes {
+		middleware := append(append([]Middleware{}, rt.middleware...), o.routeMiddleware[rt.pattern]...)
+		mux.Handle(rt.pattern, recordRoute(rt.pattern, Chain(rt.handler, middleware...)))
+	}
 
-	return mux
+	return trackRoutes(Chain(mux, o.middleware...))
 }
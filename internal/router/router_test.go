@@ -26,6 +26,15 @@ func (m *MockCountryService) SearchCountry(ctx context.Context, name string) (*m
 	return args.Get(0).(*model.Country), args.Error(1)
 }
 
+// RefreshCountry is a mock implementation of the RefreshCountry method.
+func (m *MockCountryService) RefreshCountry(ctx context.Context, name string) (*model.Country, error) {
+	args := m.Called(ctx, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Country), args.Error(1)
+}
+
 // TestNewRouter tests the NewRouter function.
 func TestNewRouter(t *testing.T) {
 	mockService := new(MockCountryService)
@@ -81,3 +90,116 @@ func TestRouter_UnknownRoute(t *testing.T) {
 
 	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
 }
+
+// recordingMiddleware returns middleware that appends name to calls before and after the wrapped handler.
+func recordingMiddleware(name string, calls *[]string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*calls = append(*calls, name+":before")
+			next.ServeHTTP(w, r)
+			*calls = append(*calls, name+":after")
+		})
+	}
+}
+
+// TestChain tests that middleware runs in the order it is listed.
+func TestChain(t *testing.T) {
+	var calls []string
+	final := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "handler")
+	})
+
+	h := Chain(final, recordingMiddleware("first", &calls), nil, recordingMiddleware("second", &calls))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, []string{"first:before", "second:before", "handler", "second:after", "first:after"}, calls)
+}
+
+// TestRouter_MiddlewareOrder tests that global middleware wraps per-route middleware deterministically.
+func TestRouter_MiddlewareOrder(t *testing.T) {
+	mockService := new(MockCountryService)
+	countryHandler := handler.NewCountryHandler(mockService)
+
+	var calls []string
+	final := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "handler")
+	})
+
+	router := NewRouter(countryHandler,
+		WithMiddleware(recordingMiddleware("global1", &calls)),
+		WithRoute("/extra", final, recordingMiddleware("route1", &calls)),
+		WithMiddleware(recordingMiddleware("global2", &calls)),
+		WithRouteMiddleware("/extra", recordingMiddleware("route2", &calls)),
+	)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/extra", nil))
+
+	assert.Equal(t, []string{
+		"global1:before", "global2:before", "route1:before", "route2:before",
+		"handler",
+		"route2:after", "route1:after", "global2:after", "global1:after",
+	}, calls)
+}
+
+// TestRouter_GlobalMiddlewareOnUnknownRoute tests that global middleware also sees unmatched requests.
+func TestRouter_GlobalMiddlewareOnUnknownRoute(t *testing.T) {
+	mockService := new(MockCountryService)
+	countryHandler := handler.NewCountryHandler(mockService)
+
+	var calls []string
+	router := NewRouter(countryHandler,
+		WithMiddleware(recordingMiddleware("global", &calls)),
+		WithRouteMiddleware(CountrySearchRoute, recordingMiddleware("route", &calls)),
+	)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/unknown", nil))
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, []string{"global:before", "global:after"}, calls)
+}
+
+// TestRouter_RouteMiddlewareOnBuiltInRoute tests that per-route middleware applies to the search route.
+func TestRouter_RouteMiddlewareOnBuiltInRoute(t *testing.T) {
+	mockService := new(MockCountryService)
+	countryHandler := handler.NewCountryHandler(mockService)
+
+	blocked := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		})
+	}
+
+	router := NewRouter(countryHandler, WithRouteMiddleware(CountrySearchRoute, blocked))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/countries/search?name=Germany", nil))
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	mockService.AssertNotCalled(t, "SearchCountry")
+}
+
+// TestRoute tests that global middleware can read the matched route pattern.
+func TestRoute(t *testing.T) {
+	mockService := new(MockCountryService)
+	countryHandler := handler.NewCountryHandler(mockService)
+	mockService.On("SearchCountry", mock.Anything, "Germany").Return(&model.Country{Name: "Germany"}, nil)
+
+	var seen []string
+	capture := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Cloning the request must not hide the route from this middleware.
+			next.ServeHTTP(w, r.WithContext(r.Context()))
+			seen = append(seen, Route(r))
+		})
+	}
+
+	router := NewRouter(countryHandler, WithMiddleware(capture))
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/countries/search?name=Germany", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/unknown", nil))
+
+	assert.Equal(t, []string{CountrySearchRoute, ""}, seen)
+	assert.Empty(t, Route(httptest.NewRequest(http.MethodGet, "/", nil)))
+}
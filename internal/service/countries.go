@@ -2,77 +2,283 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"strings"
+	"time"
 
 	"github.com/sj1815/golang-country-search/internal/cache"
 	"github.com/sj1815/golang-country-search/internal/client"
+	"github.com/sj1815/golang-country-search/internal/logging"
+	"github.com/sj1815/golang-country-search/internal/metrics"
 	"github.com/sj1815/golang-country-search/internal/model"
+	"github.com/sj1815/golang-country-search/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// ErrEmptyName is returned for search terms with nothing to search for.
+var ErrEmptyName = errors.New("country name cannot be empty")
+
 type CountryService interface {
 	SearchCountry(ctx context.Context, name string) (*model.Country, error)
+	// RefreshCountry fetches the country from upstream, bypassing the cache, and replaces
+	// any cached entry with the result.
+	RefreshCountry(ctx context.Context, name string) (*model.Country, error)
 }
 
 type countryService struct {
-	client client.CountryClient
-	cache  cache.Cache
+	client  client.CountryClient
+	cache   cache.Cache[string, *model.Country]
+	logger  *slog.Logger
+	metrics *metrics.Metrics
+	tracer  trace.Tracer
+	// peers is nil unless the cache is shared among replicas.
+	peers   PeerPicker
+	aliases *Aliases
+}
+
+// Option configures optional dependencies of the country service.
+type Option func(*countryService)
+
+// WithLogger sets the logger used by the service. Defaults to slog.Default().
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *countryService) {
+		s.logger = logger
+	}
+}
+
+// WithMetrics records cache hits and misses in m.
+func WithMetrics(m *metrics.Metrics) Option {
+	return func(s *countryService) {
+		s.metrics = m
+	}
+}
+
+// WithTracerProvider creates spans for searches and cache lookups from tp.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(s *countryService) {
+		s.tracer = tracing.Tracer(tp)
+	}
+}
+
+// WithAliases makes the service resolve search terms to cache keys with a, an index it
+// may share with Warmup. Defaults to a new index from NewAliases.
+func WithAliases(a *Aliases) Option {
+	return func(s *countryService) {
+		s.aliases = a
+	}
 }
 
 // NewCountryService creates a new instance of CountryService.
-func NewCountryService(client client.CountryClient, cache cache.Cache) CountryService {
-	return &countryService{
-		client: client,
-		cache:  cache,
+func NewCountryService(client client.CountryClient, cache cache.Cache[string, *model.Country], opts ...Option) CountryService {
+	s := &countryService{
+		client:  client,
+		cache:   cache,
+		logger:  slog.Default(),
+		tracer:  tracing.Tracer(nil),
+		aliases: NewAliases(),
+	}
+
+	for _, opt := range opts {
+		opt(s)
 	}
+
+	return s
 }
 
-// SearchCountry searches for a country by its name.
-func (s *countryService) SearchCountry(ctx context.Context, name string) (*model.Country, error) {
+// SearchCountry searches for a country by its name, alternative spelling or ISO 3166-1
+// code. Every spelling of a country shares one cache entry, keyed by its alpha-3 code.
+func (s *countryService) SearchCountry(ctx context.Context, name string) (country *model.Country, err error) {
+	ctx, span := s.tracer.Start(ctx, "CountryService.SearchCountry")
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
 	name = strings.TrimSpace(name)
-	if name == "" {
-		return nil, fmt.Errorf("SearchCountry: country name cannot be empty")
+	term := NormalizeName(name)
+	if term == "" {
+		return nil, fmt.Errorf("SearchCountry: %w", ErrEmptyName)
 	}
 
-	cacheKey := strings.ToLower(name)
+	cacheKey, resolved := s.cacheKey(term)
+	span.SetAttributes(attribute.String(logging.KeyCountry, cacheKey))
 
 	// Check cache first
-	if cached, found := s.cache.Get(cacheKey); found {
-		if country, ok := cached.(*model.Country); ok {
-			// Log cache hit
-			log.Printf("CACHE HIT: Found country in cache: %s", cacheKey)
-			return country, nil
+	if cached, ok := s.lookupCache(ctx, cacheKey); ok {
+		s.metrics.CacheHit()
+		s.logger.DebugContext(ctx, "country served from cache",
+			slog.String(logging.KeyCountry, cacheKey), slog.String(logging.KeyCacheResult, "hit"))
+		return cached, nil
+	}
+
+	s.metrics.CacheMiss()
+	s.logger.DebugContext(ctx, "country not in cache, calling upstream",
+		slog.String(logging.KeyCountry, cacheKey), slog.String(logging.KeyCacheResult, "miss"))
+
+	lookup := name
+	if resolved {
+		lookup = cacheKey
+	}
+	if country, ok, err := s.fetchFromPeer(ctx, term, cacheKey, lookup, false); ok {
+		if err != nil {
+			return nil, fmt.Errorf("SearchCountry: %w", err)
+		}
+		return country, nil
+	}
+
+	country, err = s.fetch(ctx, name, term, cacheKey, resolved)
+	if err != nil {
+		return nil, fmt.Errorf("SearchCountry: %w", err)
+	}
+	return country, nil
+}
+
+// RefreshCountry fetches a country from upstream and stores it in the cache. Countries
+// owned by another replica are refreshed by their owner, whose result is cached here too.
+func (s *countryService) RefreshCountry(ctx context.Context, name string) (country *model.Country, err error) {
+	ctx, span := s.tracer.Start(ctx, "CountryService.RefreshCountry")
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
 		}
+		span.End()
+	}()
+
+	name = strings.TrimSpace(name)
+	term := NormalizeName(name)
+	if term == "" {
+		return nil, fmt.Errorf("RefreshCountry: %w", ErrEmptyName)
 	}
 
-	// Log cache miss
-	log.Printf("CACHE MISS: Country not in cache, calling API: %s", cacheKey)
+	cacheKey, resolved := s.cacheKey(term)
+	span.SetAttributes(attribute.String(logging.KeyCountry, cacheKey))
+
+	lookup := name
+	if resolved {
+		lookup = cacheKey
+	}
+	if country, ok, err := s.fetchFromPeer(ctx, term, cacheKey, lookup, true); ok {
+		if err != nil {
+			return nil, fmt.Errorf("RefreshCountry: %w", err)
+		}
+		return country, nil
+	}
 
-	response, err := s.client.SearchCountryByName(ctx, name)
+	country, err = s.fetch(ctx, name, term, cacheKey, resolved)
 	if err != nil {
-		return nil, fmt.Errorf("SearchCountry: failed to search country by name: %w", err)
+		return nil, fmt.Errorf("RefreshCountry: %w", err)
+	}
+	return country, nil
+}
+
+// cacheKey returns the key the country named by the normalized term is cached under, and
+// whether it is the country's alpha-3 code. Terms not yet resolvable to a code are keyed
+// by themselves until the country is fetched.
+func (s *countryService) cacheKey(term string) (string, bool) {
+	if code, ok := s.aliases.Resolve(term); ok {
+		return code, true
+	}
+	return term, false
+}
+
+// fetchFromPeer asks the replica owning cacheKey to look the country up by lookup, or to
+// refresh it when refresh is set, caching the result locally. It reports false when this
+// replica owns the key or the owner is unreachable, in which case the country should be
+// looked up upstream.
+func (s *countryService) fetchFromPeer(ctx context.Context, term, cacheKey, lookup string, refresh bool) (*model.Country, bool, error) {
+	if s.peers == nil || isLocalOnly(ctx) {
+		return nil, false, nil
+	}
+	peer, ok := s.peers.PickPeer(cacheKey)
+	if !ok {
+		return nil, false, nil
+	}
+
+	start := time.Now()
+	fetch := peer.Fetch
+	if refresh {
+		fetch = peer.Refresh
+	}
+	country, err := fetch(ctx, lookup)
+	if errors.Is(err, ErrPeerLookup) {
+		return nil, true, err
+	}
+	if err != nil {
+		s.logger.WarnContext(ctx, "peer lookup failed, calling upstream",
+			slog.String(logging.KeyCountry, cacheKey), logging.Latency(time.Since(start)), logging.Error(err))
+		return nil, false, nil
+	}
+
+	if country.Code != "" {
+		s.aliases.Add(term, country.Code)
+		cacheKey = country.Code
+	}
+	s.cache.Set(cacheKey, country)
+	s.logger.DebugContext(ctx, "country fetched from peer",
+		slog.String(logging.KeyCountry, cacheKey), logging.Latency(time.Since(start)))
+	return country, true, nil
+}
+
+// fetch looks the country up upstream, by code when cacheKey is resolved and by name
+// otherwise, and caches it under its alpha-3 code. Codes upstream does not know are
+// searched for by name, since three-letter terms are only guessed to be codes.
+func (s *countryService) fetch(ctx context.Context, name, term, cacheKey string, resolved bool) (*model.Country, error) {
+	start := time.Now()
+	var response []model.RESTCountryResponse
+	var err error
+	if resolved {
+		response, err = s.client.ListCountriesByCode(ctx, []string{cacheKey})
+		if errors.Is(err, client.ErrNotFound) || (err == nil && len(response) == 0) {
+			response, err = s.client.SearchCountryByName(ctx, name)
+		}
+	} else {
+		response, err = s.client.SearchCountryByName(ctx, name)
+	}
+	if err != nil {
+		s.logger.WarnContext(ctx, "upstream lookup failed",
+			slog.String(logging.KeyCountry, cacheKey), logging.Latency(time.Since(start)), logging.Error(err))
+		return nil, fmt.Errorf("failed to search country by name: %w", err)
 	}
 
 	if len(response) == 0 {
-		return nil, fmt.Errorf("SearchCountry: no country data found for name: %s", name)
+		return nil, fmt.Errorf("no country data found for name %s: %w", name, client.ErrNotFound)
 	}
 
 	country := transformToCountry(response[0])
+	cacheKey = s.aliases.cacheKey(term, response[0])
 
 	// Store in cache for future requests
 	s.cache.Set(cacheKey, country)
-	// Log cache set operation
-	log.Printf("CACHE SET: Stored country in cache: %s", cacheKey)
+	s.logger.DebugContext(ctx, "country stored in cache",
+		slog.String(logging.KeyCountry, cacheKey), logging.Latency(time.Since(start)))
 
 	return country, nil
 }
 
+// lookupCache returns the cached country for key inside its own span.
+func (s *countryService) lookupCache(ctx context.Context, key string) (*model.Country, bool) {
+	_, span := s.tracer.Start(ctx, "cache.Get", trace.WithAttributes(attribute.String("cache.key", key)))
+	defer span.End()
+
+	country, found := s.cache.Get(key)
+	span.SetAttributes(attribute.Bool("cache.hit", found))
+	return country, found
+}
+
 // transformToCountry converts a RESTCountryResponse to a Country model.
 func transformToCountry(apiResp model.RESTCountryResponse) *model.Country {
 	country := &model.Country{
 		Name:       apiResp.Name.Common,
 		Population: apiResp.Population,
+		Code:       apiResp.CCA3,
 	}
 
 	if len(apiResp.Capital) > 0 {
@@ -1,13 +1,22 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"log/slog"
+	"strings"
 	"testing"
 
+	"github.com/sj1815/golang-country-search/internal/logging"
+	"github.com/sj1815/golang-country-search/internal/metrics"
 	"github.com/sj1815/golang-country-search/internal/model"
+	"github.com/sj1815/golang-country-search/internal/requestid"
+	"github.com/sj1815/golang-country-search/internal/tracing"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 // MockCache is a mock implementation of cache.Cache
@@ -16,16 +25,45 @@ type MockCache struct {
 }
 
 // Get is a mock implementation of the Get method.
-func (m *MockCache) Get(key string) (interface{}, bool) {
+func (m *MockCache) Get(key string) (*model.Country, bool) {
 	args := m.Called(key)
-	return args.Get(0), args.Bool(1)
+	if args.Get(0) == nil {
+		return nil, args.Bool(1)
+	}
+	return args.Get(0).(*model.Country), args.Bool(1)
 }
 
 // Set is a mock implementation of the Set method.
-func (m *MockCache) Set(key string, value interface{}) {
+func (m *MockCache) Set(key string, value *model.Country) {
 	m.Called(key, value)
 }
 
+// Delete is a mock implementation of the Delete method.
+func (m *MockCache) Delete(key string) bool {
+	args := m.Called(key)
+	return args.Bool(0)
+}
+
+// Keys is a mock implementation of the Keys method.
+func (m *MockCache) Keys() []string {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).([]string)
+}
+
+// Len is a mock implementation of the Len method.
+func (m *MockCache) Len() int {
+	args := m.Called()
+	return args.Int(0)
+}
+
+// Clear is a mock implementation of the Clear method.
+func (m *MockCache) Clear() {
+	m.Called()
+}
+
 // MockClient is a mock implementation of client.CountryClient
 type MockClient struct {
 	mock.Mock
@@ -40,6 +78,24 @@ func (m *MockClient) SearchCountryByName(ctx context.Context, name string) ([]mo
 	return args.Get(0).([]model.RESTCountryResponse), args.Error(1)
 }
 
+// ListCountriesByCode is a mock implementation of the ListCountriesByCode method.
+func (m *MockClient) ListCountriesByCode(ctx context.Context, codes []string) ([]model.RESTCountryResponse, error) {
+	args := m.Called(ctx, codes)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.RESTCountryResponse), args.Error(1)
+}
+
+// ListAllCountries is a mock implementation of the ListAllCountries method.
+func (m *MockClient) ListAllCountries(ctx context.Context) ([]model.RESTCountryResponse, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.RESTCountryResponse), args.Error(1)
+}
+
 // TestNewCountryService tests the NewCountryService function.
 func TestNewCountryService(t *testing.T) {
 	mockClient := new(MockClient)
@@ -288,3 +344,75 @@ func TestTransformToCountry(t *testing.T) {
 		})
 	}
 }
+
+// TestCountryService_SearchCountry_LogsCacheResult tests that cache lookups are logged at debug level with structured attributes.
+func TestCountryService_SearchCountry_LogsCacheResult(t *testing.T) {
+	mockClient := new(MockClient)
+	mockCache := new(MockCache)
+
+	mockCache.On("Get", "india").Return(&model.Country{Name: "India"}, true)
+
+	var logs bytes.Buffer
+	level := new(slog.LevelVar)
+	service := NewCountryService(mockClient, mockCache, WithLogger(logging.New(&logs, logging.FormatJSON, level)))
+	ctx := requestid.NewContext(context.Background(), "req-42")
+
+	_, err := service.SearchCountry(ctx, "India")
+	assert.NoError(t, err)
+	assert.Empty(t, logs.String(), "cache hits must not be logged at info level")
+
+	level.Set(slog.LevelDebug)
+	_, err = service.SearchCountry(ctx, "India")
+	assert.NoError(t, err)
+	assert.Contains(t, logs.String(), `"level":"DEBUG"`)
+	assert.Contains(t, logs.String(), `"cache_result":"hit"`)
+	assert.Contains(t, logs.String(), `"country":"india"`)
+	assert.Contains(t, logs.String(), `"request_id":"req-42"`)
+}
+
+// TestCountryService_SearchCountry_Metrics tests that cache hits and misses are counted.
+func TestCountryService_SearchCountry_Metrics(t *testing.T) {
+	mockClient := new(MockClient)
+	mockCache := new(MockCache)
+
+	mockCache.On("Get", "india").Return(&model.Country{Name: "India"}, true)
+	mockCache.On("Get", "unknown").Return(nil, false)
+	mockClient.On("SearchCountryByName", mock.Anything, "Unknown").Return(nil, errors.New("country not found"))
+
+	m := metrics.New()
+	service := NewCountryService(mockClient, mockCache, WithMetrics(m))
+	ctx := context.Background()
+
+	service.SearchCountry(ctx, "India")
+	service.SearchCountry(ctx, "India")
+	service.SearchCountry(ctx, "Unknown")
+
+	var out strings.Builder
+	m.Registry().WriteTo(&out)
+
+	assert.Contains(t, out.String(), "country_search_cache_hits_total 2")
+	assert.Contains(t, out.String(), "country_search_cache_misses_total 1")
+}
+
+// TestCountryService_SearchCountry_Tracing tests that the search and cache lookup are traced as parent and child spans.
+func TestCountryService_SearchCountry_Tracing(t *testing.T) {
+	mockClient := new(MockClient)
+	mockCache := new(MockCache)
+
+	mockCache.On("Get", "india").Return(&model.Country{Name: "India"}, true)
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := tracing.NewProvider(recorder, tracing.Options{SampleRatio: 1})
+	service := NewCountryService(mockClient, mockCache, WithTracerProvider(tp))
+
+	_, err := service.SearchCountry(context.Background(), "India")
+	assert.NoError(t, err)
+
+	spans := recorder.Ended()
+	if assert.Len(t, spans, 2) {
+		assert.Equal(t, "cache.Get", spans[0].Name())
+		assert.Equal(t, "CountryService.SearchCountry", spans[1].Name())
+		assert.Equal(t, spans[1].SpanContext().SpanID(), spans[0].Parent().SpanID())
+		assert.Contains(t, spans[0].Attributes(), attribute.Bool("cache.hit", true))
+	}
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/sj1815/golang-country-search/internal/model"
+)
+
+// NormalizeName folds a search term so that spellings differing only in case, accents,
+// punctuation or spacing compare equal: "Côte d’Ivoire", "cote d'ivoire" and
+// " COTE-D IVOIRE " all become "cote d ivoire". Compatibility characters are decomposed
+// (NFKD) and combining marks dropped; runs of anything but letters and digits become a
+// single space.
+func NormalizeName(name string) string {
+	var b strings.Builder
+	gap := false
+	for _, r := range norm.NFKD.String(name) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case unicode.IsLetter(r) || unicode.IsNumber(r):
+			if gap && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			gap = false
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			gap = true
+		}
+	}
+	return b.String()
+}
+
+// builtinAliases maps normalized names in common use, but unknown to the REST Countries
+// name search, to alpha-3 codes.
+var builtinAliases = map[string]string{
+	"america":                      "USA",
+	"britain":                      "GBR",
+	"burma":                        "MMR",
+	"cape verde":                   "CPV",
+	"czech republic":               "CZE",
+	"democratic republic of congo": "COD",
+	"dprk":                         "PRK",
+	"drc":                          "COD",
+	"east timor":                   "TLS",
+	"england":                      "GBR",
+	"great britain":                "GBR",
+	"holland":                      "NLD",
+	"ivory coast":                  "CIV",
+	"macedonia":                    "MKD",
+	"north korea":                  "PRK",
+	"russia":                       "RUS",
+	"south korea":                  "KOR",
+	"swaziland":                    "SWZ",
+	"turkey":                       "TUR",
+	"uae":                          "ARE",
+	"uk":                           "GBR",
+	"united states of america":     "USA",
+	"us":                           "USA",
+	"usa":                          "USA",
+	"vatican":                      "VAT",
+	"vatican city":                 "VAT",
+}
+
+// Aliases resolves normalized search terms to the alpha-3 code of the country they name.
+// It starts with a few built-in aliases and learns every country's names, alternative
+// spellings and codes as they are fetched. It is safe for concurrent use.
+type Aliases struct {
+	mu    sync.RWMutex
+	codes map[string]string
+}
+
+// NewAliases creates an index holding the built-in aliases.
+func NewAliases() *Aliases {
+	a := &Aliases{codes: make(map[string]string, len(builtinAliases))}
+	for alias, code := range builtinAliases {
+		a.codes[alias] = code
+	}
+	return a
+}
+
+// Resolve returns the alpha-3 code for the normalized term. Three-letter terms not known
+// as aliases are taken to be alpha-3 codes themselves, since no country is named with three
+// letters.
+func (a *Aliases) Resolve(term string) (string, bool) {
+	a.mu.RLock()
+	code, ok := a.codes[term]
+	a.mu.RUnlock()
+	if ok {
+		return code, true
+	}
+	if isAlpha3(term) {
+		return strings.ToUpper(term), true
+	}
+	return "", false
+}
+
+// Add makes the normalized term resolve to code. Empty terms and codes are ignored.
+func (a *Aliases) Add(term, code string) {
+	if term == "" || code == "" {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.codes[term] = code
+}
+
+// Learn adds the common and official names, alternative spellings and codes of country.
+// Countries without an alpha-3 code are ignored.
+func (a *Aliases) Learn(country model.RESTCountryResponse) {
+	if country.CCA3 == "" {
+		return
+	}
+	terms := append([]string{country.Name.Common, country.Name.Official, country.CCA2, country.CCA3},
+		country.AltSpellings...)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, term := range terms {
+		if term = NormalizeName(term); term != "" {
+			a.codes[term] = country.CCA3
+		}
+	}
+}
+
+// cacheKey learns the aliases of country, fetched for the normalized term, and returns
+// the key it is cached under: its alpha-3 code, or term when it has none.
+func (a *Aliases) cacheKey(term string, country model.RESTCountryResponse) string {
+	if country.CCA3 == "" {
+		return term
+	}
+	a.Learn(country)
+	a.Add(term, country.CCA3)
+	return country.CCA3
+}
+
+// isAlpha3 reports whether term has the form of an alpha-3 code.
+func isAlpha3(term string) bool {
+	if len(term) != 3 {
+		return false
+	}
+	for i := 0; i < len(term); i++ {
+		if term[i] < 'a' || term[i] > 'z' {
+			return false
+		}
+	}
+	return true
+}
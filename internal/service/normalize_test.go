@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/sj1815/golang-country-search/internal/cache"
+	"github.com/sj1815/golang-country-search/internal/client"
+	"github.com/sj1815/golang-country-search/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNormalizeName tests Unicode folding and punctuation and whitespace collapsing.
+func TestNormalizeName(t *testing.T) {
+	tests := map[string]string{
+		"Côte d'Ivoire":         "cote d ivoire",
+		"cote d’ivoire":         "cote d ivoire",
+		"  COTE-D  IVOIRE.  ":   "cote d ivoire",
+		"São Tomé and Príncipe": "sao tome and principe",
+		"Åland Islands":         "aland islands",
+		"ＪＡＰＡＮ":                 "japan",
+		"Guinea-Bissau":         "guinea bissau",
+		"日本":                    "日本",
+		" !? ":                  "",
+	}
+	for in, want := range tests {
+		assert.Equal(t, want, NormalizeName(in), in)
+	}
+}
+
+// TestAliases tests resolving built-in aliases, codes and learned names.
+func TestAliases(t *testing.T) {
+	a := NewAliases()
+
+	code, ok := a.Resolve("ivory coast")
+	assert.True(t, ok)
+	assert.Equal(t, "CIV", code)
+
+	code, ok = a.Resolve("deu")
+	assert.True(t, ok, "three letters are taken as an alpha-3 code")
+	assert.Equal(t, "DEU", code)
+
+	_, ok = a.Resolve("de")
+	assert.False(t, ok)
+	_, ok = a.Resolve("germany")
+	assert.False(t, ok)
+
+	a.Learn(model.RESTCountryResponse{
+		Name:         model.CountryName{Common: "Germany", Official: "Federal Republic of Germany"},
+		CCA2:         "DE",
+		CCA3:         "DEU",
+		AltSpellings: []string{"DE", "Deutschland"},
+	})
+	for _, term := range []string{"germany", "federal republic of germany", "de", "deutschland"} {
+		code, ok = a.Resolve(term)
+		assert.True(t, ok, term)
+		assert.Equal(t, "DEU", code, term)
+	}
+}
+
+// TestCountryService_SearchCountry_Aliases tests that every spelling of a country shares
+// one cache entry keyed by its alpha-3 code.
+func TestCountryService_SearchCountry_Aliases(t *testing.T) {
+	civ := model.RESTCountryResponse{
+		Name:         model.CountryName{Common: "Ivory Coast", Official: "Republic of Côte d'Ivoire"},
+		CCA2:         "CI",
+		CCA3:         "CIV",
+		AltSpellings: []string{"CI", "Côte d'Ivoire", "Cote d'Ivoire"},
+	}
+	mockClient := new(MockClient)
+	mockClient.On("SearchCountryByName", mock.Anything, "Côte d'Ivoire").
+		Return([]model.RESTCountryResponse{civ}, nil)
+	c := cache.NewInMemoryCache[string, *model.Country]()
+	service := NewCountryService(mockClient, c)
+
+	for _, name := range []string{"Côte d'Ivoire", "cote d'ivoire", "COTE D’IVOIRE", "Ivory Coast", "CIV", "ci"} {
+		country, err := service.SearchCountry(context.Background(), name)
+		require.NoError(t, err, name)
+		assert.Equal(t, "CIV", country.Code)
+	}
+	assert.Equal(t, []string{"CIV"}, c.Keys())
+	mockClient.AssertNumberOfCalls(t, "SearchCountryByName", 1)
+}
+
+// TestCountryService_SearchCountry_AliasByCode tests that names known to resolve to a code
+// are fetched by code.
+func TestCountryService_SearchCountry_AliasByCode(t *testing.T) {
+	mockClient := new(MockClient)
+	mockClient.On("ListCountriesByCode", mock.Anything, []string{"GBR"}).
+		Return([]model.RESTCountryResponse{{Name: model.CountryName{Common: "United Kingdom"}, CCA3: "GBR"}}, nil)
+	c := cache.NewInMemoryCache[string, *model.Country]()
+	service := NewCountryService(mockClient, c)
+
+	country, err := service.SearchCountry(context.Background(), "Great Britain")
+	require.NoError(t, err)
+	assert.Equal(t, "United Kingdom", country.Name)
+
+	_, err = service.SearchCountry(context.Background(), "united kingdom")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"GBR"}, c.Keys())
+	mockClient.AssertNumberOfCalls(t, "ListCountriesByCode", 1)
+	mockClient.AssertNotCalled(t, "SearchCountryByName", mock.Anything, mock.Anything)
+}
+
+// TestCountryService_SearchCountry_UnknownCode tests that three-letter terms upstream does
+// not know as codes are searched for by name.
+func TestCountryService_SearchCountry_UnknownCode(t *testing.T) {
+	mockClient := new(MockClient)
+	mockClient.On("ListCountriesByCode", mock.Anything, []string{"FIJ"}).
+		Return(nil, fmt.Errorf("ListCountriesByCode: %w", client.ErrNotFound))
+	mockClient.On("SearchCountryByName", mock.Anything, "fij").
+		Return([]model.RESTCountryResponse{{Name: model.CountryName{Common: "Fiji"}, CCA3: "FJI"}}, nil)
+	c := cache.NewInMemoryCache[string, *model.Country]()
+	service := NewCountryService(mockClient, c)
+
+	country, err := service.SearchCountry(context.Background(), "fij")
+	require.NoError(t, err)
+	assert.Equal(t, "Fiji", country.Name)
+	assert.Equal(t, []string{"FJI"}, c.Keys())
+
+	_, err = service.SearchCountry(context.Background(), "Fij")
+	require.NoError(t, err)
+	mockClient.AssertNumberOfCalls(t, "ListCountriesByCode", 1)
+	mockClient.AssertNumberOfCalls(t, "SearchCountryByName", 1)
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sj1815/golang-country-search/internal/model"
+)
+
+// ErrPeerLookup is wrapped by Peer errors reporting that the owning replica looked the
+// country up and failed, as opposed to the owner being unreachable. Such errors are
+// returned as they are; other peer errors fall back to looking the country up locally.
+var ErrPeerLookup = errors.New("lookup failed on owning peer")
+
+// Peer is another replica that can look countries up.
+type Peer interface {
+	// Fetch returns the country the peer holds, or looks up, under key.
+	Fetch(ctx context.Context, key string) (*model.Country, error)
+	// Refresh makes the peer fetch the country under key from upstream again and returns it.
+	Refresh(ctx context.Context, key string) (*model.Country, error)
+}
+
+// PeerPicker assigns every cache key a single owning replica.
+type PeerPicker interface {
+	// PickPeer returns the peer owning key, or false when this replica owns it.
+	PickPeer(key string) (Peer, bool)
+}
+
+// WithPeers makes the service fetch and refresh countries owned by other replicas through
+// their owner instead of upstream, so only the owner calls the REST Countries API for a
+// given key.
+func WithPeers(picker PeerPicker) Option {
+	return func(s *countryService) {
+		s.peers = picker
+	}
+}
+
+type localOnlyKey struct{}
+
+// LocalOnly returns a context whose searches never go to peers. Requests received from a
+// peer use it, so replicas that briefly disagree about ownership cannot forward a lookup
+// back and forth.
+func LocalOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, localOnlyKey{}, true)
+}
+
+// isLocalOnly reports whether ctx was returned by LocalOnly.
+func isLocalOnly(ctx context.Context) bool {
+	localOnly, _ := ctx.Value(localOnlyKey{}).(bool)
+	return localOnly
+}
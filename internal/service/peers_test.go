@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/sj1815/golang-country-search/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// peerFunc is a Peer backed by a function answering fetches and refreshes alike.
+type peerFunc func(ctx context.Context, key string) (*model.Country, error)
+
+func (f peerFunc) Fetch(ctx context.Context, key string) (*model.Country, error) {
+	return f(ctx, key)
+}
+
+func (f peerFunc) Refresh(ctx context.Context, key string) (*model.Country, error) {
+	return f(ctx, key)
+}
+
+// remotePicker assigns every key to its peer.
+type remotePicker struct {
+	peer Peer
+}
+
+func (p remotePicker) PickPeer(key string) (Peer, bool) {
+	return p.peer, true
+}
+
+// TestCountryService_SearchCountry_Peer tests that cache misses are fetched from the owning peer.
+func TestCountryService_SearchCountry_Peer(t *testing.T) {
+	mockClient := new(MockClient)
+	mockCache := new(MockCache)
+	germany := &model.Country{Name: "Germany"}
+
+	mockCache.On("Get", "germany").Return(nil, false)
+	mockCache.On("Set", "germany", germany).Return()
+
+	service := NewCountryService(mockClient, mockCache, WithPeers(remotePicker{peerFunc(
+		func(ctx context.Context, key string) (*model.Country, error) {
+			assert.Equal(t, "Germany", key, "unresolved names are looked up as given")
+			return germany, nil
+		})}))
+
+	country, err := service.SearchCountry(context.Background(), "Germany")
+
+	assert.NoError(t, err)
+	assert.Equal(t, germany, country)
+	mockCache.AssertExpectations(t)
+	mockClient.AssertNotCalled(t, "SearchCountryByName", mock.Anything, mock.Anything)
+}
+
+// TestCountryService_RefreshCountry_Peer tests that refreshes go to the owning peer, not upstream.
+func TestCountryService_RefreshCountry_Peer(t *testing.T) {
+	mockClient := new(MockClient)
+	mockCache := new(MockCache)
+	germany := &model.Country{Name: "Germany", Code: "DEU"}
+	mockCache.On("Set", "DEU", germany).Return()
+
+	service := NewCountryService(mockClient, mockCache, WithPeers(remotePicker{peerFunc(
+		func(ctx context.Context, key string) (*model.Country, error) {
+			return germany, nil
+		})}))
+
+	country, err := service.RefreshCountry(context.Background(), "Germany")
+
+	assert.NoError(t, err)
+	assert.Equal(t, germany, country)
+	mockCache.AssertExpectations(t)
+	mockClient.AssertNotCalled(t, "SearchCountryByName", mock.Anything, mock.Anything)
+}
+
+// TestCountryService_SearchCountry_PeerErrors tests that only an unreachable peer falls back to upstream.
+func TestCountryService_SearchCountry_PeerErrors(t *testing.T) {
+	mockClient := new(MockClient)
+	mockCache := new(MockCache)
+	mockCache.On("Get", mock.Anything).Return(nil, false)
+	mockCache.On("Set", mock.Anything, mock.Anything).Return()
+	mockClient.On("SearchCountryByName", mock.Anything, "Germany").
+		Return([]model.RESTCountryResponse{{Name: model.CountryName{Common: "Germany"}}}, nil)
+
+	peerErr := errors.New("connection refused")
+	service := NewCountryService(mockClient, mockCache, WithPeers(remotePicker{peerFunc(
+		func(ctx context.Context, key string) (*model.Country, error) {
+			return nil, peerErr
+		})}))
+
+	country, err := service.SearchCountry(context.Background(), "Germany")
+	assert.NoError(t, err)
+	assert.Equal(t, "Germany", country.Name)
+	mockClient.AssertNumberOfCalls(t, "SearchCountryByName", 1)
+
+	peerErr = fmt.Errorf("Fetch: %w: no country data found for name: atlantis", ErrPeerLookup)
+	_, err = service.SearchCountry(context.Background(), "Atlantis")
+	assert.ErrorIs(t, err, ErrPeerLookup)
+	mockClient.AssertNumberOfCalls(t, "SearchCountryByName", 1)
+
+	// Requests from peers never go back to a peer.
+	country, err = service.SearchCountry(LocalOnly(context.Background()), "Germany")
+	assert.NoError(t, err)
+	assert.Equal(t, "Germany", country.Name)
+	mockClient.AssertNumberOfCalls(t, "SearchCountryByName", 2)
+}
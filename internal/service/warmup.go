@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sj1815/golang-country-search/internal/cache"
+	"github.com/sj1815/golang-country-search/internal/client"
+	"github.com/sj1815/golang-country-search/internal/logging"
+	"github.com/sj1815/golang-country-search/internal/model"
+)
+
+// DefaultWarmupConcurrency is the number of names fetched in parallel during warm-up.
+const DefaultWarmupConcurrency = 4
+
+// WarmupOptions selects the countries Warmup loads into the cache.
+type WarmupOptions struct {
+	// Names are looked up one by one, exactly as searches for them would be.
+	Names []string
+	// Codes are ISO 3166-1 alpha-2 or alpha-3 codes fetched in a single request.
+	Codes []string
+	// All fetches every country in a single request.
+	All bool
+	// Concurrency bounds the name lookups in flight. Defaults to DefaultWarmupConcurrency.
+	Concurrency int
+	// Aliases learns the names of the countries loaded, which are cached under their
+	// alpha-3 codes; it should be the index the country service uses. Defaults to a new index.
+	Aliases *Aliases
+	// Logger receives progress reports. Defaults to slog.Default().
+	Logger *slog.Logger
+}
+
+// WarmupResult summarises a warm-up.
+type WarmupResult struct {
+	// Loaded counts the countries stored in the cache.
+	Loaded int
+	// Failed lists the names, codes or "all" whose lookups failed.
+	Failed []string
+}
+
+// Warmup prefetches countries into c so the first searches after a deploy are served from
+// the cache. Progress is logged as lookups complete. Warmup stops early when ctx is done;
+// the lookups still pending are reported as failed.
+func Warmup(ctx context.Context, cl client.CountryClient, c cache.Cache[string, *model.Country], opts WarmupOptions) WarmupResult {
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultWarmupConcurrency
+	}
+	aliases := opts.Aliases
+	if aliases == nil {
+		aliases = NewAliases()
+	}
+
+	start := time.Now()
+	total := len(opts.Names)
+	if len(opts.Codes) > 0 {
+		total++
+	}
+	if opts.All {
+		total++
+	}
+	logger.InfoContext(ctx, "cache warm-up started", slog.Int("lookups", total))
+
+	var (
+		mu     sync.Mutex
+		result WarmupResult
+		done   int
+	)
+	// report records one finished lookup, logging progress at every 10% step.
+	report := func(label string, countries []*model.Country, keys []string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		done++
+		if err != nil {
+			result.Failed = append(result.Failed, label)
+			logger.WarnContext(ctx, "cache warm-up lookup failed", slog.String("lookup", label), logging.Error(err))
+		} else {
+			for i, country := range countries {
+				c.Set(keys[i], country)
+			}
+			result.Loaded += len(countries)
+		}
+		if done == total || done*10/total > (done-1)*10/total {
+			logger.InfoContext(ctx, "cache warm-up progress",
+				slog.Int("done", done), slog.Int("total", total), slog.Int("loaded", result.Loaded))
+		}
+	}
+
+	// bulk caches every country in a list response.
+	bulk := func(label string, fetch func(context.Context) ([]model.RESTCountryResponse, error)) {
+		response, err := fetch(ctx)
+		countries := make([]*model.Country, 0, len(response))
+		keys := make([]string, 0, len(response))
+		for _, r := range response {
+			if r.Name.Common == "" {
+				continue
+			}
+			countries = append(countries, transformToCountry(r))
+			keys = append(keys, aliases.cacheKey(NormalizeName(r.Name.Common), r))
+		}
+		report(label, countries, keys, err)
+	}
+
+	if opts.All {
+		bulk("all", cl.ListAllCountries)
+	}
+	if len(opts.Codes) > 0 {
+		bulk("codes:"+strings.Join(opts.Codes, ","), func(ctx context.Context) ([]model.RESTCountryResponse, error) {
+			return cl.ListCountriesByCode(ctx, opts.Codes)
+		})
+	}
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, name := range opts.Names {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			report(name, nil, nil, ctx.Err())
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+
+			// Resolve the name as SearchCountry would, so aliases and codes work here too.
+			term := NormalizeName(name)
+			var response []model.RESTCountryResponse
+			var err error
+			if code, ok := aliases.Resolve(term); ok {
+				response, err = cl.ListCountriesByCode(ctx, []string{code})
+			} else {
+				response, err = cl.SearchCountryByName(ctx, name)
+			}
+			if err == nil && len(response) == 0 {
+				err = fmt.Errorf("no country data found for name: %s", name)
+			}
+			if err != nil {
+				report(name, nil, nil, err)
+				return
+			}
+			key := aliases.cacheKey(term, response[0])
+			report(name, []*model.Country{transformToCountry(response[0])}, []string{key}, nil)
+		}()
+	}
+	wg.Wait()
+
+	logger.InfoContext(ctx, "cache warm-up finished",
+		slog.Int("loaded", result.Loaded), slog.Int("failed", len(result.Failed)), logging.Latency(time.Since(start)))
+	return result
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sj1815/golang-country-search/internal/cache"
+	"github.com/sj1815/golang-country-search/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func restCountry(name string) model.RESTCountryResponse {
+	var r model.RESTCountryResponse
+	r.Name.Common = name
+	return r
+}
+
+// TestWarmup tests that names, codes and the full list are all loaded into the cache.
+func TestWarmup(t *testing.T) {
+	mockClient := new(MockClient)
+	mockClient.On("SearchCountryByName", mock.Anything, "Germany").Return([]model.RESTCountryResponse{restCountry("Germany")}, nil)
+	mockClient.On("SearchCountryByName", mock.Anything, "Atlantis").Return(nil, errors.New("not found"))
+	mockClient.On("ListCountriesByCode", mock.Anything, []string{"fr", "esp"}).
+		Return([]model.RESTCountryResponse{restCountry("France"), restCountry("Spain")}, nil)
+	mockClient.On("ListAllCountries", mock.Anything).
+		Return([]model.RESTCountryResponse{restCountry("Peru"), restCountry("")}, nil)
+
+	c := cache.NewInMemoryCache[string, *model.Country]()
+	result := Warmup(context.Background(), mockClient, c, WarmupOptions{
+		Names: []string{"Germany", "Atlantis"},
+		Codes: []string{"fr", "esp"},
+		All:   true,
+	})
+
+	assert.Equal(t, 4, result.Loaded)
+	assert.Equal(t, []string{"Atlantis"}, result.Failed)
+	assert.ElementsMatch(t, []string{"france", "germany", "peru", "spain"}, c.Keys())
+
+	cached, ok := c.Get("germany")
+	require.True(t, ok)
+	assert.Equal(t, "Germany", cached.Name)
+	mockClient.AssertExpectations(t)
+}
+
+// TestWarmup_Concurrency tests that name lookups never exceed the concurrency limit.
+func TestWarmup_Concurrency(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	mockClient := new(MockClient)
+	mockClient.On("SearchCountryByName", mock.Anything, mock.Anything).
+		Run(func(mock.Arguments) {
+			n := inFlight.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			inFlight.Add(-1)
+		}).
+		Return([]model.RESTCountryResponse{restCountry("X")}, nil)
+
+	names := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	result := Warmup(context.Background(), mockClient, cache.NewInMemoryCache[string, *model.Country](), WarmupOptions{
+		Names:       names,
+		Concurrency: 2,
+	})
+
+	assert.Equal(t, len(names), result.Loaded)
+	assert.LessOrEqual(t, peak.Load(), int32(2))
+}
+
+// TestWarmup_Canceled tests that lookups not yet started are reported as failed once ctx is done.
+func TestWarmup_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	mockClient := new(MockClient)
+	mockClient.On("SearchCountryByName", mock.Anything, mock.Anything).Return(nil, context.Canceled).Maybe()
+
+	result := Warmup(ctx, mockClient, cache.NewInMemoryCache[string, *model.Country](), WarmupOptions{
+		Names:       []string{"a", "b", "c"},
+		Concurrency: 1,
+	})
+
+	assert.Zero(t, result.Loaded)
+	assert.Len(t, result.Failed, 3)
+}
@@ -0,0 +1,144 @@
+// Package tracing configures OpenTelemetry tracing and W3C trace context propagation.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// InstrumentationName identifies the tracer used by this service's packages.
+const InstrumentationName = "github.com/sj1815/golang-country-search"
+
+// Supported exporters.
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+	ExporterOTLP   = "otlp"
+)
+
+// Propagator reads and writes W3C traceparent/tracestate and baggage headers.
+var Propagator propagation.TextMapPropagator = propagation.NewCompositeTextMapPropagator(
+	propagation.TraceContext{},
+	propagation.Baggage{},
+)
+
+// Options configures the tracer provider built by Setup.
+type Options struct {
+	// Exporter selects where spans are sent: none, stdout, file or otlp.
+	Exporter string
+	// Endpoint is the OTLP/HTTP collector URL. Empty uses the OTEL_EXPORTER_OTLP_* environment.
+	Endpoint string
+	// FilePath is the file spans are appended to when Exporter is "file".
+	FilePath string
+	// SampleRatio is the fraction of new traces that are sampled, between 0 and 1.
+	// Child spans always follow their parent's sampling decision.
+	SampleRatio float64
+	// ServiceName is reported as the service.name resource attribute.
+	ServiceName string
+}
+
+// Provider wraps a tracer provider with the function that flushes and stops it.
+type Provider struct {
+	trace.TracerProvider
+	shutdown func(context.Context) error
+}
+
+// Shutdown flushes pending spans and releases exporter resources.
+func (p *Provider) Shutdown(ctx context.Context) error {
+	if p.shutdown == nil {
+		return nil
+	}
+	return p.shutdown(ctx)
+}
+
+// Noop returns a provider that records nothing, so tests and disabled setups need no collector.
+func Noop() *Provider {
+	return &Provider{TracerProvider: noop.NewTracerProvider()}
+}
+
+// Setup builds a tracer provider for the configured exporter. The "none" exporter (or an
+// empty one) yields a no-op provider.
+func Setup(ctx context.Context, opts Options) (*Provider, error) {
+	var (
+		exporter sdktrace.SpanExporter
+		closer   io.Closer
+		err      error
+	)
+
+	switch strings.ToLower(opts.Exporter) {
+	case "", ExporterNone:
+		return Noop(), nil
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterFile:
+		var f *os.File
+		f, err = os.OpenFile(opts.FilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("Setup: failed to open trace file: %w", err)
+		}
+		closer = f
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(f))
+	case ExporterOTLP:
+		var clientOpts []otlptracehttp.Option
+		if opts.Endpoint != "" {
+			clientOpts = append(clientOpts, otlptracehttp.WithEndpointURL(opts.Endpoint))
+		}
+		exporter, err = otlptracehttp.New(ctx, clientOpts...)
+	default:
+		return nil, fmt.Errorf("Setup: unknown trace exporter %q", opts.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Setup: failed to create %s exporter: %w", opts.Exporter, err)
+	}
+
+	tp := NewProvider(sdktrace.NewBatchSpanProcessor(exporter), opts)
+
+	return &Provider{
+		TracerProvider: tp,
+		shutdown: func(ctx context.Context) error {
+			err := tp.Shutdown(ctx)
+			if closer != nil {
+				if cerr := closer.Close(); err == nil {
+					err = cerr
+				}
+			}
+			return err
+		},
+	}, nil
+}
+
+// NewProvider builds an SDK tracer provider around processor using the sampling and
+// resource settings in opts. It is exported so tests can plug in an in-memory recorder.
+func NewProvider(processor sdktrace.SpanProcessor, opts Options) *sdktrace.TracerProvider {
+	serviceName := opts.ServiceName
+	if serviceName == "" {
+		serviceName = "country-search"
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(processor),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	)
+}
+
+// Tracer returns the service tracer from tp, falling back to a no-op tracer when tp is nil.
+func Tracer(tp trace.TracerProvider) trace.Tracer {
+	if tp == nil {
+		tp = noop.NewTracerProvider()
+	}
+	return tp.Tracer(InstrumentationName)
+}
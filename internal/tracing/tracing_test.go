@@ -0,0 +1,90 @@
+package tracing
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// TestSetup_None tests that the default exporter produces a no-op provider.
+func TestSetup_None(t *testing.T) {
+	for _, exporter := range []string{"", ExporterNone} {
+		p, err := Setup(context.Background(), Options{Exporter: exporter})
+		require.NoError(t, err)
+
+		_, span := Tracer(p).Start(context.Background(), "noop")
+		assert.False(t, span.IsRecording())
+		span.End()
+		assert.NoError(t, p.Shutdown(context.Background()))
+	}
+}
+
+// TestSetup_File tests that spans are written to the configured file on shutdown.
+func TestSetup_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spans.json")
+
+	p, err := Setup(context.Background(), Options{Exporter: ExporterFile, FilePath: path, SampleRatio: 1})
+	require.NoError(t, err)
+
+	_, span := Tracer(p).Start(context.Background(), "file-span")
+	span.End()
+	require.NoError(t, p.Shutdown(context.Background()))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "file-span")
+	assert.Contains(t, string(data), "country-search")
+}
+
+// TestSetup_OTLP tests that the OTLP exporter can be built without a running collector.
+func TestSetup_OTLP(t *testing.T) {
+	p, err := Setup(context.Background(), Options{Exporter: ExporterOTLP, Endpoint: "http://127.0.0.1:4318", SampleRatio: 1})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_ = p.Shutdown(ctx)
+}
+
+// TestSetup_Errors tests invalid exporter settings.
+func TestSetup_Errors(t *testing.T) {
+	_, err := Setup(context.Background(), Options{Exporter: "jaeger-thrift"})
+	assert.ErrorContains(t, err, "unknown trace exporter")
+
+	_, err = Setup(context.Background(), Options{Exporter: ExporterFile, FilePath: filepath.Join(t.TempDir(), "missing", "spans.json")})
+	assert.ErrorContains(t, err, "failed to open trace file")
+}
+
+// TestNewProvider_Sampling tests that the sample ratio applies to root spans only.
+func TestNewProvider_Sampling(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := NewProvider(recorder, Options{SampleRatio: 0})
+	tracer := tp.Tracer("test")
+
+	_, root := tracer.Start(context.Background(), "root")
+	root.End()
+	assert.Empty(t, recorder.Ended())
+
+	sampled := tracetest.NewSpanRecorder()
+	tp = NewProvider(sampled, Options{SampleRatio: 1})
+	_, root = tp.Tracer("test").Start(context.Background(), "root")
+	root.End()
+	assert.Len(t, sampled.Ended(), 1)
+	assert.Equal(t, "country-search", attrValue(sampled.Ended()[0], "service.name"))
+}
+
+// attrValue returns a resource attribute of a recorded span.
+func attrValue(span sdktrace.ReadOnlySpan, key string) string {
+	for _, kv := range span.Resource().Attributes() {
+		if string(kv.Key) == key {
+			return kv.Value.AsString()
+		}
+	}
+	return ""
+}